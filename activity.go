@@ -0,0 +1,78 @@
+// activity.go
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Счетчик сообщений чата за последнюю минуту (по секундам), отдельно по каналам
+type ChatActivity struct {
+	mu       sync.Mutex
+	channels map[string]*activityWindow
+}
+
+type activityWindow struct {
+	buckets [60]int
+	seconds [60]int64
+}
+
+// Record учитывает одно сообщение в канале
+func (a *ChatActivity) Record(channel string, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	channel = normalizeChannel(channel)
+	w, ok := a.channels[channel]
+	if !ok {
+		if a.channels == nil {
+			a.channels = make(map[string]*activityWindow)
+		}
+		w = &activityWindow{}
+		a.channels[channel] = w
+	}
+
+	sec := now.Unix()
+	i := sec % int64(len(w.buckets))
+	if w.seconds[i] != sec {
+		w.seconds[i] = sec
+		w.buckets[i] = 0
+	}
+	w.buckets[i]++
+}
+
+// PerMinute возвращает число сообщений в канале за последние 60 секунд
+func (a *ChatActivity) PerMinute(channel string, now time.Time) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	w, ok := a.channels[normalizeChannel(channel)]
+	if !ok {
+		return 0
+	}
+
+	sec := now.Unix()
+	total := 0
+	for i, count := range w.buckets {
+		if sec-w.seconds[i] < int64(len(w.buckets)) {
+			total += count
+		}
+	}
+	return total
+}
+
+// Адаптивный cooldown: при referenceRate сообщений в минуту равен базовому,
+// растет и падает пропорционально активности чата канала в пределах [min, max]
+type AdaptiveCooldown struct {
+	activity      *ChatActivity
+	referenceRate int
+	min           time.Duration
+	max           time.Duration
+}
+
+// Duration возвращает текущий cooldown канала для базового значения base
+func (a *AdaptiveCooldown) Duration(channel string, base time.Duration, now time.Time) time.Duration {
+	rate := a.activity.PerMinute(channel, now)
+	scaled := time.Duration(float64(base) * float64(rate) / float64(a.referenceRate))
+	return min(max(scaled, a.min), a.max)
+}
@@ -0,0 +1,370 @@
+// admin.go
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// HTTP API для администрирования бота
+type AdminServer struct {
+	bot    *Bot
+	token  string
+	server *http.Server
+}
+
+func NewAdminServer(addr, token string, bot *Bot) *AdminServer {
+	s := &AdminServer{
+		bot:   bot,
+		token: token,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/audit", s.auth(s.handleAudit))
+	mux.HandleFunc("GET /api/usage", s.auth(s.handleUsage))
+	mux.HandleFunc("GET /api/pause", s.auth(s.handlePauseStatus))
+	mux.HandleFunc("POST /api/pause", s.auth(s.handlePause))
+	mux.HandleFunc("POST /api/resume", s.auth(s.handleResume))
+	mux.HandleFunc("GET /api/away", s.auth(s.handleAwayStatus))
+	mux.HandleFunc("POST /api/away", s.auth(s.handleAway))
+	mux.HandleFunc("DELETE /api/away", s.auth(s.handleBack))
+	mux.HandleFunc("GET /api/export", s.auth(s.handleExportDump))
+	mux.HandleFunc("POST /api/export", s.auth(s.handleExport))
+	mux.HandleFunc("POST /api/import", s.auth(s.handleImport))
+	mux.HandleFunc("POST /api/import/{format}", s.auth(s.handleForeignImport))
+	mux.HandleFunc("GET /api/export/{format}", s.auth(s.handleForeignExport))
+	mux.HandleFunc("GET /metrics", s.auth(s.handleMetrics))
+	mux.HandleFunc("GET /api/status", s.auth(s.handleStatus))
+	mux.HandleFunc("GET /api/version", s.auth(s.handleVersion))
+	mux.HandleFunc("POST /api/commands/{name}/enable", s.auth(s.handleCommandToggle(true)))
+	mux.HandleFunc("POST /api/commands/{name}/disable", s.auth(s.handleCommandToggle(false)))
+
+	// Проверка живости для оркестраторов, без токена
+	mux.HandleFunc("GET /health", s.handleHealth)
+
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return s
+}
+
+func (s *AdminServer) Start() {
+	go func() {
+		slog.Info("Admin API запущен", "addr", s.server.Addr)
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("Ошибка Admin API", "error", err)
+		}
+	}()
+}
+
+// auth проверяет Bearer-токен администратора
+func (s *AdminServer) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *AdminServer) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if s.bot.audit == nil {
+		writeJSONError(w, http.StatusNotFound, "audit log disabled")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := AuditFilter{
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+	}
+
+	var err error
+	if filter.Since, err = parseTimeParam(query.Get("since")); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Until, err = parseTimeParam(query.Get("until")); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	entries, err := s.bot.audit.Query(filter)
+	if err != nil {
+		slog.Error("Ошибка чтения журнала аудита", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "audit query failed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// handleMetrics отдает задержки и ошибки ответов в формате Prometheus
+func (s *AdminServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	latencyMetrics.WritePrometheus(w)
+}
+
+func (s *AdminServer) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if s.bot.usage == nil {
+		writeJSONError(w, http.StatusNotFound, "usage log disabled")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := UsageFilter{
+		Command: query.Get("command"),
+		Channel: query.Get("channel"),
+		User:    query.Get("user"),
+		Limit:   100,
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(query.Get("from")); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.To, err = parseTimeParam(query.Get("to")); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	count, err := s.bot.usage.Count(filter)
+	if err != nil {
+		slog.Error("Ошибка подсчета статистики", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "usage query failed")
+		return
+	}
+
+	records, err := s.bot.usage.Query(filter)
+	if err != nil {
+		slog.Error("Ошибка выборки статистики", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "usage query failed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"count":   count,
+		"records": records,
+	})
+}
+
+func (s *AdminServer) handlePauseStatus(w http.ResponseWriter, r *http.Request) {
+	paused, until := s.bot.pause.Paused()
+
+	status := map[string]any{"paused": paused}
+	if !until.IsZero() {
+		status["until"] = until.UTC()
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *AdminServer) handlePause(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Duration string `json:"duration"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+	}
+
+	var duration time.Duration
+	if req.Duration != "" {
+		var err error
+		duration, err = time.ParseDuration(req.Duration)
+		if err != nil || duration < 0 {
+			writeJSONError(w, http.StatusBadRequest, "invalid duration")
+			return
+		}
+	}
+
+	s.bot.pause.Pause(duration)
+	s.bot.audit.Record("admin-api", AuditPause, "", duration.String())
+	slog.Info("Бот поставлен на паузу через Admin API", "duration", duration)
+
+	s.handlePauseStatus(w, r)
+}
+
+func (s *AdminServer) handleResume(w http.ResponseWriter, r *http.Request) {
+	s.bot.pause.Resume()
+	s.bot.audit.Record("admin-api", AuditResume, "", "")
+	slog.Info("Пауза снята через Admin API")
+
+	s.handlePauseStatus(w, r)
+}
+
+// handleExportDump отдает текущий набор команд в YAML без записи на диск
+func (s *AdminServer) handleExportDump(w http.ResponseWriter, r *http.Request) {
+	export, err := s.bot.buildExport()
+	if err != nil {
+		slog.Error("Ошибка экспорта команд", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "export failed")
+		return
+	}
+
+	data, err := yaml.Marshal(export)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "export failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+	w.Write(data)
+}
+
+// handleExport записывает резервную копию команд в BACKUP_DIR
+func (s *AdminServer) handleExport(w http.ResponseWriter, r *http.Request) {
+	path, err := s.bot.exportCommands()
+	if err != nil {
+		slog.Error("Ошибка экспорта команд", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "export failed")
+		return
+	}
+
+	s.bot.audit.Record("admin-api", AuditExport, path, "")
+	writeJSON(w, http.StatusOK, map[string]string{"path": path})
+}
+
+func (s *AdminServer) handleImport(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL      string `json:"url"`
+		Strategy string `json:"strategy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.URL == "" {
+		writeJSONError(w, http.StatusBadRequest, "expected {\"url\": \"...\", \"strategy\": \"skip|overwrite\"}")
+		return
+	}
+	if req.Strategy == "" {
+		req.Strategy = ImportSkip
+	}
+
+	result, err := s.bot.importFromURL("admin-api", req.URL, req.Strategy)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleForeignImport принимает экспорт Nightbot или StreamElements в теле запроса
+func (s *AdminServer) handleForeignImport(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxImportSize+1))
+	if err != nil || len(data) > maxImportSize {
+		writeJSONError(w, http.StatusBadRequest, "body too large or unreadable")
+		return
+	}
+
+	imported, warnings, err := parseForeignCommands(r.PathValue("format"), data)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	strategy := r.URL.Query().Get("strategy")
+	if strategy == "" {
+		strategy = ImportSkip
+	}
+	result, err := s.bot.importCommands("admin-api", imported, strategy)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"result":   result,
+		"warnings": warnings,
+	})
+}
+
+// handleForeignExport отдает команды в формате Nightbot или StreamElements.
+// Пропущенные команды перечислены в заголовке X-Skipped-Commands (имена в URL-кодировке).
+func (s *AdminServer) handleForeignExport(w http.ResponseWriter, r *http.Request) {
+	data, warnings, skipped, err := exportForeignCommands(r.PathValue("format"), s.bot.Commands())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for _, warning := range warnings {
+		slog.Warn("Экспорт команд", "format", r.PathValue("format"), "warning", warning)
+	}
+	if len(skipped) > 0 {
+		names := make([]string, len(skipped))
+		for i, cmd := range skipped {
+			names[i] = url.PathEscape(cmd.Name)
+			slog.Warn("Команда не экспортирована", "format", r.PathValue("format"), "command", cmd.Name, "reason", cmd.Reason)
+		}
+		w.Header().Set("X-Skipped-Commands", strings.Join(names, ","))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// handleCommandToggle включает или выключает команду; имя можно указать без "!"
+func (s *AdminServer) handleCommandToggle(enabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		persistent, err := s.bot.setCommandEnabled("admin-api", name, enabled)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"command": name, "enabled": enabled, "persistent": persistent})
+	}
+}
+
+// parseTimeParam разбирает время в формате RFC3339 или дату YYYY-MM-DD
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Ошибка записи ответа Admin API", "error", err)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,211 @@
+// adminchat.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// isPrivileged сообщает, может ли пользователь управлять ботом из чата
+func isPrivileged(user twitch.User) bool {
+	return user.IsBroadcaster || user.IsMod
+}
+
+// Служебные команды модераторов
+var adminCommands = map[string]bool{"!bot": true, "!export": true, "!импорт": true, "!var": true, "!stop": true}
+
+// handleAdminCommand обрабатывает служебные команды модераторов.
+// Возвращает true, если сообщение было служебной командой.
+func (b *Bot) handleAdminCommand(ctx context.Context, message twitch.PrivateMessage, commandParts []string) bool {
+	if len(commandParts) == 0 || !isPrivileged(message.User) {
+		return false
+	}
+
+	// Подтверждения модераторам не ждут за длинными пастами
+	ctx = withSendPriority(ctx, PrioritySystem)
+
+	switch commandParts[0] {
+	case "!bot":
+		return b.handleBotCommand(ctx, message, commandParts)
+	case "!export":
+		b.handleExportCommand(ctx, message)
+		return true
+	case "!импорт":
+		b.handleImportCommand(ctx, message, commandParts[1:])
+		return true
+	case "!var":
+		b.handleVarCommand(ctx, message, commandParts[1:])
+		return true
+	case "!stop":
+		b.handleStopCommand(ctx, message)
+		return true
+	}
+	return false
+}
+
+// handleStopCommand прерывает отправку длинных паст по частям
+func (b *Bot) handleStopCommand(ctx context.Context, message twitch.PrivateMessage) {
+	conn := b.pool.For(message.Channel)
+	if conn == nil {
+		return
+	}
+	if cancelled := conn.CancelMultipart(message.Channel); cancelled > 0 {
+		slog.Info("Отправка паст прервана", "user", message.User.Name, "channel", message.Channel, "count", cancelled)
+		b.respond(ctx, message, "Отправка остановлена")
+	}
+}
+
+// handleBotCommand обрабатывает !bot pause/resume
+func (b *Bot) handleBotCommand(ctx context.Context, message twitch.PrivateMessage, commandParts []string) bool {
+	if len(commandParts) < 2 {
+		return false
+	}
+
+	switch strings.ToLower(commandParts[1]) {
+	case "pause":
+		var duration time.Duration
+		if len(commandParts) > 2 {
+			var err error
+			duration, err = time.ParseDuration(commandParts[2])
+			if err != nil || duration < 0 {
+				b.respond(ctx, message, "Использование: !bot pause [длительность, например 30m]")
+				return true
+			}
+		}
+
+		b.pause.Pause(duration)
+		b.audit.Record(message.User.Name, AuditPause, "", duration.String())
+		slog.Info("Бот поставлен на паузу", "user", message.User.Name, "duration", duration)
+
+		if duration > 0 {
+			b.respond(ctx, message, fmt.Sprintf("Бот на паузе на %s", duration))
+		} else {
+			b.respond(ctx, message, "Бот на паузе. Используйте !bot resume, чтобы продолжить")
+		}
+
+	case "away":
+		b.respond(ctx, message, b.awayFromChat(message.User.Name, commandParts[2:]))
+
+	case "back":
+		b.away.Clear()
+		b.audit.Record(message.User.Name, AuditBack, "", "")
+		slog.Info("Режим отсутствия выключен", "user", message.User.Name)
+		b.respond(ctx, message, "Режим отсутствия выключен")
+
+	case "status":
+		b.respond(ctx, message, b.statusText())
+
+	case "latency":
+		var command string
+		if len(commandParts) > 2 {
+			command = commandParts[2]
+		}
+		b.respond(ctx, message, latencyText(command))
+
+	case "reset":
+		// !bot reset [команда]: заново разрешить команды с max_uses_per_stream
+		var command string
+		if len(commandParts) > 2 {
+			command = commandParts[2]
+		}
+		b.streamUses.Reset(message.Channel, command)
+		b.audit.Record(message.User.Name, AuditUsesReset, command, message.Channel)
+		slog.Info("Счетчики вызовов за стрим сброшены", "user", message.User.Name, "channel", message.Channel, "command", command)
+		if command != "" {
+			b.respond(ctx, message, fmt.Sprintf("Счетчик %s сброшен", command))
+		} else {
+			b.respond(ctx, message, "Счетчики вызовов за стрим сброшены")
+		}
+
+	case "softlaunch":
+		// !bot softlaunch on|off: ответы только пользователям из SOFT_LAUNCH_USERS
+		if len(commandParts) < 3 || (commandParts[2] != "on" && commandParts[2] != "off") {
+			b.respond(ctx, message, "Использование: !bot softlaunch on|off")
+			return true
+		}
+		enabled := commandParts[2] == "on"
+		b.softLaunch.SetEnabled(enabled)
+		b.audit.Record(message.User.Name, AuditSoftLaunch, "", commandParts[2])
+		slog.Info("Пробный запуск переключен", "user", message.User.Name, "enabled", enabled)
+		if enabled {
+			b.respond(ctx, message, "Пробный запуск: бот отвечает только пользователям из списка")
+		} else {
+			b.respond(ctx, message, "Пробный запуск выключен, бот отвечает всем")
+		}
+
+	case "enable", "disable":
+		// !bot enable|disable <команда>
+		if len(commandParts) < 3 {
+			b.respond(ctx, message, "Использование: !bot enable|disable <команда>")
+			return true
+		}
+		enabled := strings.ToLower(commandParts[1]) == "enable"
+		persistent, err := b.setCommandEnabled(message.User.Name, commandParts[2], enabled)
+		if err != nil {
+			b.respond(ctx, message, fmt.Sprintf("@%s, %v", message.User.Name, err))
+			return true
+		}
+		state := "выключена"
+		if enabled {
+			state = "включена"
+		}
+		reply := fmt.Sprintf("Команда %s %s", commandParts[2], state)
+		if !persistent {
+			reply += " до перезагрузки команд (база не настроена)"
+		}
+		b.respond(ctx, message, reply)
+
+	case "resume":
+		b.pause.Resume()
+		b.audit.Record(message.User.Name, AuditResume, "", "")
+		slog.Info("Пауза снята", "user", message.User.Name)
+		b.respond(ctx, message, "Бот снова работает")
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// handleExportCommand выгружает текущие команды в файл резервной копии
+func (b *Bot) handleExportCommand(ctx context.Context, message twitch.PrivateMessage) {
+	path, err := b.exportCommands()
+	if err != nil {
+		slog.Error("Ошибка экспорта команд", "error", err)
+		b.respond(ctx, message, "Не удалось выгрузить команды")
+		return
+	}
+
+	b.audit.Record(message.User.Name, AuditExport, path, "")
+	b.respond(ctx, message, fmt.Sprintf("Команды выгружены в %s", path))
+}
+
+// handleImportCommand импортирует пасты по ссылке: !импорт <url> [skip|overwrite]
+func (b *Bot) handleImportCommand(ctx context.Context, message twitch.PrivateMessage, args []string) {
+	if len(args) == 0 {
+		b.respond(ctx, message, "Использование: !импорт <ссылка> [skip|overwrite]")
+		return
+	}
+
+	strategy := ImportSkip
+	if len(args) > 1 {
+		strategy = strings.ToLower(args[1])
+	}
+
+	// Загрузка может занять время, не задерживаем обработку чата
+	go func() {
+		result, err := b.importFromURL(message.User.Name, args[0], strategy)
+		if err != nil {
+			slog.Warn("Ошибка импорта", "error", err, "url", args[0])
+			b.respond(ctx, message, fmt.Sprintf("Импорт не удался: %s", err))
+			return
+		}
+		b.respond(ctx, message, "Импорт: "+result.String())
+	}()
+}
@@ -0,0 +1,198 @@
+// alerts.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Виды оповещений
+const (
+	AlertSendFailure = "send_failure"
+	AlertAuth        = "auth"
+	AlertReconnect   = "reconnect"
+	AlertHelix       = "helix"
+)
+
+// Куда отправлять оповещения владельцу
+type AlertSink interface {
+	Name() string
+	Send(text string) error
+}
+
+// Оповещения владельца о сбоях. Одно оповещение каждого вида - не чаще
+// раза в interval, чтобы сбой не превратился в поток сообщений.
+type Alerter struct {
+	mu       sync.Mutex
+	sinks    []AlertSink
+	interval time.Duration
+	last     map[string]time.Time
+	events   map[string][]time.Time
+}
+
+func NewAlerter() *Alerter {
+	return &Alerter{
+		interval: 30 * time.Minute,
+		last:     make(map[string]time.Time),
+		events:   make(map[string][]time.Time),
+	}
+}
+
+// Оповещения процесса; без получателей только пишут в лог
+var alerts = NewAlerter()
+
+// Configure задает получателей и минимальный интервал между оповещениями одного вида
+func (a *Alerter) Configure(sinks []AlertSink, interval time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.sinks, a.interval = sinks, interval
+}
+
+// Alert отправляет оповещение, если оповещения этого вида давно не было
+func (a *Alerter) Alert(kind, text string) {
+	a.mu.Lock()
+	now := time.Now()
+	if now.Sub(a.last[kind]) < a.interval {
+		a.mu.Unlock()
+		return
+	}
+	a.last[kind] = now
+	sinks := a.sinks
+	a.mu.Unlock()
+
+	slog.Warn("Оповещение владельца", "kind", kind, "text", text)
+	for _, sink := range sinks {
+		go func(sink AlertSink) {
+			if err := sink.Send("⚠ twitch-paste-bot: " + text); err != nil {
+				slog.Error("Ошибка отправки оповещения", "sink", sink.Name(), "error", err)
+			}
+		}(sink)
+	}
+}
+
+// Count учитывает событие и оповещает, если за window их набралось threshold
+func (a *Alerter) Count(kind string, threshold int, window time.Duration, text string) {
+	a.mu.Lock()
+	now := time.Now()
+	fresh := a.events[kind][:0]
+	for _, t := range a.events[kind] {
+		if now.Sub(t) < window {
+			fresh = append(fresh, t)
+		}
+	}
+	fresh = append(fresh, now)
+	a.events[kind] = fresh
+	reached := len(fresh) >= threshold
+	if reached {
+		a.events[kind] = nil
+	}
+	a.mu.Unlock()
+
+	if reached {
+		a.Alert(kind, fmt.Sprintf("%s (%d за %s)", text, len(fresh), window))
+	}
+}
+
+// Оповещение в Discord через webhook
+type discordSink struct {
+	webhookURL string
+}
+
+func (s discordSink) Name() string { return "discord" }
+
+func (s discordSink) Send(text string) error {
+	return postAlertJSON(s.webhookURL, map[string]string{"content": text})
+}
+
+// Оповещение в Telegram через бота
+type telegramSink struct {
+	token  string
+	chatID string
+}
+
+func (s telegramSink) Name() string { return "telegram" }
+
+func (s telegramSink) Send(text string) error {
+	endpoint := "https://api.telegram.org/bot" + s.token + "/sendMessage"
+	return postAlertJSON(endpoint, map[string]string{"chat_id": s.chatID, "text": text})
+}
+
+// Оповещение личным сообщением в Twitch от учетной записи бота
+type whisperSink struct {
+	conn *Connection
+	to   string
+
+	mu   sync.Mutex
+	toID string
+}
+
+func (s *whisperSink) Name() string { return "whisper" }
+
+func (s *whisperSink) Send(text string) error {
+	if s.conn.helix == nil {
+		return fmt.Errorf("не задан TWITCH_CLIENT_ID")
+	}
+
+	s.mu.Lock()
+	toID := s.toID
+	s.mu.Unlock()
+	if toID == "" {
+		users, err := s.conn.helix.GetUsers(s.to)
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return fmt.Errorf("пользователь %s не найден", s.to)
+		}
+		toID = users[0].ID
+		s.mu.Lock()
+		s.toID = toID
+		s.mu.Unlock()
+	}
+
+	fromID, err := s.conn.selfID()
+	if err != nil {
+		return err
+	}
+	return s.conn.helix.SendWhisper(fromID, toID, text)
+}
+
+func postAlertJSON(endpoint string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации оповещения: %w", err)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("ошибка отправки оповещения: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("получатель оповещения вернул статус %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// alertSinksFromEnv собирает получателей оповещений из ALERT_*
+func alertSinksFromEnv(pool *ConnectionPool) []AlertSink {
+	var sinks []AlertSink
+	if webhook := getSecret("ALERT_DISCORD_WEBHOOK"); webhook != "" {
+		sinks = append(sinks, discordSink{webhookURL: webhook})
+	}
+	if token := getSecret("ALERT_TELEGRAM_TOKEN"); token != "" {
+		sinks = append(sinks, telegramSink{token: token, chatID: getEnv("ALERT_TELEGRAM_CHAT_ID", "")})
+	}
+	if to := getEnv("ALERT_WHISPER_TO", ""); to != "" {
+		sinks = append(sinks, &whisperSink{conn: pool.connections[0], to: normalizeChannel(to)})
+	}
+	return sinks
+}
@@ -0,0 +1,158 @@
+// audit.go
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Типы событий журнала аудита
+const (
+	AuditCommandAdd     = "command_add"
+	AuditCommandEdit    = "command_edit"
+	AuditCommandDelete  = "command_delete"
+	AuditCommandEnable  = "command_enable"
+	AuditCommandDisable = "command_disable"
+	AuditReload         = "reload"
+	AuditTokenRefresh   = "token_refresh"
+	AuditPause          = "pause"
+	AuditResume         = "resume"
+	AuditAway           = "away"
+	AuditBack           = "back"
+	AuditExport         = "export"
+	AuditVariableSet    = "variable_set"
+	AuditVariableDelete = "variable_delete"
+	AuditModeration     = "moderation"
+	AuditUsesReset      = "uses_reset"
+	AuditSoftLaunch     = "soft_launch"
+)
+
+// Запись журнала аудита: кто, когда и что изменил
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	Actor   string    `json:"actor"`
+	Action  string    `json:"action"`
+	Target  string    `json:"target,omitempty"`
+	Details string    `json:"details,omitempty"`
+}
+
+// Фильтр для выборки из журнала аудита
+type AuditFilter struct {
+	Since  time.Time
+	Until  time.Time
+	Actor  string
+	Action string
+	Limit  int
+}
+
+// Журнал аудита с дозаписью в файл (одна JSON-запись на строку)
+type AuditLog struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+func NewAuditLog(path string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия журнала аудита %s: %w", path, err)
+	}
+
+	return &AuditLog{
+		path: path,
+		file: file,
+	}, nil
+}
+
+// Record дописывает событие в журнал. Для nil-журнала ничего не делает,
+// чтобы вызывающему коду не нужно было проверять, включен ли аудит.
+func (a *AuditLog) Record(actor, action, target, details string) {
+	if a == nil {
+		return
+	}
+
+	entry := AuditEntry{
+		Time:    time.Now().UTC(),
+		Actor:   actor,
+		Action:  action,
+		Target:  target,
+		Details: details,
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		slog.Error("Ошибка сериализации записи аудита", "error", err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, err := a.file.Write(append(data, '\n')); err != nil {
+		slog.Error("Ошибка записи в журнал аудита", "error", err)
+	}
+}
+
+// Query возвращает записи журнала, подходящие под фильтр, в порядке записи.
+// При заданном Limit возвращаются последние Limit записей.
+func (a *AuditLog) Query(filter AuditFilter) ([]AuditEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	file, err := os.Open(a.path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения журнала аудита %s: %w", a.path, err)
+	}
+	defer file.Close()
+
+	entries := []AuditEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			slog.Warn("Поврежденная запись в журнале аудита", "error", err)
+			continue
+		}
+
+		if !filter.Since.IsZero() && entry.Time.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && entry.Time.After(filter.Until) {
+			continue
+		}
+		if filter.Actor != "" && entry.Actor != filter.Actor {
+			continue
+		}
+		if filter.Action != "" && entry.Action != filter.Action {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения журнала аудита %s: %w", a.path, err)
+	}
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+
+	return entries, nil
+}
+
+func (a *AuditLog) Close() error {
+	if a == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.file.Close()
+}
@@ -0,0 +1,182 @@
+// away.go
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Как часто отвечать одному пользователю сообщением об отсутствии
+const awayReplyInterval = 10 * time.Minute
+
+// Режим отсутствия: бот отвечает на упоминания одним сообщением и не
+// выполняет команды. Может начаться и закончиться по расписанию.
+type AwayState struct {
+	defaultMessage string
+
+	mu      sync.Mutex
+	enabled bool
+	message string
+	start   time.Time
+	end     time.Time
+}
+
+// Состояние режима отсутствия для Admin API
+type AwayStatus struct {
+	Enabled bool       `json:"enabled"`
+	Active  bool       `json:"active"`
+	Message string     `json:"message,omitempty"`
+	Start   *time.Time `json:"start,omitempty"`
+	End     *time.Time `json:"end,omitempty"`
+}
+
+func NewAwayState(defaultMessage string) *AwayState {
+	return &AwayState{defaultMessage: defaultMessage}
+}
+
+// Set включает режим с start (нулевое - сразу) до end (нулевое - до отключения).
+// Пустое сообщение заменяется сообщением по умолчанию.
+func (a *AwayState) Set(message string, start, end time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if message == "" {
+		message = a.defaultMessage
+	}
+	a.enabled, a.message, a.start, a.end = true, message, start, end
+}
+
+// Clear выключает режим
+func (a *AwayState) Clear() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.enabled, a.message, a.start, a.end = false, "", time.Time{}, time.Time{}
+}
+
+// Active сообщает, действует ли режим сейчас, и возвращает сообщение
+func (a *AwayState) Active(now time.Time) (bool, string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.enabled {
+		return false, ""
+	}
+	if !a.end.IsZero() && !now.Before(a.end) {
+		// Срок вышел - выключаемся, чтобы статус не показывал устаревший режим
+		a.enabled, a.message, a.start, a.end = false, "", time.Time{}, time.Time{}
+		slog.Info("Режим отсутствия закончился")
+		return false, ""
+	}
+	if now.Before(a.start) {
+		return false, ""
+	}
+	return true, a.message
+}
+
+// Status возвращает состояние режима
+func (a *AwayState) Status() AwayStatus {
+	active, _ := a.Active(time.Now())
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	status := AwayStatus{Enabled: a.enabled, Active: active, Message: a.message}
+	if !a.start.IsZero() {
+		start := a.start.UTC()
+		status.Start = &start
+	}
+	if !a.end.IsZero() {
+		end := a.end.UTC()
+		status.End = &end
+	}
+	return status
+}
+
+// awayMiddleware в режиме отсутствия отвечает на упоминания и не пропускает команды
+func awayMiddleware(b *Bot, mc *MessageContext, next func()) {
+	active, message := b.away.Active(mc.ReceivedAt)
+	if !active {
+		next()
+		return
+	}
+
+	if mc.Mentioned && b.awayReplies.Allow(normalizeChannel(mc.Message.Channel)+" "+strings.ToLower(mc.Message.User.Name)) {
+		b.respond(mc.Ctx, mc.Message, renderTemplate(message, map[string]string{
+			"user": mc.Message.User.Name,
+		}))
+	}
+}
+
+// awayFromChat включает режим по !bot away [длительность] [сообщение]
+func (b *Bot) awayFromChat(actor string, args []string) string {
+	var end time.Time
+	if len(args) > 0 {
+		if duration, err := time.ParseDuration(args[0]); err == nil && duration > 0 {
+			end = time.Now().Add(duration)
+			args = args[1:]
+		}
+	}
+
+	b.away.Set(strings.Join(args, " "), time.Time{}, end)
+	b.audit.Record(actor, AuditAway, "", strings.Join(args, " "))
+	slog.Info("Включен режим отсутствия", "user", actor, "until", end)
+
+	if end.IsZero() {
+		return "Режим отсутствия включен. Используйте !bot back, чтобы выключить"
+	}
+	return "Режим отсутствия включен до " + end.Format("02.01 15:04")
+}
+
+func (s *AdminServer) handleAwayStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.bot.away.Status())
+}
+
+func (s *AdminServer) handleAway(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Message  string    `json:"message"`
+		Start    time.Time `json:"start"`
+		End      time.Time `json:"end"`
+		Duration string    `json:"duration"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+	}
+
+	if req.Duration != "" {
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 || !req.End.IsZero() {
+			writeJSONError(w, http.StatusBadRequest, "invalid duration")
+			return
+		}
+		req.End = time.Now().Add(duration)
+		if !req.Start.IsZero() {
+			req.End = req.Start.Add(duration)
+		}
+	}
+	if !req.End.IsZero() && !req.Start.IsZero() && !req.End.After(req.Start) {
+		writeJSONError(w, http.StatusBadRequest, "end must be after start")
+		return
+	}
+
+	s.bot.away.Set(req.Message, req.Start, req.End)
+	s.bot.audit.Record("admin-api", AuditAway, "", req.Message)
+	slog.Info("Включен режим отсутствия через Admin API", "start", req.Start, "end", req.End)
+
+	s.handleAwayStatus(w, r)
+}
+
+func (s *AdminServer) handleBack(w http.ResponseWriter, r *http.Request) {
+	s.bot.away.Clear()
+	s.bot.audit.Record("admin-api", AuditBack, "", "")
+	slog.Info("Режим отсутствия выключен через Admin API")
+
+	s.handleAwayStatus(w, r)
+}
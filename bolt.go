@@ -0,0 +1,430 @@
+// bolt.go
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"gopkg.in/yaml.v3"
+)
+
+// Корзины bbolt
+var (
+	boltCommands    = []byte("commands")
+	boltFlags       = []byte("command_flags")
+	boltVariables   = []byte("variables")
+	boltUsage       = []byte("usage")
+	boltUsageCounts = []byte("usage_counts")
+	boltState       = []byte("state")
+)
+
+// Префикс ключей времени последнего ответа в корзине state: cooldown_last_used:<канал>
+const boltCooldownPrefix = "cooldown_last_used:"
+
+// Хранилище во встроенной базе bbolt (один файл, без CGO). Файл блокируется
+// процессом целиком, поэтому CLI-команды и replay работают при остановленном боте.
+type BoltStorage struct {
+	db *bolt.DB
+}
+
+func openBoltStorage(path string) (*BoltStorage, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		// bbolt блокирует файл целиком: пока бот запущен, CLI-команды его не откроют
+		return nil, fmt.Errorf("ошибка открытия базы %s: файл занят другим процессом. "+
+			"Остановите бота или используйте Admin API: POST /api/import/{format}, GET /api/export/{format}", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия базы %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{boltCommands, boltFlags, boltVariables, boltUsage, boltUsageCounts, boltState} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ошибка создания схемы базы %s: %w", path, err)
+	}
+
+	return &BoltStorage{db: db}, nil
+}
+
+func (s *BoltStorage) Commands() (CommandStorage, error) {
+	return &boltCommandStore{db: s.db}, nil
+}
+
+func (s *BoltStorage) Variables() (Variables, error) {
+	return &BoltVariables{db: s.db}, nil
+}
+
+func (s *BoltStorage) Usage() (UsageStorage, error) {
+	return &boltUsageLog{db: s.db}, nil
+}
+
+func (s *BoltStorage) Cooldown() (SharedCooldown, error) {
+	return &boltCooldown{db: s.db}, nil
+}
+
+func (s *BoltStorage) Close() error {
+	return s.db.Close()
+}
+
+// Команды в bbolt: имя -> YAML-определение, как в SQLite
+type boltCommandStore struct {
+	db *bolt.DB
+}
+
+// List возвращает все сохраненные команды по алфавиту
+func (s *boltCommandStore) List() ([]Command, error) {
+	var commands []Command
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltCommands).ForEach(func(name, definition []byte) error {
+			var cmd Command
+			if err := yaml.Unmarshal(definition, &cmd); err != nil {
+				return fmt.Errorf("поврежденная команда %s в базе: %w", name, err)
+			}
+			commands = append(commands, cmd)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения команд из базы: %w", err)
+	}
+	return commands, nil
+}
+
+// Save сохраняет или заменяет команду
+func (s *boltCommandStore) Save(cmd Command) error {
+	definition, err := yaml.Marshal(cmd)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации команды %s: %w", cmd.Command, err)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltCommands).Put([]byte(cmd.Command), definition)
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения команды %s: %w", cmd.Command, err)
+	}
+	return nil
+}
+
+// Delete удаляет команду
+func (s *boltCommandStore) Delete(name string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltCommands).Delete([]byte(name))
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка удаления команды %s: %w", name, err)
+	}
+	return nil
+}
+
+// DisabledFlags возвращает сохраненные переключатели команд
+func (s *boltCommandStore) DisabledFlags() (map[string]bool, error) {
+	flags := make(map[string]bool)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltFlags).ForEach(func(name, value []byte) error {
+			flags[string(name)] = len(value) == 1 && value[0] == 1
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения переключателей команд: %w", err)
+	}
+	return flags, nil
+}
+
+// SetDisabled сохраняет переключатель команды
+func (s *boltCommandStore) SetDisabled(name string, disabled bool) error {
+	value := []byte{0}
+	if disabled {
+		value[0] = 1
+	}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltFlags).Put([]byte(name), value)
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения переключателя команды %s: %w", name, err)
+	}
+	return nil
+}
+
+// Переменные {var} в bbolt. Чтение из bbolt дешевое, поэтому без кэша в памяти.
+type BoltVariables struct {
+	db *bolt.DB
+}
+
+// Get возвращает значение переменной
+func (v *BoltVariables) Get(name string) (string, bool) {
+	var value []byte
+	v.db.View(func(tx *bolt.Tx) error {
+		if data := tx.Bucket(boltVariables).Get([]byte(strings.ToLower(name))); data != nil {
+			value = append([]byte(nil), data...)
+		}
+		return nil
+	})
+	return string(value), value != nil
+}
+
+// Names возвращает отсортированные имена переменных
+func (v *BoltVariables) Names() []string {
+	names := []string{}
+	v.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltVariables).ForEach(func(name, _ []byte) error {
+			names = append(names, string(name))
+			return nil
+		})
+	})
+	return names
+}
+
+// Set задает значение переменной
+func (v *BoltVariables) Set(name, value string) error {
+	name = strings.ToLower(name)
+	if !validVariableName(name) {
+		return fmt.Errorf("неверное имя переменной %q", name)
+	}
+
+	err := v.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltVariables).Put([]byte(name), []byte(value))
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения переменной %s: %w", name, err)
+	}
+	return nil
+}
+
+// Add прибавляет delta к числовой переменной в одной транзакции
+func (v *BoltVariables) Add(name string, delta int) (int, error) {
+	name = strings.ToLower(name)
+	if !validVariableName(name) {
+		return 0, fmt.Errorf("неверное имя переменной %q", name)
+	}
+
+	errNotNumber := fmt.Errorf("переменная %s не число", name)
+	current := 0
+	err := v.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltVariables)
+		if data := bucket.Get([]byte(name)); data != nil {
+			var err error
+			if current, err = strconv.Atoi(string(data)); err != nil {
+				return errNotNumber
+			}
+		}
+		current += delta
+		return bucket.Put([]byte(name), []byte(strconv.Itoa(current)))
+	})
+	if errors.Is(err, errNotNumber) {
+		return 0, err
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка сохранения переменной %s: %w", name, err)
+	}
+	return current, nil
+}
+
+// Delete удаляет переменную
+func (v *BoltVariables) Delete(name string) error {
+	name = strings.ToLower(name)
+	err := v.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltVariables).Delete([]byte(name))
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка удаления переменной %s: %w", name, err)
+	}
+	return nil
+}
+
+// Журнал использования в bbolt. Ключ записи - время в миллисекундах и
+// порядковый номер, поэтому выборки за период читают только его. Счетчики по
+// командам ведутся отдельно, и !статистика не просматривает журнал целиком.
+type boltUsageLog struct {
+	db *bolt.DB
+}
+
+// usageKey возвращает ключ записи: время, затем номер для уникальности
+func usageKey(at time.Time, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(at.UnixMilli()))
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
+
+// Record сохраняет выполнение команды и увеличивает ее счетчик
+func (u *boltUsageLog) Record(rec UsageRecord) {
+	rec.LatencyMs = rec.Latency.Milliseconds()
+	data, err := json.Marshal(rec)
+	if err != nil {
+		slog.Error("Ошибка записи статистики", "error", err, "command", rec.Command)
+		return
+	}
+
+	err = u.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltUsage)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(usageKey(rec.Time, seq), data); err != nil {
+			return err
+		}
+
+		counts := tx.Bucket(boltUsageCounts)
+		count := make([]byte, 8)
+		if current := counts.Get([]byte(rec.Command)); len(current) == 8 {
+			binary.BigEndian.PutUint64(count, binary.BigEndian.Uint64(current)+1)
+		} else {
+			binary.BigEndian.PutUint64(count, 1)
+		}
+		return counts.Put([]byte(rec.Command), count)
+	})
+	if err != nil {
+		slog.Error("Ошибка записи статистики", "error", err, "command", rec.Command)
+	}
+}
+
+// scan обходит записи периода фильтра от новых к старым, пока fn возвращает true
+func (u *boltUsageLog) scan(filter UsageFilter, fn func(rec UsageRecord) bool) error {
+	err := u.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(boltUsage).Cursor()
+
+		var key, data []byte
+		if filter.To.IsZero() {
+			key, data = cursor.Last()
+		} else if key, data = cursor.Seek(usageKey(filter.To, 0)); key == nil {
+			key, data = cursor.Last()
+		} else {
+			key, data = cursor.Prev()
+		}
+
+		var from []byte
+		if !filter.From.IsZero() {
+			from = usageKey(filter.From, 0)
+		}
+		for ; key != nil; key, data = cursor.Prev() {
+			if from != nil && bytes.Compare(key, from) < 0 {
+				return nil
+			}
+			var rec UsageRecord
+			if err := json.Unmarshal(data, &rec); err != nil {
+				return err
+			}
+			rec.Time = rec.Time.UTC()
+			rec.Latency = time.Duration(rec.LatencyMs) * time.Millisecond
+			if filter.match(rec) && !fn(rec) {
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка чтения статистики: %w", err)
+	}
+	return nil
+}
+
+// Count возвращает количество выполнений, подходящих под фильтр
+func (u *boltUsageLog) Count(filter UsageFilter) (int, error) {
+	// Без периода, канала и пользователя хватает счетчиков
+	if filter.From.IsZero() && filter.To.IsZero() && filter.Channel == "" && filter.User == "" {
+		total := 0
+		err := u.db.View(func(tx *bolt.Tx) error {
+			counts := tx.Bucket(boltUsageCounts)
+			if filter.Command != "" {
+				if count := counts.Get([]byte(filter.Command)); len(count) == 8 {
+					total = int(binary.BigEndian.Uint64(count))
+				}
+				return nil
+			}
+			return counts.ForEach(func(_, count []byte) error {
+				if len(count) == 8 {
+					total += int(binary.BigEndian.Uint64(count))
+				}
+				return nil
+			})
+		})
+		if err != nil {
+			return 0, fmt.Errorf("ошибка подсчета статистики: %w", err)
+		}
+		return total, nil
+	}
+
+	count := 0
+	err := u.scan(filter, func(UsageRecord) bool {
+		count++
+		return true
+	})
+	return count, err
+}
+
+// Query возвращает последние выполнения, подходящие под фильтр (новые первыми)
+func (u *boltUsageLog) Query(filter UsageFilter) ([]UsageRecord, error) {
+	records := []UsageRecord{}
+	err := u.scan(filter, func(rec UsageRecord) bool {
+		records = append(records, rec)
+		return filter.Limit <= 0 || len(records) < filter.Limit
+	})
+	return records, err
+}
+
+// CountsByCommand возвращает количество выполнений каждой команды
+func (u *boltUsageLog) CountsByCommand() (map[string]int, error) {
+	counts := make(map[string]int)
+	err := u.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltUsageCounts).ForEach(func(command, count []byte) error {
+			if len(count) == 8 {
+				counts[string(command)] = int(binary.BigEndian.Uint64(count))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подсчета статистики: %w", err)
+	}
+	return counts, nil
+}
+
+// Время последнего ответа в bbolt, чтобы cooldown переживал перезапуск
+type boltCooldown struct {
+	db *bolt.DB
+}
+
+func (c *boltCooldown) LastUsed(channel string) (time.Time, error) {
+	var last time.Time
+	err := c.db.View(func(tx *bolt.Tx) error {
+		if data := tx.Bucket(boltState).Get([]byte(boltCooldownPrefix + channel)); len(data) == 8 {
+			last = time.UnixMilli(int64(binary.BigEndian.Uint64(data)))
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ошибка чтения cooldown: %w", err)
+	}
+	return last, nil
+}
+
+func (c *boltCooldown) MarkUsed(channel string, at time.Time, ttl time.Duration) error {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, uint64(at.UnixMilli()))
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltState).Put([]byte(boltCooldownPrefix+channel), data)
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения cooldown: %w", err)
+	}
+	return nil
+}
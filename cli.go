@@ -0,0 +1,149 @@
+// cli.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// runCLI выполняет подкоманду, если она указана. Возвращает false, если
+// нужно запустить бота как обычно.
+func runCLI(args []string) (bool, int) {
+	if len(args) == 0 {
+		return false, 0
+	}
+
+	var err error
+	switch args[0] {
+	case "import":
+		err = cliImport(args[1:])
+	case "export":
+		err = cliExport(args[1:])
+	case "setup":
+		err = cliSetup(args[1:])
+	case "replay":
+		err = cliReplay(args[1:])
+	default:
+		return false, 0
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Ошибка:", err)
+		return true, 1
+	}
+	return true, 0
+}
+
+// cliBot создает бота без подключения к Twitch: только команды, база и аудит
+func cliBot() (*Bot, func(), error) {
+	storage, err := storageFromEnv(false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if storage == nil {
+		return nil, nil, fmt.Errorf("DATABASE_FILE не задан")
+	}
+	store, err := storage.Commands()
+	if err != nil {
+		storage.Close()
+		return nil, nil, err
+	}
+
+	commandsFile := getEnv("COMMANDS_FILE", "commands.yaml")
+	commands, err := loadEffectiveCommands(commandsFile, store)
+	if err != nil {
+		storage.Close()
+		return nil, nil, err
+	}
+
+	var audit *AuditLog
+	if auditFile := getEnvOptional("AUDIT_LOG_FILE", "audit.log"); auditFile != "" {
+		if audit, err = NewAuditLog(auditFile); err != nil {
+			storage.Close()
+			return nil, nil, err
+		}
+	}
+
+	bot := &Bot{store: store, audit: audit, commands: commands, commandsFile: commandsFile}
+	bot.registerBuiltins()
+	return bot, func() {
+		audit.Close()
+		storage.Close()
+	}, nil
+}
+
+// cliImport: import -format nightbot|streamelements [-strategy skip|overwrite] файл
+func cliImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", FormatNightbot, "формат файла: nightbot, streamelements")
+	strategy := flags.String("strategy", ImportSkip, "команды с теми же именами: skip, overwrite")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("использование: import -format nightbot|streamelements [-strategy skip|overwrite] файл")
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла %s: %w", flags.Arg(0), err)
+	}
+	imported, warnings, err := parseForeignCommands(strings.ToLower(*format), data)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, "Предупреждение:", warning)
+	}
+
+	bot, closeBot, err := cliBot()
+	if err != nil {
+		return err
+	}
+	defer closeBot()
+
+	result, err := bot.importCommands("cli", imported, *strategy)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Импорт:", result.String())
+	return nil
+}
+
+// cliExport: export -format nightbot|streamelements [-o файл]
+func cliExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", FormatNightbot, "формат: nightbot, streamelements")
+	output := flags.String("o", "", "файл для записи (по умолчанию stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	bot, closeBot, err := cliBot()
+	if err != nil {
+		return err
+	}
+	defer closeBot()
+
+	data, warnings, skipped, err := exportForeignCommands(strings.ToLower(*format), bot.Commands())
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, "Предупреждение:", warning)
+	}
+	for _, cmd := range skipped {
+		fmt.Fprintf(os.Stderr, "Пропущена: %s (%s)\n", cmd.Name, cmd.Reason)
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(append(data, '\n'))
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		return fmt.Errorf("ошибка записи %s: %w", *output, err)
+	}
+	return nil
+}
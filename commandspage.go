@@ -0,0 +1,179 @@
+// commandspage.go
+package main
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Страница со списком команд для зрителей (GET /commands на PUBLIC_ADDR, без авторизации)
+var commandsPageTemplate = template.Must(template.New("commands").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Команды бота</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 960px; padding: 0 1em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border-bottom: 1px solid #ddd; padding: .5em; text-align: left; vertical-align: top; }
+td.text { white-space: pre-wrap; word-break: break-word; }
+.muted { color: #777; }
+</style>
+</head>
+<body>
+<h1>Команды бота</h1>
+<p class="muted">Cooldown: {{.Cooldown}}{{if .Adaptive}} (меняется с активностью чата){{end}}. Обновлено {{.Generated}}.</p>
+{{range .Categories}}
+<h2>{{.Name}}</h2>
+<table>
+<tr><th>Команда</th><th>Текст</th><th>Ограничения</th></tr>
+{{range .Commands}}
+<tr>
+<td><code>{{.Name}}</code>{{if .Description}}<br><span class="muted">{{.Description}}</span>{{end}}</td>
+<td class="text">{{.Text}}</td>
+<td>{{range .Limits}}{{.}}<br>{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
+
+type commandsPageRow struct {
+	Name        string
+	Description string
+	Text        string
+	Limits      []string
+}
+
+type commandsPageCategory struct {
+	Name     string
+	Commands []commandsPageRow
+}
+
+// commandLimits описывает расписание, доступ и задержку команды
+func commandLimits(cmd *Command) []string {
+	var limits []string
+	if cmd.OnlyBetween != "" {
+		limits = append(limits, "время: "+cmd.OnlyBetween)
+	}
+	if len(cmd.Days) > 0 {
+		limits = append(limits, "дни: "+strings.Join(cmd.Days, ", "))
+	}
+	if cmd.Requires != "" {
+		limits = append(limits, "только "+requirementNames[cmd.Requires])
+	}
+	if cmd.RespondAs == DeliverWhisper {
+		limits = append(limits, "ответ в личные сообщения")
+	}
+	return limits
+}
+
+// Публичный HTTP-сервер для зрителей. Отделен от Admin API, чтобы страницу
+// можно было открыть наружу, не открывая административные эндпоинты.
+type PublicServer struct {
+	bot    *Bot
+	server *http.Server
+}
+
+func NewPublicServer(addr string, bot *Bot) *PublicServer {
+	s := &PublicServer{bot: bot}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /commands", s.handleCommandsPage)
+
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return s
+}
+
+func (s *PublicServer) Start() {
+	go func() {
+		slog.Info("Страница команд запущена", "addr", s.server.Addr)
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("Ошибка публичного сервера", "error", err)
+		}
+	}()
+}
+
+// pageChannel выбирает канал, cooldown которого показывает страница:
+// ?channel=..., а у бота с одним каналом - этот канал
+func (s *PublicServer) pageChannel(r *http.Request) string {
+	if channel := r.URL.Query().Get("channel"); channel != "" {
+		return channel
+	}
+	if s.bot.pool != nil {
+		if channels := s.bot.pool.Channels(); len(channels) == 1 {
+			return channels[0]
+		}
+	}
+	return ""
+}
+
+// handleCommandsPage отдает страницу, собранную из текущего набора команд,
+// поэтому после перезагрузки или импорта она сразу актуальна
+func (s *PublicServer) handleCommandsPage(w http.ResponseWriter, r *http.Request) {
+	commands := s.bot.Commands()
+
+	grouped := make(map[string][]commandsPageRow)
+	for name, cmd := range commands {
+		if cmd.Disabled {
+			continue
+		}
+		category := cmd.Category
+		if category == "" {
+			category = uncategorized
+		}
+		grouped[category] = append(grouped[category], commandsPageRow{
+			Name:        name,
+			Description: cmd.Description,
+			Text:        cmd.Text,
+			Limits:      commandLimits(cmd),
+		})
+	}
+
+	names := make(map[string][]string, len(grouped))
+	for category := range grouped {
+		names[category] = nil
+	}
+
+	var categories []commandsPageCategory
+	for _, category := range sortedCategories(names) {
+		rows := grouped[category]
+		sort.Slice(rows, func(i, j int) bool { return rows[i].Name < rows[j].Name })
+		categories = append(categories, commandsPageCategory{Name: category, Commands: rows})
+	}
+
+	// Без канала показываем базовое значение: адаптивный cooldown у каналов разный
+	cooldown := s.bot.cooldown.duration
+	if channel := s.pageChannel(r); channel != "" {
+		cooldown = s.bot.cooldown.Duration(channel)
+	}
+
+	data := struct {
+		Cooldown   time.Duration
+		Adaptive   bool
+		Generated  string
+		Categories []commandsPageCategory
+	}{
+		Cooldown:   cooldown.Round(time.Second),
+		Adaptive:   s.bot.cooldown.adaptive != nil,
+		Generated:  time.Now().Format("02.01.2006 15:04"),
+		Categories: categories,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := commandsPageTemplate.Execute(w, data); err != nil {
+		slog.Warn("Ошибка формирования страницы команд", "error", err)
+	}
+}
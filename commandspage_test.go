@@ -0,0 +1,56 @@
+// commandspage_test.go
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Страница команд живет на публичном сервере, а не в Admin API
+func TestCommandsPageServers(t *testing.T) {
+	activity := &ChatActivity{}
+	now := time.Now()
+	for range 60 {
+		activity.Record("канал", now)
+	}
+	cooldown := NewGlobalCooldownManager(30 * time.Second)
+	cooldown.adaptive = &AdaptiveCooldown{activity: activity, referenceRate: 30, min: 5 * time.Second, max: 2 * time.Minute}
+
+	b := &Bot{
+		cooldown: cooldown,
+		commands: map[string]*Command{
+			"!паста":  {Command: "!паста", Text: "привет <чат>", Requires: RequiresVIP},
+			"!старая": {Command: "!старая", Text: "выключена", Disabled: true},
+		},
+	}
+
+	public := httptest.NewRecorder()
+	NewPublicServer("", b).server.Handler.ServeHTTP(public, httptest.NewRequest(http.MethodGet, "/commands?channel=%23Канал", nil))
+	if public.Code != http.StatusOK {
+		t.Fatalf("код ответа %d", public.Code)
+	}
+	body := public.Body.String()
+	for _, want := range []string{"!паста", "привет &lt;чат&gt;", "только VIP", "Cooldown: 1m0s (меняется с активностью чата)"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("на странице нет %q", want)
+		}
+	}
+	if strings.Contains(body, "!старая") {
+		t.Error("выключенная команда попала на страницу")
+	}
+
+	admin := httptest.NewRecorder()
+	NewAdminServer("", "token", b).server.Handler.ServeHTTP(admin, httptest.NewRequest(http.MethodGet, "/commands", nil))
+	if admin.Code != http.StatusNotFound {
+		t.Errorf("Admin API отдает страницу команд: код %d", admin.Code)
+	}
+
+	api := httptest.NewRecorder()
+	NewPublicServer("", b).server.Handler.ServeHTTP(api, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	if api.Code != http.StatusNotFound {
+		t.Errorf("публичный сервер отдает Admin API: код %d", api.Code)
+	}
+}
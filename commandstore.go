@@ -0,0 +1,118 @@
+// commandstore.go
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Хранилище команд, добавленных во время работы (импорт, чат, Admin API).
+// Они накладываются поверх commands.yaml и переживают перезапуск.
+type CommandStore struct {
+	db *sql.DB
+}
+
+func NewCommandStore(db *sql.DB) (*CommandStore, error) {
+	schema := `
+CREATE TABLE IF NOT EXISTS commands (
+	command    TEXT PRIMARY KEY,
+	definition TEXT NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS command_flags (
+	command    TEXT PRIMARY KEY,
+	disabled   INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL
+);`
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("ошибка создания схемы команд: %w", err)
+	}
+
+	return &CommandStore{db: db}, nil
+}
+
+// List возвращает все сохраненные команды
+func (s *CommandStore) List() ([]Command, error) {
+	rows, err := s.db.Query("SELECT definition FROM commands ORDER BY command")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения команд из базы: %w", err)
+	}
+	defer rows.Close()
+
+	var commands []Command
+	for rows.Next() {
+		var definition string
+		if err := rows.Scan(&definition); err != nil {
+			return nil, fmt.Errorf("ошибка чтения команд из базы: %w", err)
+		}
+
+		var cmd Command
+		if err := yaml.Unmarshal([]byte(definition), &cmd); err != nil {
+			return nil, fmt.Errorf("поврежденная команда в базе: %w", err)
+		}
+		commands = append(commands, cmd)
+	}
+	return commands, rows.Err()
+}
+
+// Save сохраняет или заменяет команду
+func (s *CommandStore) Save(cmd Command) error {
+	definition, err := yaml.Marshal(cmd)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации команды %s: %w", cmd.Command, err)
+	}
+
+	_, err = s.db.Exec(
+		`INSERT INTO commands (command, definition, updated_at) VALUES (?, ?, ?)
+		 ON CONFLICT (command) DO UPDATE SET definition = excluded.definition, updated_at = excluded.updated_at`,
+		cmd.Command, string(definition), time.Now().Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения команды %s: %w", cmd.Command, err)
+	}
+	return nil
+}
+
+// Delete удаляет команду
+func (s *CommandStore) Delete(name string) error {
+	if _, err := s.db.Exec("DELETE FROM commands WHERE command = ?", name); err != nil {
+		return fmt.Errorf("ошибка удаления команды %s: %w", name, err)
+	}
+	return nil
+}
+
+// DisabledFlags возвращает сохраненные переключатели команд
+func (s *CommandStore) DisabledFlags() (map[string]bool, error) {
+	rows, err := s.db.Query("SELECT command, disabled FROM command_flags")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения переключателей команд: %w", err)
+	}
+	defer rows.Close()
+
+	flags := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		var disabled bool
+		if err := rows.Scan(&name, &disabled); err != nil {
+			return nil, fmt.Errorf("ошибка чтения переключателей команд: %w", err)
+		}
+		flags[name] = disabled
+	}
+	return flags, rows.Err()
+}
+
+// SetDisabled сохраняет переключатель команды
+func (s *CommandStore) SetDisabled(name string, disabled bool) error {
+	_, err := s.db.Exec(
+		`INSERT INTO command_flags (command, disabled, updated_at) VALUES (?, ?, ?)
+		 ON CONFLICT (command) DO UPDATE SET disabled = excluded.disabled, updated_at = excluded.updated_at`,
+		name, disabled, time.Now().Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения переключателя команды %s: %w", name, err)
+	}
+	return nil
+}
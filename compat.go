@@ -0,0 +1,370 @@
+// compat.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Форматы команд других ботов
+const (
+	FormatNightbot       = "nightbot"
+	FormatStreamElements = "streamelements"
+)
+
+// Команда из экспорта Nightbot (GET /1/commands)
+type nightbotCommand struct {
+	Name      string `json:"name"`
+	Message   string `json:"message"`
+	CoolDown  int    `json:"coolDown"`
+	UserLevel string `json:"userLevel"`
+}
+
+// Команда из экспорта StreamElements (GET /kappa/v2/bot/commands/{channel})
+type streamElementsCommand struct {
+	Command     string   `json:"command"`
+	Reply       string   `json:"reply"`
+	AccessLevel int      `json:"accessLevel"`
+	Enabled     bool     `json:"enabled"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Cooldown    struct {
+		User   int `json:"user"`
+		Global int `json:"global"`
+	} `json:"cooldown"`
+}
+
+// Уровни доступа Nightbot -> requires
+var nightbotLevels = map[string]string{
+	"everyone":   "",
+	"regular":    "",
+	"subscriber": RequiresSubscriber,
+	"twitch_vip": RequiresVIP,
+	"moderator":  RequiresModerator,
+	"owner":      RequiresBroadcaster,
+}
+
+// Уровни доступа StreamElements (accessLevel) <-> requires
+var streamElementsLevels = map[string]int{
+	"":                  100,
+	RequiresFollower:    100,
+	RequiresSubscriber:  250,
+	RequiresVIP:         400,
+	RequiresModerator:   500,
+	RequiresBroadcaster: 1500,
+}
+
+// validForeignFormat проверяет имя формата
+func validForeignFormat(format string) bool {
+	return format == FormatNightbot || format == FormatStreamElements
+}
+
+// parseForeignCommands разбирает экспорт Nightbot или StreamElements. Возвращает
+// команды и предупреждения о том, что перенести не удалось.
+func parseForeignCommands(format string, data []byte) ([]Command, []string, error) {
+	switch format {
+	case FormatNightbot:
+		return parseNightbot(data)
+	case FormatStreamElements:
+		return parseStreamElements(data)
+	}
+	return nil, nil, fmt.Errorf("неизвестный формат %q (nightbot, streamelements)", format)
+}
+
+func parseNightbot(data []byte) ([]Command, []string, error) {
+	// Ответ API или просто массив команд
+	var wrapped struct {
+		Commands []nightbotCommand `json:"commands"`
+	}
+	var list []nightbotCommand
+	if err := json.Unmarshal(data, &wrapped); err == nil && wrapped.Commands != nil {
+		list = wrapped.Commands
+	} else if err := json.Unmarshal(data, &list); err != nil {
+		return nil, nil, fmt.Errorf("ошибка разбора экспорта Nightbot: %w", err)
+	}
+
+	var commands []Command
+	var warnings []string
+	for _, nc := range list {
+		name := "!" + strings.TrimPrefix(nc.Name, "!")
+		text, unknown := convertNightbotVars(nc.Message)
+		for _, v := range unknown {
+			warnings = append(warnings, fmt.Sprintf("%s: переменная %s не поддерживается", name, v))
+		}
+
+		requires, ok := nightbotLevels[nc.UserLevel]
+		if !ok || nc.UserLevel == "regular" {
+			warnings = append(warnings, fmt.Sprintf("%s: уровень %q заменен на всех", name, nc.UserLevel))
+		}
+		if nc.CoolDown > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: cooldown %d с не переносится, действует общий", name, nc.CoolDown))
+		}
+
+		commands = append(commands, Command{Command: name, Text: text, Requires: requires})
+	}
+	return commands, warnings, nil
+}
+
+func parseStreamElements(data []byte) ([]Command, []string, error) {
+	var list []streamElementsCommand
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, nil, fmt.Errorf("ошибка разбора экспорта StreamElements: %w", err)
+	}
+
+	var commands []Command
+	var warnings []string
+	for _, sc := range list {
+		name := "!" + strings.TrimPrefix(sc.Command, "!")
+		if !sc.Enabled {
+			warnings = append(warnings, fmt.Sprintf("%s: команда выключена, пропущена", name))
+			continue
+		}
+
+		text, unknown := convertStreamElementsVars(sc.Reply)
+		for _, v := range unknown {
+			warnings = append(warnings, fmt.Sprintf("%s: переменная %s не поддерживается", name, v))
+		}
+		if sc.Cooldown.User > 0 || sc.Cooldown.Global > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: cooldown не переносится, действует общий", name))
+		}
+
+		cmd := Command{Command: name, Text: text, Requires: streamElementsRequirement(sc.AccessLevel)}
+		commands = append(commands, cmd)
+
+		// Синонимов у нас нет - заводим отдельные команды с тем же текстом
+		for _, alias := range sc.Aliases {
+			aliasCmd := cmd
+			aliasCmd.Command = "!" + strings.TrimPrefix(alias, "!")
+			commands = append(commands, aliasCmd)
+		}
+	}
+	return commands, warnings, nil
+}
+
+// streamElementsRequirement подбирает requires по accessLevel с округлением вниз
+func streamElementsRequirement(level int) string {
+	switch {
+	case level >= 1000:
+		return RequiresBroadcaster
+	case level >= 500:
+		return RequiresModerator
+	case level >= 400:
+		return RequiresVIP
+	case level >= 250:
+		return RequiresSubscriber
+	}
+	return ""
+}
+
+var (
+	nightbotVarRe       = regexp.MustCompile(`\$\(([a-zA-Z0-9_.]+)(?:\s+([^)]*))?\)`)
+	streamElementsVarRe = regexp.MustCompile(`\$\{([^}]*)\}`)
+	seRandomRangeRe     = regexp.MustCompile(`^random\.(-?\d+)-(-?\d+)$`)
+)
+
+// convertNightbotVars переводит $(user) и подобные в шаблоны бота.
+// Неизвестные переменные остаются как есть и возвращаются списком.
+func convertNightbotVars(text string) (string, []string) {
+	var unknown []string
+	result := nightbotVarRe.ReplaceAllStringFunc(text, func(match string) string {
+		parts := nightbotVarRe.FindStringSubmatch(match)
+		name, arg := strings.ToLower(parts[1]), strings.TrimSpace(parts[2])
+		switch {
+		case name == "user" && arg == "":
+			return "{user}"
+		case name == "touser" && arg == "":
+			return "{touser}"
+		case name == "channel" && arg == "":
+			return "{channel}"
+		case name == "query" && arg == "":
+			return "{query}"
+		case name == "time":
+			return templateCall("time", arg)
+		}
+		unknown = append(unknown, match)
+		return match
+	})
+	return result, unknown
+}
+
+// convertStreamElementsVars переводит ${user} и подобные в шаблоны бота
+func convertStreamElementsVars(text string) (string, []string) {
+	var unknown []string
+	result := streamElementsVarRe.ReplaceAllStringFunc(text, func(match string) string {
+		expr := strings.TrimSpace(match[2 : len(match)-1])
+		lower := strings.ToLower(expr)
+
+		switch {
+		case lower == "user" || lower == "sender" || lower == "user.name":
+			return "{user}"
+		case lower == "touser":
+			return "{touser}"
+		case lower == "channel":
+			return "{channel}"
+		case lower == "1:" || lower == "0:":
+			return "{query}"
+		case strings.HasPrefix(lower, "time."):
+			return templateCall("time", expr[len("time."):])
+		case strings.HasPrefix(lower, "random.pick "):
+			options := strings.Split(expr[len("random.pick "):], "'")
+			var picks []string
+			for i := 1; i < len(options); i += 2 {
+				picks = append(picks, options[i])
+			}
+			if len(picks) > 0 {
+				return templateCall("pick", picks...)
+			}
+		}
+		if m := seRandomRangeRe.FindStringSubmatch(lower); m != nil {
+			return "{randint " + m[1] + " " + m[2] + "}"
+		}
+
+		unknown = append(unknown, match)
+		return match
+	})
+	return result, unknown
+}
+
+// templateCall собирает вызов функции шаблона {name "arg" ...}
+func templateCall(name string, args ...string) string {
+	var call strings.Builder
+	call.WriteString("{" + name)
+	for _, arg := range args {
+		if arg != "" {
+			call.WriteString(" " + strconv.Quote(arg))
+		}
+	}
+	call.WriteString("}")
+	return call.String()
+}
+
+var templateCallRe = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// convertToForeignVars переводит шаблоны бота в переменные Nightbot или
+// StreamElements. Функции без аналога остаются как есть и возвращаются списком.
+func convertToForeignVars(format, text string) (string, []string) {
+	var unknown []string
+	result := templateCallRe.ReplaceAllStringFunc(text, func(match string) string {
+		args := splitTemplateArgs(match[1 : len(match)-1])
+		if len(args) == 0 {
+			return match
+		}
+		name, params := strings.ToLower(args[0]), args[1:]
+
+		if format == FormatNightbot {
+			switch {
+			case name == "user" || name == "touser" || name == "channel" || name == "query":
+				return "$(" + name + ")"
+			case name == "time" && len(params) <= 1:
+				return "$(time " + strings.Join(params, "") + ")"
+			}
+		} else {
+			switch {
+			case name == "user" || name == "touser" || name == "channel":
+				return "${" + name + "}"
+			case name == "query":
+				return "${1:}"
+			case name == "time" && len(params) == 1:
+				return "${time." + params[0] + "}"
+			case name == "pick" && len(params) > 0:
+				return "${random.pick '" + strings.Join(params, "' '") + "'}"
+			case name == "randint" && len(params) == 2:
+				return "${random." + params[0] + "-" + params[1] + "}"
+			}
+		}
+
+		unknown = append(unknown, match)
+		return match
+	})
+	return result, unknown
+}
+
+// Команда, которую нельзя выгрузить в формат другого бота
+type skippedCommand struct {
+	Name   string
+	Reason string
+}
+
+// foreignSkipReason объясняет, почему команду нельзя выгрузить как обычный текст
+func foreignSkipReason(format string, cmd *Command) string {
+	switch {
+	case cmd.Type == CommandTypeModeration:
+		return "команда модерации"
+	case len(cmd.Parts) > 0:
+		return fmt.Sprintf("последовательность из %d сообщений", len(cmd.Parts))
+	case cmd.Disabled && format == FormatNightbot:
+		return "команда выключена, а у Nightbot нет выключенных команд"
+	}
+	return ""
+}
+
+// exportForeignCommands выгружает команды в формате Nightbot или StreamElements.
+// Команды, которые там стали бы другим поведением, пропускаются и возвращаются в skipped.
+func exportForeignCommands(format string, commands map[string]*Command) (data []byte, warnings []string, skipped []skippedCommand, err error) {
+	if !validForeignFormat(format) {
+		return nil, nil, nil, fmt.Errorf("неизвестный формат %q (nightbot, streamelements)", format)
+	}
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var nightbot []nightbotCommand
+	var streamElements []streamElementsCommand
+
+	for _, name := range names {
+		cmd := commands[name]
+		if reason := foreignSkipReason(format, cmd); reason != "" {
+			skipped = append(skipped, skippedCommand{Name: name, Reason: reason})
+			continue
+		}
+
+		text, unknown := convertToForeignVars(format, cmd.Text)
+		for _, v := range unknown {
+			warnings = append(warnings, fmt.Sprintf("%s: %s не имеет аналога", name, v))
+		}
+		if cmd.OnlyBetween != "" || len(cmd.Days) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: расписание не переносится", name))
+		}
+
+		if format == FormatNightbot {
+			level := "everyone"
+			for nbLevel, requires := range nightbotLevels {
+				if requires == cmd.Requires && requires != "" {
+					level = nbLevel
+				}
+			}
+			if cmd.Requires == RequiresFollower {
+				warnings = append(warnings, fmt.Sprintf("%s: у Nightbot нет уровня follower", name))
+			}
+			nightbot = append(nightbot, nightbotCommand{Name: name, Message: text, UserLevel: level})
+			continue
+		}
+
+		sc := streamElementsCommand{
+			Command:     strings.TrimPrefix(name, "!"),
+			Reply:       text,
+			AccessLevel: streamElementsLevels[cmd.Requires],
+			Enabled:     !cmd.Disabled,
+		}
+		if cmd.Requires == RequiresFollower {
+			warnings = append(warnings, fmt.Sprintf("%s: у StreamElements нет уровня follower", name))
+		}
+		streamElements = append(streamElements, sc)
+	}
+
+	if format == FormatNightbot {
+		data, err = json.MarshalIndent(map[string]any{"commands": nightbot}, "", "  ")
+	} else {
+		data, err = json.MarshalIndent(streamElements, "", "  ")
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("ошибка сериализации команд: %w", err)
+	}
+	return data, warnings, skipped, nil
+}
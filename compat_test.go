@@ -0,0 +1,181 @@
+// compat_test.go
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestConvertNightbotVars(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		unknown []string
+	}{
+		{"Привет, $(user)!", "Привет, {user}!", nil},
+		{"$(touser) в $(channel): $(query)", "{touser} в {channel}: {query}", nil},
+		{"Время $(time Europe/Moscow)", `Время {time "Europe/Moscow"}`, nil},
+		{"$(urlfetch https://example.com)", "$(urlfetch https://example.com)", []string{"$(urlfetch https://example.com)"}},
+		{"без переменных", "без переменных", nil},
+	}
+	for _, tt := range tests {
+		got, unknown := convertNightbotVars(tt.in)
+		if got != tt.want || !reflect.DeepEqual(unknown, tt.unknown) {
+			t.Errorf("convertNightbotVars(%q) = %q, %v; ожидалось %q, %v", tt.in, got, unknown, tt.want, tt.unknown)
+		}
+	}
+}
+
+func TestConvertStreamElementsVars(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		unknown []string
+	}{
+		{"${user} и ${sender}", "{user} и {user}", nil},
+		{"${touser} ${channel} ${1:}", "{touser} {channel} {query}", nil},
+		{"${time.Europe/Moscow}", `{time "Europe/Moscow"}`, nil},
+		{"${random.pick 'да' 'нет'}", `{pick "да" "нет"}`, nil},
+		{"${random.1-100}", "{randint 1 100}", nil},
+		{"${random.-5-5}", "{randint -5 5}", nil},
+		{"${count}", "${count}", []string{"${count}"}},
+	}
+	for _, tt := range tests {
+		got, unknown := convertStreamElementsVars(tt.in)
+		if got != tt.want || !reflect.DeepEqual(unknown, tt.unknown) {
+			t.Errorf("convertStreamElementsVars(%q) = %q, %v; ожидалось %q, %v", tt.in, got, unknown, tt.want, tt.unknown)
+		}
+	}
+}
+
+func TestConvertToForeignVars(t *testing.T) {
+	tests := []struct {
+		format  string
+		in      string
+		want    string
+		unknown []string
+	}{
+		{FormatNightbot, "Привет, {user}: {query}", "Привет, $(user): $(query)", nil},
+		{FormatNightbot, `{time "Europe/Moscow"}`, "$(time Europe/Moscow)", nil},
+		{FormatNightbot, `{pick "а" "б"}`, `{pick "а" "б"}`, []string{`{pick "а" "б"}`}},
+		{FormatStreamElements, "{user} {query}", "${user} ${1:}", nil},
+		{FormatStreamElements, `{pick "да" "нет"}`, "${random.pick 'да' 'нет'}", nil},
+		{FormatStreamElements, "{randint 1 6}", "${random.1-6}", nil},
+		{FormatStreamElements, "{counter}", "{counter}", []string{"{counter}"}},
+	}
+	for _, tt := range tests {
+		got, unknown := convertToForeignVars(tt.format, tt.in)
+		if got != tt.want || !reflect.DeepEqual(unknown, tt.unknown) {
+			t.Errorf("convertToForeignVars(%s, %q) = %q, %v; ожидалось %q, %v", tt.format, tt.in, got, unknown, tt.want, tt.unknown)
+		}
+	}
+}
+
+// Переменные, у которых есть аналог, переживают выгрузку и загрузку обратно
+func TestForeignVarsRoundTrip(t *testing.T) {
+	for _, text := range []string{"{user} спрашивает {touser}: {query}", `{pick "да" "нет"} в {channel}`, "{randint 1 20}"} {
+		foreign, _ := convertToForeignVars(FormatStreamElements, text)
+		if back, unknown := convertStreamElementsVars(foreign); back != text || unknown != nil {
+			t.Errorf("StreamElements: %q -> %q -> %q", text, foreign, back)
+		}
+	}
+	for _, text := range []string{"{user} спрашивает {touser}: {query}", "{channel}"} {
+		foreign, _ := convertToForeignVars(FormatNightbot, text)
+		if back, unknown := convertNightbotVars(foreign); back != text || unknown != nil {
+			t.Errorf("Nightbot: %q -> %q -> %q", text, foreign, back)
+		}
+	}
+}
+
+func TestStreamElementsRequirement(t *testing.T) {
+	tests := map[int]string{0: "", 100: "", 250: RequiresSubscriber, 300: RequiresSubscriber, 400: RequiresVIP, 500: RequiresModerator, 1000: RequiresBroadcaster, 1500: RequiresBroadcaster}
+	for level, want := range tests {
+		if got := streamElementsRequirement(level); got != want {
+			t.Errorf("streamElementsRequirement(%d) = %q, ожидалось %q", level, got, want)
+		}
+	}
+}
+
+func TestParseForeignCommands(t *testing.T) {
+	nightbot := `{"commands": [{"name": "!привет", "message": "Привет, $(user)", "userLevel": "moderator", "coolDown": 5}]}`
+	commands, warnings, err := parseForeignCommands(FormatNightbot, []byte(nightbot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Command{{Command: "!привет", Text: "Привет, {user}", Requires: RequiresModerator}}
+	if !reflect.DeepEqual(commands, want) || len(warnings) != 1 {
+		t.Errorf("Nightbot: %+v, %v", commands, warnings)
+	}
+
+	streamElements := `[
+		{"command": "дс", "reply": "${user}, дискорд", "accessLevel": 100, "enabled": true, "aliases": ["discord"]},
+		{"command": "старая", "reply": "нет", "enabled": false}
+	]`
+	commands, warnings, err = parseForeignCommands(FormatStreamElements, []byte(streamElements))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Command{{Command: "!дс", Text: "{user}, дискорд"}, {Command: "!discord", Text: "{user}, дискорд"}}
+	if !reflect.DeepEqual(commands, want) || len(warnings) != 1 {
+		t.Errorf("StreamElements: %+v, %v", commands, warnings)
+	}
+
+	if _, _, err := parseForeignCommands("moobot", []byte("[]")); err == nil {
+		t.Error("принят неизвестный формат")
+	}
+	if _, _, err := parseForeignCommands(FormatStreamElements, []byte("{")); err == nil {
+		t.Error("принят битый JSON")
+	}
+}
+
+// Модерация и последовательности не становятся обычным текстом в чужом боте
+func TestExportForeignSkipped(t *testing.T) {
+	commands := map[string]*Command{
+		"!паста":  {Command: "!паста", Text: "привет, {user}"},
+		"!бан":    {Command: "!бан", Type: CommandTypeModeration, Action: "timeout", Text: "{touser} отдыхает"},
+		"!серия":  {Command: "!серия", Text: "раз два", Parts: []string{"раз", "два"}},
+		"!старая": {Command: "!старая", Text: "выключена", Disabled: true},
+	}
+
+	data, _, skipped, err := exportForeignCommands(FormatNightbot, commands)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nightbot struct{ Commands []nightbotCommand }
+	if err := json.Unmarshal(data, &nightbot); err != nil {
+		t.Fatal(err)
+	}
+	if len(nightbot.Commands) != 1 || nightbot.Commands[0].Message != "привет, $(user)" {
+		t.Errorf("Nightbot: %+v", nightbot.Commands)
+	}
+	if names := skippedNames(skipped); !reflect.DeepEqual(names, []string{"!бан", "!серия", "!старая"}) {
+		t.Errorf("Nightbot пропущены %v", names)
+	}
+
+	data, _, skipped, err = exportForeignCommands(FormatStreamElements, commands)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var streamElements []streamElementsCommand
+	if err := json.Unmarshal(data, &streamElements); err != nil {
+		t.Fatal(err)
+	}
+	if len(streamElements) != 2 || streamElements[0].Command != "паста" || !streamElements[0].Enabled || streamElements[1].Enabled {
+		t.Errorf("StreamElements: %+v", streamElements)
+	}
+	if names := skippedNames(skipped); !reflect.DeepEqual(names, []string{"!бан", "!серия"}) {
+		t.Errorf("StreamElements пропущены %v", names)
+	}
+}
+
+func skippedNames(skipped []skippedCommand) []string {
+	var names []string
+	for _, cmd := range skipped {
+		if cmd.Reason == "" {
+			return nil
+		}
+		names = append(names, cmd.Name)
+	}
+	return names
+}
@@ -0,0 +1,206 @@
+// config.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Шаблоны системных сообщений. Пустое поле означает "взять значение уровнем выше".
+type TemplatesConfig struct {
+	CommandsHeader   string `yaml:"commands_header,omitempty"`
+	UnknownCommand   string `yaml:"unknown_command,omitempty"`
+	CooldownNotice   string `yaml:"cooldown_notice,omitempty"`
+	PermissionDenied string `yaml:"permission_denied,omitempty"`
+	// Ответ на команду, вызванную несколько раз подряд (DUPLICATE_MODE=aggregate)
+	DuplicateAggregate string `yaml:"duplicate_aggregate,omitempty"`
+	// Ответ на !время
+	LocalTime string `yaml:"local_time,omitempty"`
+	// Ответ на !пасты, если задан COMMANDS_PAGE_URL
+	CommandsLink string `yaml:"commands_link,omitempty"`
+	// Сообщение о временном игноре флудящего пользователя (пусто - молча)
+	FloodNotice string `yaml:"flood_notice,omitempty"`
+	// Ответ на команду вне ее расписания (пусто - молча)
+	Unavailable string `yaml:"unavailable,omitempty"`
+	// Ответ на команду, исчерпавшую max_uses_per_stream (пусто - молча)
+	Exhausted string `yaml:"exhausted,omitempty"`
+	// Ответ команд с данными Twitch API, когда он недоступен и в кэше ничего нет
+	HelixUnavailable string `yaml:"helix_unavailable,omitempty"`
+	// Пометка к ответу из кэша при недоступном Twitch API, переменная {age}
+	StaleNote string `yaml:"stale_note,omitempty"`
+}
+
+// Шаблоны по умолчанию
+var defaultTemplates = TemplatesConfig{
+	CommandsHeader:   "Доступные команды: ",
+	UnknownCommand:   "@{user} Неизвестная команда. Используйте !пасты для списка команд.",
+	CooldownNotice:   "",
+	PermissionDenied: defaultDeniedMessage,
+
+	DuplicateAggregate: "{text} (запрошено {count} раз)",
+	LocalTime:          "У стримера сейчас {time} ({timezone})",
+	CommandsLink:       "@{user}, список команд: {url}",
+	Exhausted:          "@{user}, команда {command} исчерпана на сегодня",
+	HelixUnavailable:   "@{user}, Twitch API сейчас недоступен, попробуйте позже",
+	StaleNote:          " (данные {age} назад)",
+}
+
+// merge возвращает шаблоны, в которых пустые поля заполнены из fallback
+func (t TemplatesConfig) merge(fallback TemplatesConfig) TemplatesConfig {
+	if t.CommandsHeader == "" {
+		t.CommandsHeader = fallback.CommandsHeader
+	}
+	if t.UnknownCommand == "" {
+		t.UnknownCommand = fallback.UnknownCommand
+	}
+	if t.CooldownNotice == "" {
+		t.CooldownNotice = fallback.CooldownNotice
+	}
+	if t.PermissionDenied == "" {
+		t.PermissionDenied = fallback.PermissionDenied
+	}
+	if t.DuplicateAggregate == "" {
+		t.DuplicateAggregate = fallback.DuplicateAggregate
+	}
+	if t.LocalTime == "" {
+		t.LocalTime = fallback.LocalTime
+	}
+	if t.CommandsLink == "" {
+		t.CommandsLink = fallback.CommandsLink
+	}
+	if t.FloodNotice == "" {
+		t.FloodNotice = fallback.FloodNotice
+	}
+	if t.Unavailable == "" {
+		t.Unavailable = fallback.Unavailable
+	}
+	if t.Exhausted == "" {
+		t.Exhausted = fallback.Exhausted
+	}
+	if t.HelixUnavailable == "" {
+		t.HelixUnavailable = fallback.HelixUnavailable
+	}
+	if t.StaleNote == "" {
+		t.StaleNote = fallback.StaleNote
+	}
+	return t
+}
+
+// Настройки отдельного канала
+type ChannelConfig struct {
+	Templates TemplatesConfig `yaml:"templates"`
+	// Часовой пояс стримера для {time} и !время
+	Timezone string `yaml:"timezone,omitempty"`
+	// Префикс команд вместо "!"
+	Prefix string `yaml:"prefix,omitempty"`
+	// Язык системных сообщений: ru, en или auto (по языку сообщения)
+	Locale string `yaml:"locale,omitempty"`
+	// Переопределение MENTION_ONLY
+	MentionOnly *bool `yaml:"mention_only,omitempty"`
+	// Настройки мини-игр канала
+	Games GamesConfig `yaml:"games,omitempty"`
+	// Части шаблонов канала, перекрывают общие
+	Partials map[string]string `yaml:"partials,omitempty"`
+}
+
+// Конфигурация бота из config.yaml
+type Config struct {
+	Accounts  []Account                `yaml:"accounts"`
+	Templates TemplatesConfig          `yaml:"templates"`
+	Timezone  string                   `yaml:"timezone,omitempty"`
+	Locale    string                   `yaml:"locale,omitempty"`
+	Channels  map[string]ChannelConfig `yaml:"channels"`
+	// Пасты по расписанию
+	Schedules []ScheduledPaste `yaml:"schedules,omitempty"`
+	// Настройки мини-игр
+	Games GamesConfig `yaml:"games,omitempty"`
+	// Общие части шаблонов паст: {{> имя}}
+	Partials map[string]string `yaml:"partials,omitempty"`
+}
+
+// loadConfig читает config.yaml. Отсутствующий файл не является ошибкой.
+func loadConfig(filename string) (*Config, error) {
+	config := &Config{}
+
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла %s: %w", filename, err)
+	}
+
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга YAML: %w", err)
+	}
+
+	// Имена каналов в IRC всегда в нижнем регистре и без #
+	channels := make(map[string]ChannelConfig, len(config.Channels))
+	for name, channel := range config.Channels {
+		if _, err := time.LoadLocation(channel.Timezone); err != nil {
+			return nil, fmt.Errorf("неверный часовой пояс канала %s: %w", name, err)
+		}
+		if strings.ContainsAny(channel.Prefix, " \t") {
+			return nil, fmt.Errorf("префикс канала %s не должен содержать пробелов", name)
+		}
+		if !validLocale(channel.Locale) {
+			return nil, fmt.Errorf("неизвестный язык канала %s: %q (ru, en, auto)", name, channel.Locale)
+		}
+		channels[normalizeChannel(name)] = channel
+	}
+	config.Channels = channels
+
+	if _, err := time.LoadLocation(config.Timezone); err != nil {
+		return nil, fmt.Errorf("неверный часовой пояс: %w", err)
+	}
+	if !validLocale(config.Locale) {
+		return nil, fmt.Errorf("неизвестный язык %q (ru, en, auto)", config.Locale)
+	}
+
+	for i := range config.Schedules {
+		if err := config.Schedules[i].prepare(); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.validatePartials(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// TemplatesFor возвращает шаблоны для канала: канал > config.yaml > fallback
+func (c *Config) TemplatesFor(channel string, fallback TemplatesConfig) TemplatesConfig {
+	global := c.Templates.merge(fallback)
+	return c.Channels[normalizeChannel(channel)].Templates.merge(global)
+}
+
+// Channel возвращает настройки канала (пустые, если канал не описан)
+func (c *Config) Channel(channel string) ChannelConfig {
+	return c.Channels[normalizeChannel(channel)]
+}
+
+// LocationFor возвращает часовой пояс канала: канал > config.yaml > local
+func (c *Config) LocationFor(channel string) *time.Location {
+	name := c.Channels[normalizeChannel(channel)].Timezone
+	if name == "" {
+		name = c.Timezone
+	}
+	if name == "" {
+		return time.Local
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Local
+	}
+	return location
+}
+
+func normalizeChannel(channel string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(channel), "#"))
+}
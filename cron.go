@@ -0,0 +1,103 @@
+// cron.go
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron-выражение из пяти полей: минута, час, день месяца, месяц, день недели.
+// Поддерживаются *, числа, диапазоны a-b, списки через запятую и шаг */n.
+type CronExpr struct {
+	minutes, hours, days, months, weekdays map[int]bool
+	// День месяца и день недели заданы (не *): достаточно совпадения любого из них
+	daysRestricted, weekdaysRestricted bool
+}
+
+func parseCron(expr string) (*CronExpr, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: нужно 5 полей (минута час день месяц день_недели)", expr)
+	}
+
+	limits := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sets := make([]map[int]bool, 5)
+	for i, field := range fields {
+		set, err := parseCronField(field, limits[i][0], limits[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %w", expr, err)
+		}
+		sets[i] = set
+	}
+
+	// Воскресенье можно записать как 0 или 7
+	if sets[4][7] {
+		sets[4][0] = true
+	}
+
+	return &CronExpr{
+		minutes:            sets[0],
+		hours:              sets[1],
+		days:               sets[2],
+		months:             sets[3],
+		weekdays:           sets[4],
+		daysRestricted:     fields[2] != "*",
+		weekdaysRestricted: fields[4] != "*",
+	}, nil
+}
+
+func parseCronField(field string, lo, hi int) (map[int]bool, error) {
+	set := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return nil, fmt.Errorf("неверный шаг в %q", part)
+			}
+			part = part[:i]
+		}
+
+		start, end := lo, hi
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			start, err1 = strconv.Atoi(bounds[0])
+			end, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("неверный диапазон %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("неверное значение %q", part)
+			}
+			start, end = n, n
+		}
+
+		if start < lo || end > hi || start > end {
+			return nil, fmt.Errorf("значение %q вне диапазона %d-%d", part, lo, hi)
+		}
+		for v := start; v <= end; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+// Matches сообщает, подходит ли минута t под выражение
+func (c *CronExpr) Matches(t time.Time) bool {
+	if !c.minutes[t.Minute()] || !c.hours[t.Hour()] || !c.months[int(t.Month())] {
+		return false
+	}
+
+	day, weekday := c.days[t.Day()], c.weekdays[int(t.Weekday())]
+	if c.daysRestricted && c.weekdaysRestricted {
+		return day || weekday
+	}
+	return day && weekday
+}
@@ -0,0 +1,74 @@
+// cron_test.go
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{"* * * * *", false},
+		{"*/15 18-23 * * 1-5", false},
+		{"0 12 1,15 * 0,7", false},
+		{"30 9 * 1-12/3 *", false},
+		{"* * * *", true},
+		{"* * * * * *", true},
+		{"60 * * * *", true},
+		{"* 24 * * *", true},
+		{"* * 0 * *", true},
+		{"* * * 13 *", true},
+		{"* * * * 8", true},
+		{"5-1 * * * *", true},
+		{"*/0 * * * *", true},
+		{"a * * * *", true},
+		{"1-x * * * *", true},
+	}
+	for _, tt := range tests {
+		if _, err := parseCron(tt.expr); (err != nil) != tt.wantErr {
+			t.Errorf("parseCron(%q) ошибка = %v, ожидалась %v", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCronMatches(t *testing.T) {
+	// 2026-01-05 - понедельник
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name string
+		expr string
+		at   time.Time
+		want bool
+	}{
+		{"каждую минуту", "* * * * *", at(5, 3, 7), true},
+		{"шаг совпал", "*/15 * * * *", at(5, 3, 45), true},
+		{"шаг не совпал", "*/15 * * * *", at(5, 3, 46), false},
+		{"шаг в диапазоне", "10-40/10 * * * *", at(5, 3, 30), true},
+		{"шаг за диапазоном", "10-40/10 * * * *", at(5, 3, 50), false},
+		{"час вне диапазона", "0 18-23 * * *", at(5, 17, 0), false},
+		{"будни", "0 12 * * 1-5", at(5, 12, 0), true},
+		{"выходные", "0 12 * * 1-5", at(4, 12, 0), false},
+		{"воскресенье как 7", "0 12 * * 7", at(4, 12, 0), true},
+		{"месяц", "0 12 * 2 *", at(5, 12, 0), false},
+		{"день или день недели: день", "0 12 15 * 1", at(15, 12, 0), true},
+		{"день или день недели: день недели", "0 12 15 * 1", at(12, 12, 0), true},
+		{"день или день недели: ни то, ни другое", "0 12 15 * 1", at(13, 12, 0), false},
+		{"только день месяца", "0 12 15 * *", at(12, 12, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cron.Matches(tt.at); got != tt.want {
+				t.Errorf("%q.Matches(%s) = %v, ожидалось %v", tt.expr, tt.at.Format("Mon 02 15:04"), got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// database.go
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// openDatabase открывает базу SQLite, общую для статистики и команд
+func openDatabase(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия базы %s: %w", path, err)
+	}
+	// SQLite не любит параллельную запись из нескольких соединений
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ошибка открытия базы %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// Хранилище в SQLite: все данные в одной базе
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+func (s *SQLiteStorage) Commands() (CommandStorage, error) {
+	return NewCommandStore(s.db)
+}
+
+func (s *SQLiteStorage) Variables() (Variables, error) {
+	return NewVariableStore(s.db)
+}
+
+func (s *SQLiteStorage) Usage() (UsageStorage, error) {
+	return NewUsageLog(s.db)
+}
+
+func (s *SQLiteStorage) Cooldown() (SharedCooldown, error) {
+	schema := `
+CREATE TABLE IF NOT EXISTS channel_cooldown (
+	channel   TEXT PRIMARY KEY,
+	last_used INTEGER NOT NULL
+);`
+	if _, err := s.db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("ошибка создания схемы cooldown: %w", err)
+	}
+	return &sqliteCooldown{db: s.db}, nil
+}
+
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// Время последнего ответа по каналам в SQLite, чтобы cooldown переживал перезапуск
+type sqliteCooldown struct {
+	db *sql.DB
+}
+
+func (c *sqliteCooldown) LastUsed(channel string) (time.Time, error) {
+	var ms int64
+	err := c.db.QueryRow("SELECT last_used FROM channel_cooldown WHERE channel = ?", channel).Scan(&ms)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ошибка чтения cooldown: %w", err)
+	}
+	return time.UnixMilli(ms), nil
+}
+
+func (c *sqliteCooldown) MarkUsed(channel string, at time.Time, ttl time.Duration) error {
+	_, err := c.db.Exec(
+		`INSERT INTO channel_cooldown (channel, last_used) VALUES (?, ?)
+		 ON CONFLICT (channel) DO UPDATE SET last_used = excluded.last_used`,
+		channel, at.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения cooldown: %w", err)
+	}
+	return nil
+}
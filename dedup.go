@@ -0,0 +1,146 @@
+// dedup.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Поведение при повторных вызовах команды в окне
+const (
+	DuplicateSuppress  = "suppress"
+	DuplicateAggregate = "aggregate"
+)
+
+// Подавление одинаковых команд, вызванных в канале за короткое окно
+type TriggerDeduper struct {
+	window    time.Duration
+	aggregate bool
+
+	mu     sync.Mutex
+	recent map[string]*int
+
+	// Если задан, окна общие для нескольких экземпляров бота
+	shared SharedDedup
+}
+
+// Счетчик вызовов в окне, общий для нескольких экземпляров
+type SharedDedup interface {
+	// Hit учитывает вызов и возвращает его номер в окне
+	Hit(key string, ttl time.Duration) (int64, error)
+	// Take возвращает число вызовов и закрывает окно
+	Take(key string) (int64, error)
+	// Open сообщает, открыто ли окно
+	Open(key string) (bool, error)
+}
+
+func NewTriggerDeduper(window time.Duration, mode string) (*TriggerDeduper, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode != DuplicateSuppress && mode != DuplicateAggregate {
+		return nil, fmt.Errorf("неизвестный режим повторов %q (suppress, aggregate)", mode)
+	}
+	return &TriggerDeduper{
+		window:    window,
+		aggregate: mode == DuplicateAggregate,
+		recent:    make(map[string]*int),
+	}, nil
+}
+
+// Pending сообщает, открыто ли окно повторов команды в канале. Такой вызов
+// только подсчитывается, поэтому cooldown канала его не останавливает.
+func (d *TriggerDeduper) Pending(channel, command string) bool {
+	if d == nil {
+		return false
+	}
+
+	key := channel + " " + command
+	if d.shared != nil {
+		open, err := d.shared.Open(key)
+		if err != nil {
+			slog.Warn("Общее подавление повторов недоступно", "error", err)
+			return false
+		}
+		return open
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.recent[key]
+	return ok
+}
+
+// Trigger учитывает вызов команды в канале. Первый вызов в окне приводит к fire:
+// сразу в режиме suppress или по окончании окна с числом вызовов в режиме aggregate.
+// Остальные вызовы в окне только подсчитываются. Возвращает false для подавленного вызова.
+func (d *TriggerDeduper) Trigger(channel, command string, fire func(count int)) bool {
+	if d == nil {
+		fire(1)
+		return true
+	}
+
+	key := channel + " " + command
+
+	if d.shared != nil {
+		return d.triggerShared(key, fire)
+	}
+
+	d.mu.Lock()
+	if count, ok := d.recent[key]; ok {
+		*count++
+		d.mu.Unlock()
+		return false
+	}
+	count := new(int)
+	*count = 1
+	d.recent[key] = count
+	d.mu.Unlock()
+
+	if !d.aggregate {
+		fire(1)
+	}
+
+	time.AfterFunc(d.window, func() {
+		d.mu.Lock()
+		delete(d.recent, key)
+		total := *count
+		d.mu.Unlock()
+
+		if d.aggregate {
+			fire(total)
+		}
+	})
+	return true
+}
+
+// triggerShared - Trigger с окном в общем хранилище: отвечает только
+// экземпляр, первым получивший вызов
+func (d *TriggerDeduper) triggerShared(key string, fire func(count int)) bool {
+	// Запас, чтобы окно не истекло раньше, чем открывший его экземпляр прочитает счетчик
+	count, err := d.shared.Hit(key, d.window+5*time.Second)
+	if err != nil {
+		slog.Warn("Общее подавление повторов недоступно", "error", err)
+		fire(1)
+		return true
+	}
+	if count > 1 {
+		return false
+	}
+
+	if !d.aggregate {
+		fire(1)
+	}
+
+	time.AfterFunc(d.window, func() {
+		total, err := d.shared.Take(key)
+		if err != nil {
+			slog.Warn("Общее подавление повторов недоступно", "error", err)
+		}
+		if d.aggregate {
+			fire(int(max(total, 1)))
+		}
+	})
+	return true
+}
@@ -0,0 +1,84 @@
+// dedup_test.go
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTriggerDeduperAggregate(t *testing.T) {
+	d, err := NewTriggerDeduper(50*time.Millisecond, DuplicateAggregate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fired := make(chan int, 1)
+	fire := func(count int) { fired <- count }
+
+	if d.Pending("#канал", "!паста") {
+		t.Fatal("окно открыто до первого вызова")
+	}
+	if !d.Trigger("#канал", "!паста", fire) {
+		t.Fatal("первый вызов подавлен")
+	}
+	if !d.Pending("#канал", "!паста") {
+		t.Fatal("окно не открыто после первого вызова")
+	}
+	if d.Pending("#канал", "!другая") || d.Pending("#другой", "!паста") {
+		t.Fatal("окно открыто для другой команды или канала")
+	}
+	for i := 0; i < 2; i++ {
+		if d.Trigger("#канал", "!паста", fire) {
+			t.Fatal("повтор в окне не подавлен")
+		}
+	}
+
+	select {
+	case count := <-fired:
+		if count != 3 {
+			t.Errorf("получено %d вызовов, ожидалось 3", count)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ответ по окончании окна не отправлен")
+	}
+	if d.Pending("#канал", "!паста") {
+		t.Error("окно не закрылось")
+	}
+}
+
+func TestTriggerDeduperSuppress(t *testing.T) {
+	d, err := NewTriggerDeduper(time.Minute, DuplicateSuppress)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var counts []int
+	fire := func(count int) { counts = append(counts, count) }
+
+	if !d.Trigger("#канал", "!паста", fire) || d.Trigger("#канал", "!паста", fire) {
+		t.Fatal("ожидался ответ только на первый вызов")
+	}
+	if len(counts) != 1 || counts[0] != 1 {
+		t.Errorf("неожиданные ответы: %v", counts)
+	}
+}
+
+func TestTriggerDeduperNil(t *testing.T) {
+	var d *TriggerDeduper
+	fired := 0
+	if !d.Trigger("#канал", "!паста", func(int) { fired++ }) || fired != 1 {
+		t.Error("без подавления повторов каждый вызов должен срабатывать")
+	}
+	if d.Pending("#канал", "!паста") {
+		t.Error("без подавления повторов окон нет")
+	}
+}
+
+func TestNewTriggerDeduperMode(t *testing.T) {
+	if _, err := NewTriggerDeduper(time.Second, "никак"); err == nil {
+		t.Error("неизвестный режим принят")
+	}
+	if _, err := NewTriggerDeduper(time.Second, " Aggregate "); err != nil {
+		t.Errorf("режим не распознан: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+// delay.go
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Задержка перед ответом: фиксированная часть и случайная добавка до Jitter
+type ResponseDelay struct {
+	Base   time.Duration
+	Jitter time.Duration
+}
+
+// Next возвращает задержку для очередного ответа
+func (d ResponseDelay) Next() time.Duration {
+	delay := d.Base
+	if d.Jitter > 0 {
+		delay += rand.N(d.Jitter)
+	}
+	return delay
+}
+
+// responseDelay возвращает задержку команды; поля, не заданные в команде, берутся из глобальных
+func (b *Bot) responseDelay(command *Command) ResponseDelay {
+	delay := b.delay
+	if command == nil {
+		return delay
+	}
+	if command.DelayMs != nil {
+		delay.Base = time.Duration(*command.DelayMs) * time.Millisecond
+	}
+	if command.JitterMs != nil {
+		delay.Jitter = time.Duration(*command.JitterMs) * time.Millisecond
+	}
+	return delay
+}
+
+// respondDelayed отправляет ответ на команду (nil - встроенная) после задержки,
+// не блокируя обработку других сообщений
+func (b *Bot) respondDelayed(ctx context.Context, message twitch.PrivateMessage, response string, command *Command) {
+	b.respondSequenceDelayed(ctx, message, []string{response}, command)
+}
+
+// respondSequenceDelayed отправляет последовательность сообщений команды с ее задержкой
+func (b *Bot) respondSequenceDelayed(ctx context.Context, message twitch.PrivateMessage, responses []string, command *Command) {
+	mode := b.respondAs
+	gap := defaultSequenceDelay
+	if command != nil {
+		if command.RespondAs != "" {
+			mode = command.RespondAs
+		}
+		gap = command.SequenceDelay()
+	}
+
+	ctx = withSendPriority(ctx, PriorityPaste)
+
+	wait := b.responseDelay(command).Next()
+	if wait <= 0 {
+		b.deliverSequence(ctx, message, responses, mode, gap)
+		return
+	}
+
+	time.AfterFunc(wait, func() {
+		b.deliverSequence(ctx, message, responses, mode, gap)
+	})
+}
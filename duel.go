@@ -0,0 +1,134 @@
+// duel.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Вызов на дуэль, ожидающий ответа
+type duelChallenge struct {
+	challenger string
+	target     string
+	timer      *time.Timer
+}
+
+// Дуэли между зрителями: !дуэль @ник, затем !принять от вызванного.
+// В канале одновременно может висеть только один вызов. Ставок нет:
+// у бота нет системы очков, дуэль идет на интерес.
+type DuelGame struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	pending map[string]*duelChallenge
+}
+
+func NewDuelGame(timeout time.Duration) *DuelGame {
+	return &DuelGame{
+		timeout: timeout,
+		pending: make(map[string]*duelChallenge),
+	}
+}
+
+// Challenge создает вызов. onExpire вызывается, если вызов не приняли за
+// timeout. Возвращает false, если в канале уже есть вызов.
+func (d *DuelGame) Challenge(channel, challenger, target string, onExpire func()) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, busy := d.pending[channel]; busy {
+		return false
+	}
+
+	challenge := &duelChallenge{challenger: challenger, target: target}
+	challenge.timer = time.AfterFunc(d.timeout, func() {
+		d.mu.Lock()
+		expired := d.pending[channel] == challenge
+		if expired {
+			delete(d.pending, channel)
+		}
+		d.mu.Unlock()
+
+		if expired {
+			onExpire()
+		}
+	})
+	d.pending[channel] = challenge
+	return true
+}
+
+// Accept принимает вызов, адресованный user. Возвращает имя вызвавшего.
+func (d *DuelGame) Accept(channel, user string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	challenge, ok := d.pending[channel]
+	if !ok || challenge.target != user {
+		return "", false
+	}
+	challenge.timer.Stop()
+	delete(d.pending, channel)
+	return challenge.challenger, true
+}
+
+func (b *Bot) duel(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+	en := b.locale(message) == LocaleEN
+	user := message.User.Name
+
+	if len(args) == 0 {
+		if en {
+			return "@" + user + ", usage: !duel @nickname"
+		}
+		return "@" + user + ", использование: !дуэль @ник"
+	}
+
+	target := strings.ToLower(strings.TrimPrefix(args[0], "@"))
+	if target == strings.ToLower(user) || b.senders.Ignored(target) {
+		if en {
+			return "@" + user + ", pick another opponent"
+		}
+		return "@" + user + ", выбери другого соперника"
+	}
+
+	channel := normalizeChannel(message.Channel)
+	started := b.duels.Challenge(channel, user, target, func() {
+		text := "@" + user + ", @" + target + " не принял вызов"
+		if en {
+			text = "@" + user + ", @" + target + " did not accept the duel"
+		}
+		b.respondDelayed(context.Background(), message, text, nil)
+	})
+	if !started {
+		if en {
+			return "@" + user + ", another duel is pending, wait a bit"
+		}
+		return "@" + user + ", в чате уже есть вызов, подожди немного"
+	}
+
+	seconds := int(b.duels.timeout.Seconds())
+	if en {
+		return fmt.Sprintf("@%s, @%s challenges you to a duel! Type !accept within %ds", target, user, seconds)
+	}
+	return fmt.Sprintf("@%s, @%s вызывает тебя на дуэль! Напиши !принять за %d с", target, user, seconds)
+}
+
+func (b *Bot) acceptDuel(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+	challenger, ok := b.duels.Accept(normalizeChannel(message.Channel), strings.ToLower(message.User.Name))
+	if !ok {
+		return ""
+	}
+
+	winner, loser := challenger, message.User.Name
+	if rand.IntN(2) == 0 {
+		winner, loser = loser, winner
+	}
+	if b.locale(message) == LocaleEN {
+		return "💥 @" + winner + " wins the duel against @" + loser + "!"
+	}
+	return "💥 @" + winner + " побеждает @" + loser + " в дуэли!"
+}
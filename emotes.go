@@ -0,0 +1,272 @@
+// emotes.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Смайлы сторонних сервисов (7TV, BTTV, FFZ) для каналов бота
+type EmoteCache struct {
+	helix      *HelixClient
+	channels   []string
+	httpClient *http.Client
+
+	mu     sync.RWMutex
+	global map[string]bool
+	byRoom map[string]map[string]bool // канал -> смайлы канала
+}
+
+func NewEmoteCache(helix *HelixClient, channels []string) *EmoteCache {
+	return &EmoteCache{
+		helix:      helix,
+		channels:   channels,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		global:     make(map[string]bool),
+		byRoom:     make(map[string]map[string]bool),
+	}
+}
+
+// getJSON загружает JSON с адреса url в out
+func (e *EmoteCache) getJSON(url string, out any) error {
+	resp, err := e.httpClient.Get(url)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	// Канал без смайлов в сервисе - не ошибка
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s вернул статус %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("ошибка разбора ответа %s: %w", url, err)
+	}
+	return nil
+}
+
+// Refresh заново загружает глобальные смайлы и смайлы всех каналов.
+// Ошибка отдельного сервиса не мешает остальным.
+func (e *EmoteCache) Refresh() error {
+	users, err := e.helix.GetUsers(e.channels...)
+	if err != nil {
+		return fmt.Errorf("ошибка получения ID каналов: %w", err)
+	}
+
+	global := make(map[string]bool)
+	e.fetchGlobal(global)
+
+	byRoom := make(map[string]map[string]bool, len(users))
+	for _, user := range users {
+		emotes := make(map[string]bool)
+		e.fetchChannel(user.ID, emotes)
+		byRoom[user.Login] = emotes
+	}
+
+	e.mu.Lock()
+	e.global, e.byRoom = global, byRoom
+	e.mu.Unlock()
+
+	slog.Info("Смайлы каналов обновлены", "global", len(global), "channels", len(byRoom))
+	return nil
+}
+
+func (e *EmoteCache) fetchGlobal(emotes map[string]bool) {
+	var bttv []struct {
+		Code string `json:"code"`
+	}
+	if err := e.getJSON("https://api.betterttv.net/3/cached/emotes/global", &bttv); err != nil {
+		slog.Warn("Ошибка загрузки смайлов BTTV", "error", err)
+	}
+	for _, emote := range bttv {
+		emotes[emote.Code] = true
+	}
+
+	var ffz struct {
+		Sets map[string]ffzSet `json:"sets"`
+	}
+	if err := e.getJSON("https://api.frankerfacez.com/v1/set/global", &ffz); err != nil {
+		slog.Warn("Ошибка загрузки смайлов FFZ", "error", err)
+	}
+	for _, set := range ffz.Sets {
+		for _, emote := range set.Emoticons {
+			emotes[emote.Name] = true
+		}
+	}
+
+	var stv struct {
+		Emotes []struct {
+			Name string `json:"name"`
+		} `json:"emotes"`
+	}
+	if err := e.getJSON("https://7tv.io/v3/emote-sets/global", &stv); err != nil {
+		slog.Warn("Ошибка загрузки смайлов 7TV", "error", err)
+	}
+	for _, emote := range stv.Emotes {
+		emotes[emote.Name] = true
+	}
+}
+
+type ffzSet struct {
+	Emoticons []struct {
+		Name string `json:"name"`
+	} `json:"emoticons"`
+}
+
+func (e *EmoteCache) fetchChannel(roomID string, emotes map[string]bool) {
+	var bttv struct {
+		ChannelEmotes []struct {
+			Code string `json:"code"`
+		} `json:"channelEmotes"`
+		SharedEmotes []struct {
+			Code string `json:"code"`
+		} `json:"sharedEmotes"`
+	}
+	if err := e.getJSON("https://api.betterttv.net/3/cached/users/twitch/"+roomID, &bttv); err != nil {
+		slog.Warn("Ошибка загрузки смайлов BTTV", "error", err)
+	}
+	for _, emote := range bttv.ChannelEmotes {
+		emotes[emote.Code] = true
+	}
+	for _, emote := range bttv.SharedEmotes {
+		emotes[emote.Code] = true
+	}
+
+	var ffz struct {
+		Sets map[string]ffzSet `json:"sets"`
+	}
+	if err := e.getJSON("https://api.frankerfacez.com/v1/room/id/"+roomID, &ffz); err != nil {
+		slog.Warn("Ошибка загрузки смайлов FFZ", "error", err)
+	}
+	for _, set := range ffz.Sets {
+		for _, emote := range set.Emoticons {
+			emotes[emote.Name] = true
+		}
+	}
+
+	var stv struct {
+		EmoteSet struct {
+			Emotes []struct {
+				Name string `json:"name"`
+			} `json:"emotes"`
+		} `json:"emote_set"`
+	}
+	if err := e.getJSON("https://7tv.io/v3/users/twitch/"+roomID, &stv); err != nil {
+		slog.Warn("Ошибка загрузки смайлов 7TV", "error", err)
+	}
+	for _, emote := range stv.EmoteSet.Emotes {
+		emotes[emote.Name] = true
+	}
+}
+
+// Random возвращает случайный смайл канала или пустую строку
+func (e *EmoteCache) Random(channel string) string {
+	if e == nil {
+		return ""
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	emotes := e.byRoom[normalizeChannel(channel)]
+	if len(emotes) == 0 {
+		return ""
+	}
+
+	// Порядок обхода map и так случаен, но не равномерен
+	n := rand.IntN(len(emotes))
+	for name := range emotes {
+		if n == 0 {
+			return name
+		}
+		n--
+	}
+	return ""
+}
+
+// IsEmote сообщает, является ли слово смайлом канала или глобальным смайлом
+func (e *EmoteCache) IsEmote(channel, word string) bool {
+	if e == nil {
+		return false
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.global[word] || e.byRoom[normalizeChannel(channel)][word]
+}
+
+// MissingEmotes возвращает слова пасты, которые являются смайлами какого-то
+// другого канала бота, но недоступны в channel. Слова, неизвестные ни одному
+// каналу, считаются обычным текстом.
+func (e *EmoteCache) MissingEmotes(channel, text string) []string {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	channel = normalizeChannel(channel)
+	own := e.byRoom[channel]
+	var missing []string
+	for _, word := range strings.Fields(text) {
+		if own[word] || e.global[word] {
+			continue
+		}
+		for room, emotes := range e.byRoom {
+			if room != channel && emotes[word] {
+				missing = append(missing, word)
+				break
+			}
+		}
+	}
+	return missing
+}
+
+// checkEmotes предупреждает о пастах со смайлами, которых нет в канале
+func (b *Bot) checkEmotes() {
+	if b.emotes == nil {
+		return
+	}
+
+	commands := b.Commands()
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, channel := range b.pool.Channels() {
+		for _, name := range names {
+			if missing := b.emotes.MissingEmotes(channel, commands[name].Text); len(missing) > 0 {
+				slog.Warn("В пасте есть смайлы, которых нет в канале",
+					"command", name,
+					"channel", channel,
+					"emotes", strings.Join(missing, ", "))
+			}
+		}
+	}
+}
+
+// runEmoteRefresh загружает смайлы при запуске и обновляет их с интервалом
+func (b *Bot) runEmoteRefresh(interval time.Duration, check bool) {
+	for {
+		if err := b.emotes.Refresh(); err != nil {
+			slog.Warn("Ошибка обновления смайлов", "error", err)
+		} else if check {
+			b.checkEmotes()
+		}
+		time.Sleep(interval)
+	}
+}
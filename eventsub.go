@@ -0,0 +1,276 @@
+// eventsub.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+	"github.com/gorilla/websocket"
+)
+
+const eventSubURL = "wss://eventsub.wss.twitch.tv/ws"
+
+// Транспорт чата через EventSub WebSocket (прием) и Helix Send Chat Message (отправка)
+type EventSubClient struct {
+	helix    *HelixClient
+	username string
+	channels []string
+
+	// Вызывается на каждое сообщение сессии (в том числе keepalive)
+	onActivity func()
+
+	mu             sync.RWMutex
+	botID          string
+	broadcasterIDs map[string]string // логин канала -> ID
+}
+
+func NewEventSubClient(helix *HelixClient, username string, channels []string) *EventSubClient {
+	return &EventSubClient{
+		helix:          helix,
+		username:       username,
+		channels:       channels,
+		broadcasterIDs: make(map[string]string),
+	}
+}
+
+// Сообщение EventSub WebSocket
+type eventSubMessage struct {
+	Metadata struct {
+		MessageType      string    `json:"message_type"`
+		MessageTimestamp time.Time `json:"message_timestamp"`
+		SubscriptionType string    `json:"subscription_type"`
+	} `json:"metadata"`
+	Payload struct {
+		Session struct {
+			ID                      string `json:"id"`
+			KeepaliveTimeoutSeconds int    `json:"keepalive_timeout_seconds"`
+			ReconnectURL            string `json:"reconnect_url"`
+		} `json:"session"`
+		Subscription struct {
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"subscription"`
+		Event json.RawMessage `json:"event"`
+	} `json:"payload"`
+}
+
+// Событие channel.chat.message
+type chatMessageEvent struct {
+	BroadcasterUserID    string `json:"broadcaster_user_id"`
+	BroadcasterUserLogin string `json:"broadcaster_user_login"`
+	ChatterUserID        string `json:"chatter_user_id"`
+	ChatterUserLogin     string `json:"chatter_user_login"`
+	ChatterUserName      string `json:"chatter_user_name"`
+	MessageID            string `json:"message_id"`
+	Message              struct {
+		Text string `json:"text"`
+	} `json:"message"`
+	Color  string `json:"color"`
+	Badges []struct {
+		SetID string `json:"set_id"`
+		ID    string `json:"id"`
+	} `json:"badges"`
+	MessageType string `json:"message_type"`
+}
+
+// resolveIDs запрашивает ID бота и каналов через Helix
+func (e *EventSubClient) resolveIDs() error {
+	self, err := e.helix.GetUsers()
+	if err != nil {
+		return fmt.Errorf("ошибка получения пользователя токена: %w", err)
+	}
+	if len(self) == 0 {
+		return fmt.Errorf("токен не принадлежит ни одному пользователю")
+	}
+
+	users, err := e.helix.GetUsers(e.channels...)
+	if err != nil {
+		return fmt.Errorf("ошибка получения ID каналов: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.botID = self[0].ID
+	for _, user := range users {
+		e.broadcasterIDs[user.Login] = user.ID
+	}
+	for _, channel := range e.channels {
+		if _, ok := e.broadcasterIDs[channel]; !ok {
+			slog.Warn("Канал не найден в Helix", "channel", channel)
+		}
+	}
+	return nil
+}
+
+// Run подключается к EventSub и блокируется до обрыва сессии
+func (e *EventSubClient) Run(onMessage func(twitch.PrivateMessage), onConnect func()) error {
+	if err := e.resolveIDs(); err != nil {
+		return err
+	}
+
+	url := eventSubURL
+	subscribed := false
+
+	for {
+		ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			return fmt.Errorf("ошибка подключения к EventSub: %w", err)
+		}
+
+		reconnectURL, err := e.readLoop(ws, &subscribed, onMessage, onConnect)
+		ws.Close()
+
+		// session_reconnect: переходим на новый адрес, подписки сохраняются
+		if reconnectURL != "" {
+			slog.Info("EventSub запросил переподключение", "bot_username", e.username)
+			url = reconnectURL
+			continue
+		}
+		return err
+	}
+}
+
+// readLoop читает сообщения сессии. Возвращает reconnect_url при session_reconnect.
+func (e *EventSubClient) readLoop(ws *websocket.Conn, subscribed *bool, onMessage func(twitch.PrivateMessage), onConnect func()) (string, error) {
+	keepalive := 10 * time.Second
+
+	for {
+		// Twitch шлет keepalive; если их нет дольше таймаута, сессия мертва
+		ws.SetReadDeadline(time.Now().Add(keepalive + 5*time.Second))
+
+		var msg eventSubMessage
+		if err := ws.ReadJSON(&msg); err != nil {
+			return "", fmt.Errorf("ошибка чтения EventSub: %w", err)
+		}
+		if e.onActivity != nil {
+			e.onActivity()
+		}
+
+		switch msg.Metadata.MessageType {
+		case "session_welcome":
+			if seconds := msg.Payload.Session.KeepaliveTimeoutSeconds; seconds > 0 {
+				keepalive = time.Duration(seconds) * time.Second
+			}
+			if !*subscribed {
+				if err := e.subscribe(msg.Payload.Session.ID); err != nil {
+					return "", err
+				}
+				*subscribed = true
+			}
+			onConnect()
+
+		case "session_keepalive":
+
+		case "session_reconnect":
+			return msg.Payload.Session.ReconnectURL, nil
+
+		case "revocation":
+			slog.Error("Подписка EventSub отозвана",
+				"bot_username", e.username,
+				"type", msg.Payload.Subscription.Type,
+				"status", msg.Payload.Subscription.Status)
+
+		case "notification":
+			if msg.Metadata.SubscriptionType != "channel.chat.message" {
+				continue
+			}
+
+			var event chatMessageEvent
+			if err := json.Unmarshal(msg.Payload.Event, &event); err != nil {
+				slog.Warn("Ошибка разбора события EventSub", "error", err)
+				continue
+			}
+			onMessage(event.toPrivateMessage(msg.Metadata.MessageTimestamp))
+		}
+	}
+}
+
+// subscribe подписывает сессию на сообщения чата всех каналов
+func (e *EventSubClient) subscribe(sessionID string) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	for channel, broadcasterID := range e.broadcasterIDs {
+		condition := map[string]string{
+			"broadcaster_user_id": broadcasterID,
+			"user_id":             e.botID,
+		}
+		if err := e.helix.CreateEventSubSubscription("channel.chat.message", "1", condition, sessionID); err != nil {
+			return fmt.Errorf("ошибка подписки на чат %s: %w", channel, err)
+		}
+		slog.Info("Подписка EventSub создана", "bot_username", e.username, "channel", channel)
+	}
+	return nil
+}
+
+// toPrivateMessage приводит событие EventSub к формату IRC-сообщения,
+// чтобы обработчики не зависели от транспорта
+func (ev chatMessageEvent) toPrivateMessage(ts time.Time) twitch.PrivateMessage {
+	user := twitch.User{
+		ID:            ev.ChatterUserID,
+		Name:          ev.ChatterUserLogin,
+		DisplayName:   ev.ChatterUserName,
+		Color:         ev.Color,
+		Badges:        make(map[string]int),
+		IsBroadcaster: ev.ChatterUserID == ev.BroadcasterUserID,
+	}
+	for _, badge := range ev.Badges {
+		version, _ := strconv.Atoi(badge.ID)
+		user.Badges[badge.SetID] = version
+		switch badge.SetID {
+		case "moderator":
+			user.IsMod = true
+		case "vip":
+			user.IsVip = true
+		}
+	}
+
+	return twitch.PrivateMessage{
+		User:    user,
+		Type:    twitch.PRIVMSG,
+		RawType: "PRIVMSG",
+		Tags: map[string]string{
+			"id":      ev.MessageID,
+			"user-id": ev.ChatterUserID,
+			"room-id": ev.BroadcasterUserID,
+		},
+		Message: ev.Message.Text,
+		Channel: ev.BroadcasterUserLogin,
+		RoomID:  ev.BroadcasterUserID,
+		ID:      ev.MessageID,
+		Time:    ts,
+		Action:  ev.MessageType == "action",
+	}
+}
+
+// Say отправляет сообщение через Helix
+func (e *EventSubClient) Say(channel, text string) {
+	e.send(channel, "", text)
+}
+
+// Reply отвечает на сообщение через Helix
+func (e *EventSubClient) Reply(channel, parentMsgID, text string) {
+	e.send(channel, parentMsgID, text)
+}
+
+func (e *EventSubClient) send(channel, parentMsgID, text string) {
+	e.mu.RLock()
+	broadcasterID := e.broadcasterIDs[channel]
+	botID := e.botID
+	e.mu.RUnlock()
+
+	if broadcasterID == "" {
+		slog.Warn("Неизвестный канал для отправки через Helix", "channel", channel)
+		return
+	}
+
+	if err := e.helix.SendChatMessage(broadcasterID, botID, text, parentMsgID); err != nil {
+		slog.Error("Ошибка отправки сообщения через Helix", "error", err, "channel", channel)
+	}
+}
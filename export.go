@@ -0,0 +1,168 @@
+// export.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const backupPrefix = "commands-"
+
+// Команда в резервной копии вместе со счетчиком использований
+type ExportedCommand struct {
+	Command `yaml:",inline"`
+	Uses    int `yaml:"uses,omitempty"`
+}
+
+// MarshalYAML добавляет uses к полям команды: встроенная Command со своим
+// MarshalYAML иначе скрыла бы счетчик
+func (e ExportedCommand) MarshalYAML() (any, error) {
+	value, err := e.Command.MarshalYAML()
+	if err != nil {
+		return nil, err
+	}
+	node := value.(*yaml.Node)
+	if e.Uses > 0 {
+		var key, uses yaml.Node
+		key.SetString("uses")
+		if err := uses.Encode(e.Uses); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &key, &uses)
+	}
+	return node, nil
+}
+
+// UnmarshalYAML читает команду и счетчик uses из одного узла
+func (e *ExportedCommand) UnmarshalYAML(node *yaml.Node) error {
+	if err := node.Decode(&e.Command); err != nil {
+		return err
+	}
+	var counter struct {
+		Uses int `yaml:"uses"`
+	}
+	if err := node.Decode(&counter); err != nil {
+		return err
+	}
+	e.Uses = counter.Uses
+	return nil
+}
+
+// Резервная копия команд. Формат совместим с commands.yaml:
+// лишние поля при загрузке игнорируются.
+type CommandsExport struct {
+	ExportedAt time.Time         `yaml:"exported_at"`
+	Messages   []ExportedCommand `yaml:"messages"`
+}
+
+// buildExport собирает текущий набор команд со счетчиками
+func (b *Bot) buildExport() (*CommandsExport, error) {
+	var counts map[string]int
+	if b.usage != nil {
+		var err error
+		if counts, err = b.usage.CountsByCommand(); err != nil {
+			return nil, err
+		}
+	}
+
+	commands := b.Commands()
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	export := &CommandsExport{ExportedAt: time.Now().UTC()}
+	for _, name := range names {
+		export.Messages = append(export.Messages, ExportedCommand{
+			Command: *commands[name],
+			Uses:    counts[name],
+		})
+	}
+	return export, nil
+}
+
+// exportCommands записывает резервную копию в BACKUP_DIR и возвращает путь к файлу
+func (b *Bot) exportCommands() (string, error) {
+	export, err := b.buildExport()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := yaml.Marshal(export)
+	if err != nil {
+		return "", fmt.Errorf("ошибка сериализации YAML: %w", err)
+	}
+
+	if err := os.MkdirAll(b.backupDir, 0755); err != nil {
+		return "", fmt.Errorf("ошибка создания каталога %s: %w", b.backupDir, err)
+	}
+
+	name := backupPrefix + export.ExportedAt.Format("20060102-150405") + ".yaml"
+	path := filepath.Join(b.backupDir, name)
+
+	// Пишем во временный файл и переименовываем, чтобы не оставить обрывок
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return "", fmt.Errorf("ошибка записи файла %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("ошибка записи файла %s: %w", path, err)
+	}
+
+	slog.Info("Команды выгружены", "path", path, "count", len(export.Messages))
+
+	if err := pruneBackups(b.backupDir, b.backupKeep); err != nil {
+		slog.Warn("Ошибка удаления старых резервных копий", "error", err)
+	}
+
+	return path, nil
+}
+
+// pruneBackups оставляет в каталоге только keep последних резервных копий
+func pruneBackups(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения каталога %s: %w", dir, err)
+	}
+
+	// Имена содержат время, поэтому сортировка по имени хронологическая
+	var backups []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), backupPrefix) && strings.HasSuffix(entry.Name(), ".yaml") {
+			backups = append(backups, entry.Name())
+		}
+	}
+	sort.Strings(backups)
+
+	for len(backups) > keep {
+		if err := os.Remove(filepath.Join(dir, backups[0])); err != nil {
+			return fmt.Errorf("ошибка удаления %s: %w", backups[0], err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// runPeriodicBackups делает резервную копию команд каждые interval
+func (b *Bot) runPeriodicBackups(interval time.Duration) {
+	for range time.Tick(interval) {
+		path, err := b.exportCommands()
+		if err != nil {
+			slog.Error("Ошибка автоматического резервного копирования", "error", err)
+			continue
+		}
+		b.audit.Record("system", AuditExport, path, "автоматическая копия")
+	}
+}
@@ -0,0 +1,62 @@
+// export_test.go
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestExportedCommandRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		command ExportedCommand
+	}{
+		{"текст со счетчиком", ExportedCommand{Command: Command{Command: "!паста", Text: "привет"}, Uses: 5}},
+		{"без использований", ExportedCommand{Command: Command{Command: "!паста", Text: "привет"}}},
+		{"последовательность", ExportedCommand{Command: Command{Command: "!серия", Text: "раз два", Parts: []string{"раз", "два"}}, Uses: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := yaml.Marshal(CommandsExport{Messages: []ExportedCommand{tt.command}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.command.Uses > 0 && !strings.Contains(string(data), "uses:") {
+				t.Fatalf("в экспорте нет uses:\n%s", data)
+			}
+
+			var export CommandsExport
+			if err := yaml.Unmarshal(data, &export); err != nil {
+				t.Fatal(err)
+			}
+			if len(export.Messages) != 1 {
+				t.Fatalf("ожидалась одна команда, получено %d", len(export.Messages))
+			}
+			if got := export.Messages[0]; !reflect.DeepEqual(got, tt.command) {
+				t.Errorf("после круга получено %+v, ожидалось %+v", got, tt.command)
+			}
+		})
+	}
+}
+
+// Экспорт должен загружаться как commands.yaml
+func TestExportLoadsAsCommands(t *testing.T) {
+	data, err := yaml.Marshal(CommandsExport{Messages: []ExportedCommand{
+		{Command: Command{Command: "!паста", Text: "привет"}, Uses: 3},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config CommandsConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Messages) != 1 || config.Messages[0].Text != "привет" {
+		t.Errorf("неожиданные команды: %+v", config.Messages)
+	}
+}
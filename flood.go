@@ -0,0 +1,81 @@
+// flood.go
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Окно, в котором считаются попытки вызова команд одним пользователем
+const floodWindow = time.Minute
+
+// Защита от пользователя, заваливающего бота командами: после maxAttempts
+// попыток за минуту он игнорируется на ignoreFor без каких-либо ответов
+type FloodGuard struct {
+	maxAttempts int
+	ignoreFor   time.Duration
+
+	mu       sync.Mutex
+	attempts map[string][]time.Time
+	ignored  map[string]time.Time
+}
+
+func NewFloodGuard(maxAttempts int, ignoreFor time.Duration) *FloodGuard {
+	return &FloodGuard{
+		maxAttempts: maxAttempts,
+		ignoreFor:   ignoreFor,
+		attempts:    make(map[string][]time.Time),
+		ignored:     make(map[string]time.Time),
+	}
+}
+
+// Attempt учитывает попытку вызова команды. Возвращает ignored = true, если
+// пользователь сейчас игнорируется, и started = true, если игнор начался этой попыткой.
+func (f *FloodGuard) Attempt(channel, user string) (ignored, started bool) {
+	if f == nil {
+		return false, false
+	}
+
+	key := channel + " " + user
+	now := time.Now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if until, ok := f.ignored[key]; ok {
+		if now.Before(until) {
+			return true, false
+		}
+		delete(f.ignored, key)
+	}
+
+	// Убираем старые попытки, в том числе у других пользователей, чтобы карта не росла
+	for k, times := range f.attempts {
+		fresh := times[:0]
+		for _, t := range times {
+			if now.Sub(t) < floodWindow {
+				fresh = append(fresh, t)
+			}
+		}
+		if len(fresh) == 0 && k != key {
+			delete(f.attempts, k)
+		} else {
+			f.attempts[k] = fresh
+		}
+	}
+
+	f.attempts[key] = append(f.attempts[key], now)
+	if len(f.attempts[key]) <= f.maxAttempts {
+		return false, false
+	}
+
+	delete(f.attempts, key)
+	f.ignored[key] = now.Add(f.ignoreFor)
+	slog.Warn("Пользователь временно игнорируется за флуд командами",
+		"channel", channel,
+		"user", user,
+		"attempts", f.maxAttempts+1,
+		"ignore_for", f.ignoreFor)
+	return true, true
+}
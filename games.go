@@ -0,0 +1,101 @@
+// games.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Настройки мини-игр в config.yaml (глобально и для канала)
+type GamesConfig struct {
+	// Ответы !8ball; если не заданы, берутся стандартные для языка канала
+	EightBall []string `yaml:"eightball,omitempty"`
+}
+
+// Стандартные ответы !8ball по языкам
+var eightBallAnswers = map[string][]string{
+	LocaleRU: {
+		"Бесспорно", "Предрешено", "Никаких сомнений", "Определенно да",
+		"Можешь быть уверен в этом", "Мне кажется - да", "Вероятнее всего",
+		"Хорошие перспективы", "Знаки говорят - да", "Да",
+		"Пока не ясно, попробуй снова", "Спроси позже", "Лучше не рассказывать",
+		"Сейчас нельзя предсказать", "Сконцентрируйся и спроси опять",
+		"Даже не думай", "Мой ответ - нет", "По моим данным - нет",
+		"Перспективы не очень хорошие", "Весьма сомнительно",
+	},
+	LocaleEN: {
+		"It is certain", "It is decidedly so", "Without a doubt", "Yes definitely",
+		"You may rely on it", "As I see it, yes", "Most likely", "Outlook good",
+		"Signs point to yes", "Yes", "Reply hazy, try again", "Ask again later",
+		"Better not tell you now", "Cannot predict now", "Concentrate and ask again",
+		"Don't count on it", "My reply is no", "My sources say no",
+		"Outlook not so good", "Very doubtful",
+	},
+}
+
+// registerGames регистрирует мини-игры чата. Новая игра добавляется
+// отдельным обработчиком здесь же.
+func (b *Bot) registerGames() {
+	b.registerHandler(handlerFunc{[]string{"!8ball"}, b.eightBall})
+	b.registerHandler(handlerFunc{[]string{"!roll"}, b.roll})
+	b.registerHandler(handlerFunc{[]string{"!coin"}, b.coin})
+
+	if b.duels != nil {
+		b.registerHandler(handlerFunc{[]string{"!дуэль", "!duel"}, b.duel})
+		b.registerHandler(cooldownExempt{handlerFunc{[]string{"!принять", "!accept"}, b.acceptDuel}})
+	}
+}
+
+func (b *Bot) eightBall(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+	locale := b.locale(message)
+	if len(args) == 0 {
+		if locale == LocaleEN {
+			return "@" + message.User.Name + ", ask a question: !8ball <question>"
+		}
+		return "@" + message.User.Name + ", задай вопрос: !8ball <вопрос>"
+	}
+	answers := b.eightBallAnswers(message.Channel, locale)
+	return "@" + message.User.Name + ", " + answers[rand.IntN(len(answers))]
+}
+
+// roll бросает кубики в нотации NdM±K, по умолчанию 1d6
+func (b *Bot) roll(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+	notation := "1d6"
+	if len(args) > 0 {
+		notation = args[0]
+	}
+	total, err := rollDice(notation)
+	if err != nil {
+		if b.locale(message) == LocaleEN {
+			return "@" + message.User.Name + ", usage: !roll 2d6"
+		}
+		return "@" + message.User.Name + ", использование: !roll 2d6"
+	}
+	return fmt.Sprintf("@%s 🎲 %s: %d", message.User.Name, notation, total)
+}
+
+func (b *Bot) coin(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+	sides := [2]string{"орел", "решка"}
+	if b.locale(message) == LocaleEN {
+		sides = [2]string{"heads", "tails"}
+	}
+	return "@" + message.User.Name + " 🪙 " + sides[rand.IntN(2)]
+}
+
+// eightBallAnswers возвращает ответы !8ball: канал > config.yaml > стандартные для языка
+func (b *Bot) eightBallAnswers(channel, locale string) []string {
+	config := b.Config()
+	if answers := config.Channel(channel).Games.EightBall; len(answers) > 0 {
+		return answers
+	}
+	if answers := config.Games.EightBall; len(answers) > 0 {
+		return answers
+	}
+	if answers, ok := eightBallAnswers[locale]; ok {
+		return answers
+	}
+	return eightBallAnswers[LocaleRU]
+}
@@ -0,0 +1,109 @@
+// handlers.go
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Встроенный обработчик команд чата. Новые встроенные команды и мини-игры
+// добавляются реализацией Handler и регистрацией, без правки диспетчера.
+type Handler interface {
+	// Commands возвращает имена команд, которые обрабатывает handler
+	Commands() []string
+	// Handle возвращает ответ на команду; пустой ответ ничего не отправляет
+	Handle(ctx context.Context, message twitch.PrivateMessage, args []string) string
+}
+
+// Обработчик из функции
+type handlerFunc struct {
+	names []string
+	fn    func(ctx context.Context, message twitch.PrivateMessage, args []string) string
+}
+
+func (h handlerFunc) Commands() []string { return h.names }
+
+func (h handlerFunc) Handle(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+	return h.fn(ctx, message, args)
+}
+
+// Обработчик вне глобального cooldown: не ждет его и не включает. Нужен
+// ответам в играх, которые иначе не успели бы до конца cooldown.
+type cooldownExempt struct {
+	Handler
+}
+
+// Обработчик, который ходит во внешние API (Twitch Helix) и может ждать
+// повторов и таймаутов. Выполняется вне горутины чтения чата, чтобы не
+// задерживать остальные каналы соединения и ответы на PING.
+type asyncHandler struct {
+	Handler
+}
+
+// registerHandler добавляет встроенный обработчик. Команды, уже занятые
+// другим обработчиком, не перезаписываются.
+func (b *Bot) registerHandler(handler Handler) {
+	if b.handlers == nil {
+		b.handlers = make(map[string]Handler)
+	}
+	for _, name := range handler.Commands() {
+		if _, exists := b.handlers[name]; exists {
+			slog.Warn("Встроенная команда уже зарегистрирована", "command", name)
+			continue
+		}
+		b.handlers[name] = handler
+	}
+}
+
+// isBuiltin сообщает, обрабатывается ли команда встроенным обработчиком
+func (b *Bot) isBuiltin(name string) bool {
+	_, ok := b.handlers[name]
+	return ok
+}
+
+// registerBuiltins регистрирует встроенные команды бота
+func (b *Bot) registerBuiltins() {
+	b.registerHandler(handlerFunc{[]string{"!пасты", "!commands"}, func(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+		if b.commandsPageURL != "" && len(args) == 0 {
+			return renderTemplate(b.templates(message).CommandsLink, map[string]string{
+				"user": message.User.Name,
+				"url":  b.commandsPageURL,
+			})
+		}
+		header := renderTemplate(b.templates(message).CommandsHeader, map[string]string{
+			"user": message.User.Name,
+		})
+		return b.commandsListText(header, args)
+	}})
+
+	b.registerHandler(handlerFunc{[]string{"!помощь", "!help"}, func(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+		return b.helpText(args)
+	}})
+
+	if b.usage != nil {
+		b.registerHandler(handlerFunc{[]string{"!статистика"}, func(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+			return b.statsText(args)
+		}})
+	}
+
+	b.registerHandler(handlerFunc{[]string{"!последние"}, func(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+		if !isPrivileged(message.User) {
+			return ""
+		}
+		return b.recentText(message, args)
+	}})
+
+	b.registerHandler(handlerFunc{[]string{"!время"}, func(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+		return b.localTimeText(message)
+	}})
+
+	b.registerHandler(handlerFunc{[]string{"!version", "!версия"}, func(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+		return versionText()
+	}})
+
+	b.registerHandler(handlerFunc{[]string{"!найти"}, func(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+		return b.searchText(message.User.Name, args)
+	}})
+}
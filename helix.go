@@ -0,0 +1,328 @@
+// helix.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const helixBaseURL = "https://api.twitch.tv/helix"
+
+// Минимальный клиент Twitch Helix API
+type HelixClient struct {
+	clientID   string
+	httpClient *http.Client
+
+	mu    sync.RWMutex
+	token string
+
+	// Защита от сбоев Twitch API: предохранитель и последние успешные ответы
+	breaker *helixBreaker
+	cache   *helixCache
+}
+
+func NewHelixClient(clientID, oauthToken string) *HelixClient {
+	return &HelixClient{
+		clientID:   clientID,
+		token:      strings.TrimPrefix(oauthToken, "oauth:"),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		breaker:    &helixBreaker{},
+		cache:      newHelixCache(),
+	}
+}
+
+// SetToken подменяет токен для следующих запросов
+func (h *HelixClient) SetToken(oauthToken string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.token = strings.TrimPrefix(oauthToken, "oauth:")
+}
+
+func (h *HelixClient) bearer() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return "Bearer " + h.token
+}
+
+// get выполняет GET-запрос к Helix и разбирает JSON-ответ в out
+func (h *HelixClient) get(path string, params url.Values, out any) error {
+	_, err := h.getCached(path, params, out)
+	return err
+}
+
+// getCached выполняет GET-запрос с повторами. Если Helix недоступен, отдает
+// последний успешный ответ на тот же запрос и возвращает его возраст.
+func (h *HelixClient) getCached(path string, params url.Values, out any) (time.Duration, error) {
+	key := path + "?" + params.Encode()
+
+	body, err := h.fetch(key)
+	if err != nil {
+		if !helixUnavailable(err) {
+			return 0, err
+		}
+		body, age, ok := h.cache.Get(key)
+		if !ok {
+			return 0, err
+		}
+		slog.Debug("Ответ Helix взят из кэша", "path", path, "age", age, "error", err)
+		if err := json.Unmarshal(body, out); err != nil {
+			return 0, fmt.Errorf("ошибка разбора ответа Helix %s: %w", path, err)
+		}
+		return age, nil
+	}
+
+	h.cache.Put(key, body)
+	if err := json.Unmarshal(body, out); err != nil {
+		return 0, fmt.Errorf("ошибка разбора ответа Helix %s: %w", path, err)
+	}
+	return 0, nil
+}
+
+// fetch выполняет GET-запрос, повторяя его с нарастающей паузой при сбоях Twitch
+func (h *HelixClient) fetch(path string) ([]byte, error) {
+	if !h.breaker.Allow() {
+		return nil, ErrHelixUnavailable
+	}
+
+	var err error
+	for attempt := 0; attempt <= helixRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(helixRetryDelay << (attempt - 1))
+		}
+		var body []byte
+		if body, err = h.request(http.MethodGet, path, nil); err == nil || !helixUnavailable(err) {
+			// Helix ответил, пусть и ошибкой запроса
+			h.breaker.Success()
+			return body, err
+		}
+	}
+	h.breaker.Failure()
+	return nil, err
+}
+
+// post выполняет POST-запрос к Helix с JSON-телом. body и out могут быть nil.
+// Запросы с действиями не повторяются, чтобы не выполнить их дважды.
+func (h *HelixClient) post(path string, body any, out any) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("ошибка сериализации запроса Helix %s: %w", path, err)
+		}
+	}
+
+	resp, err := h.do(http.MethodPost, path, data)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(resp) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp, out); err != nil {
+		return fmt.Errorf("ошибка разбора ответа Helix %s: %w", path, err)
+	}
+	return nil
+}
+
+// delete выполняет DELETE-запрос к Helix
+func (h *HelixClient) delete(path string) error {
+	_, err := h.do(http.MethodDelete, path, nil)
+	return err
+}
+
+// do выполняет запрос без повторов с учетом состояния предохранителя
+func (h *HelixClient) do(method, path string, body []byte) ([]byte, error) {
+	if !h.breaker.Allow() {
+		return nil, ErrHelixUnavailable
+	}
+	resp, err := h.request(method, path, body)
+	if helixUnavailable(err) {
+		h.breaker.Failure()
+	} else {
+		h.breaker.Success()
+	}
+	return resp, err
+}
+
+// request выполняет один HTTP-запрос к Helix и возвращает тело успешного ответа
+func (h *HelixClient) request(method, path string, body []byte) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, helixBaseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания запроса Helix %s: %w", path, err)
+	}
+	req.Header.Set("Client-Id", h.clientID)
+	req.Header.Set("Authorization", h.bearer())
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, &helixError{path: path, err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		alerts.Alert(AlertAuth, "Helix не принимает токен (401), проверьте токен и TWITCH_CLIENT_ID")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &helixError{path: path, status: resp.StatusCode}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, &helixError{path: path, err: err}
+	}
+	return data, nil
+}
+
+// Пользователь Twitch из Helix
+type HelixUser struct {
+	ID    string `json:"id"`
+	Login string `json:"login"`
+}
+
+// GetUsers возвращает пользователей по логинам. Без логинов - владельца токена.
+func (h *HelixClient) GetUsers(logins ...string) ([]HelixUser, error) {
+	var resp struct {
+		Data []HelixUser `json:"data"`
+	}
+
+	params := url.Values{"login": logins}
+	if err := h.get("/users", params, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// SendChatMessage отправляет сообщение в чат через Helix.
+// Требует scope user:write:chat. replyParentID может быть пустым.
+func (h *HelixClient) SendChatMessage(broadcasterID, senderID, message, replyParentID string) error {
+	body := map[string]string{
+		"broadcaster_id": broadcasterID,
+		"sender_id":      senderID,
+		"message":        message,
+	}
+	if replyParentID != "" {
+		body["reply_parent_message_id"] = replyParentID
+	}
+
+	var resp struct {
+		Data []struct {
+			IsSent     bool `json:"is_sent"`
+			DropReason *struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"drop_reason"`
+		} `json:"data"`
+	}
+	if err := h.post("/chat/messages", body, &resp); err != nil {
+		return err
+	}
+
+	if len(resp.Data) > 0 && !resp.Data[0].IsSent && resp.Data[0].DropReason != nil {
+		return fmt.Errorf("сообщение отклонено: %s (%s)", resp.Data[0].DropReason.Message, resp.Data[0].DropReason.Code)
+	}
+	return nil
+}
+
+// CreateEventSubSubscription подписывает WebSocket-сессию на событие EventSub
+func (h *HelixClient) CreateEventSubSubscription(eventType, version string, condition map[string]string, sessionID string) error {
+	body := map[string]any{
+		"type":      eventType,
+		"version":   version,
+		"condition": condition,
+		"transport": map[string]string{
+			"method":     "websocket",
+			"session_id": sessionID,
+		},
+	}
+	return h.post("/eventsub/subscriptions", body, nil)
+}
+
+// IsFollower проверяет, подписан ли пользователь на канал.
+// Требует scope moderator:read:followers и прав модератора у бота.
+func (h *HelixClient) IsFollower(broadcasterID, userID string) (bool, error) {
+	var resp struct {
+		Data []struct {
+			UserID string `json:"user_id"`
+		} `json:"data"`
+	}
+
+	params := url.Values{
+		"broadcaster_id": {broadcasterID},
+		"user_id":        {userID},
+	}
+	if err := h.get("/channels/followers", params, &resp); err != nil {
+		return false, err
+	}
+
+	return len(resp.Data) > 0, nil
+}
+
+// SendWhisper отправляет личное сообщение. Требует scope user:manage:whispers.
+func (h *HelixClient) SendWhisper(fromUserID, toUserID, message string) error {
+	params := url.Values{"from_user_id": {fromUserID}, "to_user_id": {toUserID}}
+	return h.post("/whispers?"+params.Encode(), map[string]string{"message": message}, nil)
+}
+
+// SendAnnouncement публикует объявление в чате. Требует scope
+// moderator:manage:announcements и прав модератора в канале.
+func (h *HelixClient) SendAnnouncement(broadcasterID, moderatorID, message string) error {
+	params := url.Values{"broadcaster_id": {broadcasterID}, "moderator_id": {moderatorID}}
+	return h.post("/chat/announcements?"+params.Encode(), map[string]string{"message": message}, nil)
+}
+
+// TimeoutUser отстраняет пользователя от чата на duration. Требует scope
+// moderator:manage:banned_users и прав модератора в канале.
+func (h *HelixClient) TimeoutUser(broadcasterID, moderatorID, userID string, duration time.Duration, reason string) error {
+	params := url.Values{"broadcaster_id": {broadcasterID}, "moderator_id": {moderatorID}}
+	body := map[string]any{"data": map[string]any{
+		"user_id":  userID,
+		"duration": int(duration.Seconds()),
+		"reason":   reason,
+	}}
+	return h.post("/moderation/bans?"+params.Encode(), body, nil)
+}
+
+// WarnUser выносит пользователю предупреждение. Требует scope
+// moderator:manage:warnings и прав модератора в канале.
+func (h *HelixClient) WarnUser(broadcasterID, moderatorID, userID, reason string) error {
+	params := url.Values{"broadcaster_id": {broadcasterID}, "moderator_id": {moderatorID}}
+	body := map[string]any{"data": map[string]string{"user_id": userID, "reason": reason}}
+	return h.post("/moderation/warnings?"+params.Encode(), body, nil)
+}
+
+// DeleteChatMessage удаляет сообщение из чата. Требует scope
+// moderator:manage:chat_messages и прав модератора в канале.
+func (h *HelixClient) DeleteChatMessage(broadcasterID, moderatorID, messageID string) error {
+	params := url.Values{"broadcaster_id": {broadcasterID}, "moderator_id": {moderatorID}, "message_id": {messageID}}
+	return h.delete("/moderation/chat?" + params.Encode())
+}
+
+// IsLive сообщает, идет ли сейчас стрим на канале
+func (h *HelixClient) IsLive(login string) (bool, error) {
+	var resp struct {
+		Data []struct {
+			Type string `json:"type"`
+		} `json:"data"`
+	}
+	if err := h.get("/streams", url.Values{"user_login": {login}}, &resp); err != nil {
+		return false, err
+	}
+	return len(resp.Data) > 0 && resp.Data[0].Type == "live", nil
+}
@@ -0,0 +1,150 @@
+// helixbreaker.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// Повторы GET-запроса при сбое и пауза перед первым повтором (дальше вдвое больше)
+	helixRetries    = 2
+	helixRetryDelay = 250 * time.Millisecond
+
+	// Подряд неудачных запросов, после которых Helix считается недоступным
+	helixFailureThreshold = 3
+	// Пауза до пробного запроса: растет вдвое после каждой неудачной пробы
+	helixBreakerMin = 30 * time.Second
+	helixBreakerMax = 5 * time.Minute
+
+	// Кэш ответов: сколько запросов помнить и насколько старые ответы отдавать
+	helixCacheSize  = 500
+	helixStaleLimit = 6 * time.Hour
+)
+
+// Helix не отвечает или предохранитель разомкнут
+var ErrHelixUnavailable = errors.New("twitch api временно недоступен")
+
+// Ошибка запроса к Helix: сетевая или статус ответа
+type helixError struct {
+	path   string
+	status int
+	err    error
+}
+
+func (e *helixError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("ошибка запроса Helix %s: %v", e.path, e.err)
+	}
+	return fmt.Sprintf("helix %s вернул статус %d", e.path, e.status)
+}
+
+func (e *helixError) Unwrap() error { return e.err }
+
+// helixUnavailable сообщает, что ошибка - сбой на стороне Twitch, а не
+// ошибка самого запроса: сеть, 5xx, 429 или разомкнутый предохранитель
+func helixUnavailable(err error) bool {
+	if errors.Is(err, ErrHelixUnavailable) {
+		return true
+	}
+	var helixErr *helixError
+	if !errors.As(err, &helixErr) {
+		return false
+	}
+	return helixErr.err != nil || helixErr.status >= 500 || helixErr.status == 429
+}
+
+// Предохранитель Helix: после нескольких сбоев подряд запросы не отправляются
+// до пробного, чтобы бот не ждал таймаутов на каждой команде
+type helixBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	backoff   time.Duration
+	openUntil time.Time
+}
+
+// Allow сообщает, можно ли отправить запрос. После паузы пропускает пробный.
+func (b *helixBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return !time.Now().Before(b.openUntil)
+}
+
+// Success отмечает ответ Helix и замыкает предохранитель
+func (b *helixBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.backoff > 0 {
+		slog.Info("Twitch API снова доступен")
+	}
+	b.failures, b.backoff, b.openUntil = 0, 0, time.Time{}
+}
+
+// Failure отмечает сбой. Неудачная проба размыкает предохранитель сразу.
+func (b *helixBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.failures < helixFailureThreshold && b.backoff == 0 {
+		return
+	}
+
+	b.backoff = min(max(b.backoff*2, helixBreakerMin), helixBreakerMax)
+	b.openUntil = time.Now().Add(b.backoff)
+	slog.Warn("Twitch API недоступен, запросы приостановлены", "retry_in", b.backoff)
+	alerts.Alert(AlertHelix, fmt.Sprintf("Twitch API не отвечает, бот работает на кэше (следующая проба через %s)", b.backoff))
+}
+
+// Последние успешные ответы Helix по запросам
+type helixCache struct {
+	mu      sync.Mutex
+	entries map[string]helixCacheEntry
+}
+
+type helixCacheEntry struct {
+	body []byte
+	at   time.Time
+}
+
+func newHelixCache() *helixCache {
+	return &helixCache{entries: make(map[string]helixCacheEntry)}
+}
+
+func (c *helixCache) Put(key string, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= helixCacheSize {
+		// Вытесняем самый старый ответ
+		var oldest string
+		for k, entry := range c.entries {
+			if oldest == "" || entry.at.Before(c.entries[oldest].at) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = helixCacheEntry{body: body, at: time.Now()}
+}
+
+// Get возвращает сохраненный ответ и его возраст, если он не старше helixStaleLimit
+func (c *helixCache) Get(key string) ([]byte, time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, 0, false
+	}
+	age := time.Since(entry.at)
+	if age > helixStaleLimit {
+		return nil, 0, false
+	}
+	return entry.body, age, true
+}
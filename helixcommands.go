@@ -0,0 +1,190 @@
+// helixcommands.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// StreamStartedAt возвращает время начала стрима. age > 0 - данные из кэша,
+// потому что Helix сейчас недоступен.
+func (h *HelixClient) StreamStartedAt(login string) (started time.Time, live bool, age time.Duration, err error) {
+	var resp struct {
+		Data []struct {
+			Type      string    `json:"type"`
+			StartedAt time.Time `json:"started_at"`
+		} `json:"data"`
+	}
+	if age, err = h.getCached("/streams", url.Values{"user_login": {login}}, &resp); err != nil {
+		return time.Time{}, false, 0, err
+	}
+	if len(resp.Data) == 0 || resp.Data[0].Type != "live" {
+		return time.Time{}, false, age, nil
+	}
+	return resp.Data[0].StartedAt, true, age, nil
+}
+
+// FollowedAt возвращает время, с которого пользователь фолловит канал.
+// Требует scope moderator:read:followers и прав модератора в канале.
+func (h *HelixClient) FollowedAt(broadcasterID, userID string) (since time.Time, follows bool, age time.Duration, err error) {
+	var resp struct {
+		Data []struct {
+			FollowedAt time.Time `json:"followed_at"`
+		} `json:"data"`
+	}
+	params := url.Values{"broadcaster_id": {broadcasterID}, "user_id": {userID}}
+	if age, err = h.getCached("/channels/followers", params, &resp); err != nil {
+		return time.Time{}, false, 0, err
+	}
+	if len(resp.Data) == 0 {
+		return time.Time{}, false, age, nil
+	}
+	return resp.Data[0].FollowedAt, true, age, nil
+}
+
+// ChannelGame возвращает категорию, в которой канал стримил последним
+func (h *HelixClient) ChannelGame(broadcasterID string) (string, error) {
+	var resp struct {
+		Data []struct {
+			GameName string `json:"game_name"`
+		} `json:"data"`
+	}
+	if _, err := h.getCached("/channels", url.Values{"broadcaster_id": {broadcasterID}}, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Data) == 0 {
+		return "", nil
+	}
+	return resp.Data[0].GameName, nil
+}
+
+// Shoutout делает официальный шаутаут Twitch. Требует scope
+// moderator:manage:shoutouts и прав модератора в канале.
+func (h *HelixClient) Shoutout(fromBroadcasterID, toBroadcasterID, moderatorID string) error {
+	params := url.Values{
+		"from_broadcaster_id": {fromBroadcasterID},
+		"to_broadcaster_id":   {toBroadcasterID},
+		"moderator_id":        {moderatorID},
+	}
+	return h.post("/chat/shoutouts?"+params.Encode(), nil, nil)
+}
+
+// registerHelixCommands регистрирует команды с данными Twitch API: !uptime,
+// !followage и !so. Они выполняются вне чтения чата, а при сбое Helix
+// отвечают по кэшу с пометкой о давности или шаблоном helix_unavailable.
+func (b *Bot) registerHelixCommands() {
+	b.registerHandler(asyncHandler{handlerFunc{[]string{"!uptime", "!аптайм"}, func(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+		helix := b.helixFor(message.Channel)
+		if helix == nil {
+			return ""
+		}
+		started, live, age, err := helix.StreamStartedAt(normalizeChannel(message.Channel))
+		if err != nil {
+			return b.helixFallback(message, err)
+		}
+		if !live {
+			return "Стрим сейчас не идет" + b.staleNote(message, age)
+		}
+		return "Стрим идет " + formatUptime(time.Since(started)) + b.staleNote(message, age)
+	}}})
+
+	b.registerHandler(asyncHandler{handlerFunc{[]string{"!followage", "!фолловинг"}, func(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+		helix := b.helixFor(message.Channel)
+		if helix == nil {
+			return ""
+		}
+
+		login, userID := message.User.Name, message.User.ID
+		if len(args) > 0 {
+			login = strings.ToLower(strings.TrimPrefix(args[0], "@"))
+			users, err := helix.GetUsers(login)
+			if err != nil {
+				return b.helixFallback(message, err)
+			}
+			if len(users) == 0 {
+				return fmt.Sprintf("@%s, пользователь %s не найден", message.User.Name, login)
+			}
+			userID = users[0].ID
+		}
+
+		since, follows, age, err := helix.FollowedAt(message.RoomID, userID)
+		if err != nil {
+			return b.helixFallback(message, err)
+		}
+		if !follows {
+			return fmt.Sprintf("%s не фолловит канал", login) + b.staleNote(message, age)
+		}
+		location := b.Config().LocationFor(message.Channel)
+		days := int(time.Since(since).Hours()) / 24
+		return fmt.Sprintf("%s фолловит канал с %s (%d дн.)", login, since.In(location).Format(defaultDateLayout), days) + b.staleNote(message, age)
+	}}})
+
+	b.registerHandler(asyncHandler{handlerFunc{[]string{"!so", "!шаутаут"}, func(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+		if !isPrivileged(message.User) || len(args) == 0 {
+			return ""
+		}
+		conn := b.pool.For(message.Channel)
+		if conn == nil || conn.helix == nil {
+			return ""
+		}
+
+		login := strings.ToLower(strings.TrimPrefix(args[0], "@"))
+		users, err := conn.helix.GetUsers(login)
+		if err != nil {
+			// Без Twitch API шаутаут все равно полезен ссылкой на канал
+			slog.Warn("Шаутаут без данных Twitch API", "error", err, "target", login)
+			return fmt.Sprintf("Заходите к @%s: twitch.tv/%s", login, login)
+		}
+		if len(users) == 0 {
+			return fmt.Sprintf("@%s, пользователь %s не найден", message.User.Name, login)
+		}
+
+		if moderatorID, err := conn.selfID(); err == nil {
+			if err := conn.helix.Shoutout(message.RoomID, users[0].ID, moderatorID); err != nil {
+				slog.Warn("Ошибка официального шаутаута", "error", err, "target", login)
+			}
+		}
+
+		text := fmt.Sprintf("Заходите к @%s: twitch.tv/%s", login, login)
+		if game, err := conn.helix.ChannelGame(users[0].ID); err == nil && game != "" {
+			text += ", последняя категория: " + game
+		}
+		return text
+	}}})
+}
+
+// helixFor возвращает клиент Helix учетной записи, обслуживающей канал
+func (b *Bot) helixFor(channel string) *HelixClient {
+	conn := b.pool.For(channel)
+	if conn == nil {
+		return nil
+	}
+	return conn.helix
+}
+
+// helixFallback формирует ответ, когда данные Twitch получить не удалось
+func (b *Bot) helixFallback(message twitch.PrivateMessage, err error) string {
+	slog.Warn("Ошибка запроса к Twitch API", "error", err, "channel", message.Channel)
+	if !helixUnavailable(err) {
+		return fmt.Sprintf("@%s, не удалось получить данные Twitch", message.User.Name)
+	}
+	return renderTemplate(b.templates(message).HelixUnavailable, map[string]string{
+		"user": message.User.Name,
+	})
+}
+
+// staleNote возвращает пометку о давности данных из кэша (пусто для свежих)
+func (b *Bot) staleNote(message twitch.PrivateMessage, age time.Duration) string {
+	if age <= 0 {
+		return ""
+	}
+	return renderTemplate(b.templates(message).StaleNote, map[string]string{
+		"age": formatUptime(age),
+	})
+}
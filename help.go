@@ -0,0 +1,174 @@
+// help.go
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Категория команд без явно заданной category
+const uncategorized = "Прочее"
+
+// joinLimited соединяет items через ", " после header, укладываясь в limit символов.
+// То, что не поместилось, заменяется на "и еще N…".
+func joinLimited(header string, items []string, limit int) string {
+	text := header + strings.Join(items, ", ")
+	if len([]rune(text)) <= limit {
+		return text
+	}
+
+	shown := header
+	for i, item := range items {
+		candidate := shown
+		if i > 0 {
+			candidate += ", "
+		}
+		candidate += item
+
+		rest := fmt.Sprintf(" и еще %d…", len(items)-i-1)
+		if len([]rune(candidate+rest)) > limit {
+			return shown + fmt.Sprintf(" и еще %d…", len(items)-i)
+		}
+		shown = candidate
+	}
+	return shown
+}
+
+// commandsByCategory группирует доступные команды по категориям
+func commandsByCategory(commands map[string]*Command, now time.Time) map[string][]string {
+	categories := make(map[string][]string)
+	for name, cmd := range commands {
+		if !cmd.Available(now) {
+			continue
+		}
+		category := cmd.Category
+		if category == "" {
+			category = uncategorized
+		}
+		categories[category] = append(categories[category], name)
+	}
+	for _, names := range categories {
+		sort.Strings(names)
+	}
+	return categories
+}
+
+// sortedCategories возвращает имена категорий по алфавиту, "Прочее" в конце
+func sortedCategories(categories map[string][]string) []string {
+	names := make([]string, 0, len(categories))
+	for name := range categories {
+		if name != uncategorized {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := categories[uncategorized]; ok {
+		names = append(names, uncategorized)
+	}
+	return names
+}
+
+// commandsListText формирует ответ для !пасты [категория]. Если у команд есть
+// категории, они выводятся группами; если все не помещаются в одно сообщение,
+// выводится список категорий с числом команд в каждой.
+func (b *Bot) commandsListText(header string, args []string) string {
+	categories := commandsByCategory(b.Commands(), time.Now())
+
+	// !пасты <категория>
+	if len(args) > 0 {
+		wanted := strings.Join(args, " ")
+		for name, commands := range categories {
+			if strings.EqualFold(name, wanted) {
+				return joinLimited(header+name+": ", commands, maxMessageLength)
+			}
+		}
+		return fmt.Sprintf("Категория «%s» не найдена", wanted)
+	}
+
+	names := sortedCategories(categories)
+	if len(names) <= 1 {
+		var commands []string
+		for _, list := range categories {
+			commands = list
+		}
+		return joinLimited(header, commands, maxMessageLength)
+	}
+
+	groups := make([]string, 0, len(names))
+	for _, name := range names {
+		groups = append(groups, name+": "+strings.Join(categories[name], ", "))
+	}
+	if text := header + strings.Join(groups, " | "); len([]rune(text)) <= maxMessageLength {
+		return text
+	}
+
+	summary := make([]string, 0, len(names))
+	for _, name := range names {
+		summary = append(summary, fmt.Sprintf("%s (%d)", name, len(categories[name])))
+	}
+	const more = ". Подробнее: !пасты <категория>"
+	return joinLimited("Категории: ", summary, maxMessageLength-len([]rune(more))) + more
+}
+
+// Описания встроенных команд для !помощь
+var builtinDescriptions = map[string]string{
+	"!пасты":      "список команд, !пасты <категория> - команды категории",
+	"!commands":   "список команд, !commands <категория> - команды категории",
+	"!помощь":     "описание команды: !помощь <команда>",
+	"!help":       "описание команды: !help <команда>",
+	"!найти":      "поиск команд по слову: !найти <слово>",
+	"!статистика": "сколько раз вызывали команду: !статистика <команда>",
+	"!последние":  "последние вызовы команд (только модераторам)",
+	"!время":      "местное время стримера",
+	"!version":    "версия бота",
+	"!версия":     "версия бота",
+	"!uptime":     "сколько идет стрим",
+	"!аптайм":     "сколько идет стрим",
+	"!followage":  "сколько вы фолловите канал",
+	"!фолловинг":  "сколько вы фолловите канал",
+	"!so":         "шаутаут каналу (только модераторам)",
+	"!шаутаут":    "шаутаут каналу (только модераторам)",
+}
+
+// helpText формирует ответ для !помощь <команда>
+func (b *Bot) helpText(args []string) string {
+	if len(args) == 0 {
+		return "Использование: !помощь <команда>. Список команд: !пасты"
+	}
+
+	name := args[0]
+	if !strings.HasPrefix(name, "!") {
+		name = "!" + name
+	}
+
+	// Встроенные команды перекрывают одноименные из commands.yaml, как и при вызове
+	if b.isBuiltin(name) {
+		description, ok := builtinDescriptions[name]
+		if !ok {
+			description = "встроенная команда бота"
+		}
+		return name + " - " + description
+	}
+
+	// Выключенная команда не отвечает, поэтому и в справке ее нет, как на странице команд
+	command, ok := b.Commands()[name]
+	if !ok || command.Disabled {
+		return fmt.Sprintf("Команда %s не найдена", name)
+	}
+
+	text := name
+	if command.Category != "" {
+		text += " [" + command.Category + "]"
+	}
+	if command.Description != "" {
+		text += " - " + command.Description
+	} else {
+		text += " - описания нет"
+	}
+	if command.Requires != "" {
+		text += fmt.Sprintf(" (доступна только %s)", requirementNames[command.Requires])
+	}
+	return text
+}
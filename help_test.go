@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestHelpText(t *testing.T) {
+	b := &Bot{
+		config: &Config{},
+		commands: map[string]*Command{
+			"!дискорд":  {Command: "!дискорд", Description: "ссылка на дискорд", Category: "Ссылки"},
+			"!старая":   {Command: "!старая", Description: "выключена", Disabled: true},
+			"!безописи": {Command: "!безописи"},
+		},
+	}
+	b.registerBuiltins()
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"без аргументов", nil, "Использование: !помощь <команда>. Список команд: !пасты"},
+		{"команда из файла", []string{"дискорд"}, "!дискорд [Ссылки] - ссылка на дискорд"},
+		{"с восклицательным знаком", []string{"!дискорд"}, "!дискорд [Ссылки] - ссылка на дискорд"},
+		{"без описания", []string{"безописи"}, "!безописи - описания нет"},
+		{"выключенная команда", []string{"старая"}, "Команда !старая не найдена"},
+		{"встроенная команда", []string{"пасты"}, "!пасты - " + builtinDescriptions["!пасты"]},
+		{"английский синоним", []string{"help"}, "!help - " + builtinDescriptions["!help"]},
+		{"неизвестная команда", []string{"нет"}, "Команда !нет не найдена"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.helpText(tt.args); got != tt.want {
+				t.Errorf("helpText(%q) = %q, ожидалось %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
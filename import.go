@@ -0,0 +1,203 @@
+// import.go
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Стратегии разрешения конфликтов при импорте
+const (
+	ImportSkip      = "skip"
+	ImportOverwrite = "overwrite"
+)
+
+// Максимальный размер импортируемого файла
+const maxImportSize = 1 << 20
+
+// Хосты, с которых разрешен импорт, если IMPORT_ALLOWED_HOSTS не задан
+var defaultImportHosts = []string{"pastebin.com", "gist.githubusercontent.com"}
+
+// Итог импорта
+type ImportResult struct {
+	Added       []string `json:"added"`
+	Overwritten []string `json:"overwritten"`
+	Skipped     []string `json:"skipped"`
+	Invalid     []string `json:"invalid"`
+}
+
+func (r ImportResult) String() string {
+	return fmt.Sprintf("добавлено %d, перезаписано %d, пропущено %d, с ошибками %d",
+		len(r.Added), len(r.Overwritten), len(r.Skipped), len(r.Invalid))
+}
+
+// rawImportURL проверяет адрес и приводит ссылку на Pastebin к raw-виду
+func rawImportURL(rawURL string, allowedHosts []string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return "", fmt.Errorf("неверная ссылка %q", rawURL)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	allowed := false
+	for _, h := range allowedHosts {
+		if host == strings.ToLower(h) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", fmt.Errorf("импорт с %s запрещен", host)
+	}
+
+	// pastebin.com/XXXX -> pastebin.com/raw/XXXX
+	if host == "pastebin.com" && !strings.HasPrefix(u.Path, "/raw/") {
+		u.Path = "/raw" + u.Path
+	}
+	u.Scheme = "https"
+	return u.String(), nil
+}
+
+// fetchImport скачивает и разбирает файл с командами
+func fetchImport(rawURL string, allowedHosts []string) ([]Command, error) {
+	target, err := rawImportURL(rawURL, allowedHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(target)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s вернул статус %d", target, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImportSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки %s: %w", target, err)
+	}
+	if len(data) > maxImportSize {
+		return nil, fmt.Errorf("файл больше %d байт", maxImportSize)
+	}
+
+	return parseImport(data)
+}
+
+// parseImport разбирает YAML в формате commands.yaml или CSV "команда,текст"
+func parseImport(data []byte) ([]Command, error) {
+	var config CommandsConfig
+	if err := yaml.Unmarshal(data, &config); err == nil && len(config.Messages) > 0 {
+		return config.Messages, nil
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("файл не похож ни на YAML, ни на CSV: %w", err)
+	}
+
+	var commands []Command
+	for i, record := range records {
+		if len(record) < 2 {
+			continue
+		}
+		// Необязательная строка заголовка
+		if i == 0 && strings.EqualFold(record[0], "command") {
+			continue
+		}
+		commands = append(commands, Command{
+			Command: strings.TrimSpace(record[0]),
+			Text:    strings.TrimSpace(record[1]),
+		})
+	}
+
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("в файле нет команд")
+	}
+	return commands, nil
+}
+
+// importFromURL скачивает файл и импортирует из него команды
+func (b *Bot) importFromURL(actor, rawURL, strategy string) (ImportResult, error) {
+	imported, err := fetchImport(rawURL, b.importHosts)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	return b.importCommands(actor, imported, strategy)
+}
+
+// importCommands проверяет и сохраняет команды, обновляя текущий набор
+func (b *Bot) importCommands(actor string, imported []Command, strategy string) (ImportResult, error) {
+	var result ImportResult
+
+	if b.store == nil {
+		return result, fmt.Errorf("импорт недоступен: база данных отключена")
+	}
+	if strategy != ImportSkip && strategy != ImportOverwrite {
+		return result, fmt.Errorf("неизвестная стратегия %q (skip, overwrite)", strategy)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Набор команд неизменяемый, поэтому собираем новую копию
+	commands := make(map[string]*Command, len(b.commands)+len(imported))
+	for name, cmd := range b.commands {
+		commands[name] = cmd
+	}
+
+	for i := range imported {
+		cmd := &imported[i]
+		if err := prepareCommand(cmd); err != nil {
+			slog.Warn("Команда не импортирована", "error", err)
+			result.Invalid = append(result.Invalid, cmd.Command)
+			continue
+		}
+
+		_, exists := commands[cmd.Command]
+		if b.isBuiltin(cmd.Command) || (exists && strategy == ImportSkip) {
+			result.Skipped = append(result.Skipped, cmd.Command)
+			continue
+		}
+
+		if err := b.store.Save(*cmd); err != nil {
+			return result, err
+		}
+		commands[cmd.Command] = cmd
+
+		if exists {
+			result.Overwritten = append(result.Overwritten, cmd.Command)
+			b.audit.Record(actor, AuditCommandEdit, cmd.Command, "импорт")
+		} else {
+			result.Added = append(result.Added, cmd.Command)
+			b.audit.Record(actor, AuditCommandAdd, cmd.Command, "импорт")
+		}
+	}
+
+	b.setCommandsLocked(commands)
+
+	slog.Info("Импорт команд завершен",
+		"actor", actor,
+		"added", len(result.Added),
+		"overwritten", len(result.Overwritten),
+		"skipped", len(result.Skipped),
+		"invalid", len(result.Invalid))
+
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRawImportURL(t *testing.T) {
+	hosts := []string{"pastebin.com", "gist.githubusercontent.com"}
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"pastebin в raw", "https://pastebin.com/AbCd12", "https://pastebin.com/raw/AbCd12", false},
+		{"pastebin уже raw", "https://pastebin.com/raw/AbCd12", "https://pastebin.com/raw/AbCd12", false},
+		{"http заменяется на https", "http://pastebin.com/raw/AbCd12", "https://pastebin.com/raw/AbCd12", false},
+		{"регистр хоста", "https://PasteBin.com/AbCd12", "https://PasteBin.com/raw/AbCd12", false},
+		{"gist без изменений", "https://gist.githubusercontent.com/u/1/raw/c.yaml", "https://gist.githubusercontent.com/u/1/raw/c.yaml", false},
+		{"чужой хост", "https://example.com/c.yaml", "", true},
+		{"поддомен не разрешен", "https://evil.pastebin.com/AbCd12", "", true},
+		{"другая схема", "ftp://pastebin.com/AbCd12", "", true},
+		{"не ссылка", "pastebin.com/AbCd12", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rawImportURL(tt.in, hosts)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("rawImportURL(%q) = %q, %v, ожидалось %q, ошибка %v", tt.in, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseImport(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Command
+		wantErr bool
+	}{
+		{
+			name: "YAML",
+			data: "messages:\n  - command: \"!паста\"\n    text: текст\n",
+			want: []Command{{Command: "!паста", Text: "текст"}},
+		},
+		{
+			name: "CSV",
+			data: "!раз,один\n!два, два слова \n",
+			want: []Command{{Command: "!раз", Text: "один"}, {Command: "!два", Text: "два слова"}},
+		},
+		{
+			name: "CSV с заголовком",
+			data: "command,text\n!раз,один\n",
+			want: []Command{{Command: "!раз", Text: "один"}},
+		},
+		{
+			name: "CSV с запятой в кавычках",
+			data: "!раз,\"один, два\"\n",
+			want: []Command{{Command: "!раз", Text: "один, два"}},
+		},
+		{
+			name: "строки без текста пропускаются",
+			data: "!раз\n!два,два\n",
+			want: []Command{{Command: "!два", Text: "два"}},
+		},
+		{name: "нет команд", data: "command,text\n", wantErr: true},
+		{name: "пустой файл", data: "", wantErr: true},
+		{name: "сломанный CSV", data: "!раз,\"один\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImport([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseImport ошибка = %v, ожидалась %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseImport = %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
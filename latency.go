@@ -0,0 +1,254 @@
+// latency.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Границы корзин гистограммы задержек
+var latencyBuckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Этапы ответа: total - от получения сообщения до отправки в Twitch,
+// queue - из них ожидание в очереди отправки и лимитах Twitch
+const (
+	LatencyTotal = "total"
+	LatencyQueue = "queue"
+)
+
+// Гистограмма задержек
+type latencyHistogram struct {
+	counts []uint64 // по корзинам, последняя - больше всех границ
+	sum    time.Duration
+	total  uint64
+}
+
+func newLatencyHistogram() *latencyHistogram {
+	return &latencyHistogram{counts: make([]uint64, len(latencyBuckets)+1)}
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	i := sort.Search(len(latencyBuckets), func(i int) bool { return d <= latencyBuckets[i] })
+	h.counts[i]++
+	h.sum += d
+	h.total++
+}
+
+// quantile оценивает квантиль линейной интерполяцией внутри корзины
+func (h *latencyHistogram) quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := q * float64(h.total)
+	var seen float64
+	for i, count := range h.counts {
+		if count == 0 {
+			continue
+		}
+		if seen+float64(count) >= rank {
+			if i == len(latencyBuckets) {
+				return latencyBuckets[i-1]
+			}
+			var lower time.Duration
+			if i > 0 {
+				lower = latencyBuckets[i-1]
+			}
+			fraction := (rank - seen) / float64(count)
+			return lower + time.Duration(fraction*float64(latencyBuckets[i]-lower))
+		}
+		seen += float64(count)
+	}
+	return latencyBuckets[len(latencyBuckets)-1]
+}
+
+// Задержки и ошибки ответов по командам
+type LatencyMetrics struct {
+	mu     sync.Mutex
+	stages map[string]map[string]*latencyHistogram // команда -> этап
+	errors map[string]uint64
+}
+
+func NewLatencyMetrics() *LatencyMetrics {
+	return &LatencyMetrics{
+		stages: make(map[string]map[string]*latencyHistogram),
+		errors: make(map[string]uint64),
+	}
+}
+
+// Метрики ответов процесса; заполняет очередь отправки
+var latencyMetrics = NewLatencyMetrics()
+
+// Observe учитывает задержку этапа ответа на команду
+func (m *LatencyMetrics) Observe(command, stage string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stages, ok := m.stages[command]
+	if !ok {
+		stages = make(map[string]*latencyHistogram)
+		m.stages[command] = stages
+	}
+	h, ok := stages[stage]
+	if !ok {
+		h = newLatencyHistogram()
+		stages[stage] = h
+	}
+	h.observe(d)
+}
+
+// Error учитывает неотправленный ответ на команду
+func (m *LatencyMetrics) Error(command string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors[command]++
+}
+
+// Сводка задержек одной команды или всех сразу
+type LatencySummary struct {
+	Count    uint64
+	Errors   uint64
+	P50, P95 time.Duration
+	QueueP50 time.Duration
+	QueueP95 time.Duration
+}
+
+// Summary возвращает сводку по команде; пустая команда - по всем
+func (m *LatencyMetrics) Summary(command string) LatencySummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	total, queue := newLatencyHistogram(), newLatencyHistogram()
+	var summary LatencySummary
+	for name, stages := range m.stages {
+		if command != "" && name != command {
+			continue
+		}
+		total.merge(stages[LatencyTotal])
+		queue.merge(stages[LatencyQueue])
+	}
+	for name, count := range m.errors {
+		if command == "" || name == command {
+			summary.Errors += count
+		}
+	}
+
+	summary.Count = total.total
+	summary.P50, summary.P95 = total.quantile(0.5), total.quantile(0.95)
+	summary.QueueP50, summary.QueueP95 = queue.quantile(0.5), queue.quantile(0.95)
+	return summary
+}
+
+func (h *latencyHistogram) merge(other *latencyHistogram) {
+	if other == nil {
+		return
+	}
+	for i, count := range other.counts {
+		h.counts[i] += count
+	}
+	h.sum += other.sum
+	h.total += other.total
+}
+
+// WritePrometheus выводит метрики в текстовом формате Prometheus
+func (m *LatencyMetrics) WritePrometheus(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	commands := make([]string, 0, len(m.stages))
+	for command := range m.stages {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	fmt.Fprintln(w, "# HELP pastebot_response_latency_seconds Time from message receipt to sending the response.")
+	fmt.Fprintln(w, "# TYPE pastebot_response_latency_seconds histogram")
+	for _, command := range commands {
+		for _, stage := range []string{LatencyTotal, LatencyQueue} {
+			h, ok := m.stages[command][stage]
+			if !ok {
+				continue
+			}
+			labels := fmt.Sprintf("command=%q,stage=%q", command, stage)
+			var cumulative uint64
+			for i, bound := range latencyBuckets {
+				cumulative += h.counts[i]
+				fmt.Fprintf(w, "pastebot_response_latency_seconds_bucket{%s,le=\"%g\"} %d\n", labels, bound.Seconds(), cumulative)
+			}
+			fmt.Fprintf(w, "pastebot_response_latency_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.total)
+			fmt.Fprintf(w, "pastebot_response_latency_seconds_sum{%s} %g\n", labels, h.sum.Seconds())
+			fmt.Fprintf(w, "pastebot_response_latency_seconds_count{%s} %d\n", labels, h.total)
+		}
+	}
+
+	errors := make([]string, 0, len(m.errors))
+	for command := range m.errors {
+		errors = append(errors, command)
+	}
+	sort.Strings(errors)
+
+	fmt.Fprintln(w, "# HELP pastebot_response_errors_total Responses that could not be sent.")
+	fmt.Fprintln(w, "# TYPE pastebot_response_errors_total counter")
+	for _, command := range errors {
+		fmt.Fprintf(w, "pastebot_response_errors_total{command=%q} %d\n", command, m.errors[command])
+	}
+}
+
+type latencyKey struct{}
+
+// Команда и время получения сообщения, на которое отвечаем
+type latencyOrigin struct {
+	command    string
+	receivedAt time.Time
+	// Вызывается с полной задержкой, когда первая часть ответа ушла в чат
+	onSent func(latency time.Duration)
+}
+
+// withLatencyOrigin помечает ответы, отправленные в рамках ctx, для учета задержки.
+// onSent может быть nil.
+func withLatencyOrigin(ctx context.Context, command string, receivedAt time.Time, onSent func(time.Duration)) context.Context {
+	return context.WithValue(ctx, latencyKey{}, latencyOrigin{command: command, receivedAt: receivedAt, onSent: onSent})
+}
+
+func latencyOriginFrom(ctx context.Context) latencyOrigin {
+	origin, _ := ctx.Value(latencyKey{}).(latencyOrigin)
+	return origin
+}
+
+// latencyText формирует ответ на !bot latency [команда]
+func latencyText(command string) string {
+	summary := latencyMetrics.Summary(command)
+	subject := "всех команд"
+	if command != "" {
+		subject = command
+	}
+	if summary.Count == 0 && summary.Errors == 0 {
+		return "Нет данных о задержках " + subject
+	}
+
+	ms := func(d time.Duration) string {
+		return fmt.Sprintf("%d мс", d.Milliseconds())
+	}
+	parts := []string{
+		fmt.Sprintf("Задержка %s: p50 %s, p95 %s", subject, ms(summary.P50), ms(summary.P95)),
+		fmt.Sprintf("из них очередь p50 %s, p95 %s", ms(summary.QueueP50), ms(summary.QueueP95)),
+		fmt.Sprintf("ответов %d, ошибок %d", summary.Count, summary.Errors),
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,152 @@
+// locale.go
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Язык системных сообщений канала
+const (
+	LocaleRU   = "ru"
+	LocaleEN   = "en"
+	LocaleAuto = "auto"
+)
+
+// Шаблоны системных сообщений для языков, кроме русского (он в defaultTemplates)
+var localeTemplates = map[string]TemplatesConfig{
+	LocaleEN: {
+		CommandsHeader:     "Available commands: ",
+		UnknownCommand:     "@{user} Unknown command. Use !commands to list commands.",
+		PermissionDenied:   "@{user}, {command} is available only to {requirement}",
+		DuplicateAggregate: "{text} (requested {count} times)",
+		LocalTime:          "Streamer's local time is {time} ({timezone})",
+		CommandsLink:       "@{user}, commands: {url}",
+		Exhausted:          "@{user}, {command} is used up for this stream",
+		HelixUnavailable:   "@{user}, Twitch API is unavailable right now, try again later",
+		StaleNote:          " (as of {age} ago)",
+	},
+}
+
+// validLocale проверяет значение locale из config.yaml
+func validLocale(locale string) bool {
+	switch locale {
+	case "", LocaleRU, LocaleEN, LocaleAuto:
+		return true
+	}
+	return false
+}
+
+// detectLocale определяет язык сообщения по преобладающему алфавиту
+func detectLocale(text string) string {
+	cyrillic, latin := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	if latin > cyrillic {
+		return LocaleEN
+	}
+	return LocaleRU
+}
+
+// locale возвращает язык ответа на сообщение: канал > config.yaml > ru
+func (b *Bot) locale(message twitch.PrivateMessage) string {
+	config := b.Config()
+	locale := config.Channel(message.Channel).Locale
+	if locale == "" {
+		locale = config.Locale
+	}
+	switch locale {
+	case LocaleAuto:
+		return detectLocale(message.Message)
+	case "":
+		return LocaleRU
+	}
+	return locale
+}
+
+// templates возвращает шаблоны системных сообщений для сообщения с учетом языка канала
+func (b *Bot) templates(message twitch.PrivateMessage) TemplatesConfig {
+	config := b.Config()
+	fallback := b.baseTemplates
+
+	if localized, ok := localeTemplates[b.locale(message)]; ok {
+		fallback = localized.merge(fallback)
+	}
+
+	return config.TemplatesFor(message.Channel, fallback)
+}
+
+// commandPrefix возвращает префикс команд канала
+func (b *Bot) commandPrefix(channel string) string {
+	if prefix := b.Config().Channel(channel).Prefix; prefix != "" {
+		return prefix
+	}
+	return "!"
+}
+
+// applyPrefix приводит команды с префиксом канала к виду "!команда", под которым
+// они записаны в commands.yaml. Слова с "!" в канале с другим префиксом
+// командами не считаются. Возвращает также, начиналось ли сообщение с префикса.
+func applyPrefix(text, prefix string) (string, bool) {
+	direct := strings.HasPrefix(text, prefix)
+	if prefix == "!" {
+		return text, direct
+	}
+
+	words := strings.Fields(text)
+	for i, word := range words {
+		switch {
+		case strings.HasPrefix(word, prefix) && len(word) > len(prefix):
+			words[i] = "!" + strings.TrimPrefix(word, prefix)
+		case strings.HasPrefix(word, "!"):
+			words[i] = strings.TrimLeft(word, "!")
+		}
+	}
+	return strings.Join(words, " "), direct
+}
+
+// renderPrefix заменяет "!" у команд бота в системном ответе на префикс канала,
+// чтобы подсказки вроде "Используйте !пасты" можно было повторить в чате
+func (b *Bot) renderPrefix(channel, text string) string {
+	prefix := b.commandPrefix(channel)
+	if prefix == "!" || !strings.Contains(text, "!") {
+		return text
+	}
+
+	words := strings.Split(text, " ")
+	for i, word := range words {
+		if !strings.HasPrefix(word, "!") {
+			continue
+		}
+		name := word
+		if !b.isBotCommand(name) {
+			name = trimCommandToken(word)
+			if !b.isBotCommand(name) {
+				continue
+			}
+		}
+		words[i] = prefix + strings.TrimPrefix(word, "!")
+	}
+	return strings.Join(words, " ")
+}
+
+// isBotCommand сообщает, является ли слово командой бота, в том числе служебной
+func (b *Bot) isBotCommand(name string) bool {
+	return adminCommands[name] || b.isKnownCommand(name)
+}
+
+// mentionOnlyFor сообщает, отвечает ли бот в канале только на упоминания
+func (b *Bot) mentionOnlyFor(channel string) bool {
+	if mentionOnly := b.Config().Channel(channel).MentionOnly; mentionOnly != nil {
+		return *mentionOnly
+	}
+	return b.mentionOnly
+}
@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestApplyPrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		prefix string
+		want   string
+		direct bool
+	}{
+		{"обычный префикс без изменений", "!паста3 Kappa", "!", "!паста3 Kappa", true},
+		{"обычный префикс в середине", "эй !паста3", "!", "эй !паста3", false},
+		{"свой префикс", "%паста3 Kappa", "%", "!паста3 Kappa", true},
+		{"свой префикс в середине", "скинь %паста3", "%", "скинь !паста3", false},
+		{"! в канале со своим префиксом", "!паста3", "%", "паста3", false},
+		{"префикс из нескольких символов", "bot.паста3", "bot.", "!паста3", true},
+		{"один префикс без команды", "% Kappa", "%", "% Kappa", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, direct := applyPrefix(tt.text, tt.prefix)
+			if got != tt.want || direct != tt.direct {
+				t.Errorf("applyPrefix(%q, %q) = %q, %v, ожидалось %q, %v", tt.text, tt.prefix, got, direct, tt.want, tt.direct)
+			}
+		})
+	}
+}
+
+func TestRenderPrefix(t *testing.T) {
+	b := &Bot{
+		config: &Config{Channels: map[string]ChannelConfig{
+			"custom": {Prefix: "%"},
+		}},
+		commands: map[string]*Command{"!паста": {Command: "!паста"}},
+	}
+	b.registerBuiltins()
+
+	tests := []struct {
+		name    string
+		channel string
+		text    string
+		want    string
+	}{
+		{"обычный префикс", "plain", "Используйте !пасты для списка команд.", "Используйте !пасты для списка команд."},
+		{"встроенная команда", "custom", "Используйте !пасты для списка команд.", "Используйте %пасты для списка команд."},
+		{"список команд", "custom", "Доступные команды: !паста, !пасты", "Доступные команды: %паста, %пасты"},
+		{"служебная команда", "custom", "Используйте !bot resume, чтобы продолжить", "Используйте %bot resume, чтобы продолжить"},
+		{"английский синоним", "custom", "Use !commands to list commands.", "Use %commands to list commands."},
+		{"не команда", "custom", "вызов! !неизвестная", "вызов! !неизвестная"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.renderPrefix("#"+tt.channel, tt.text); got != tt.want {
+				t.Errorf("renderPrefix(%q) = %q, ожидалось %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
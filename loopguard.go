@@ -0,0 +1,93 @@
+// loopguard.go
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Известные боты, на сообщения которых не отвечаем, если KNOWN_BOTS не задан
+var defaultKnownBots = []string{"nightbot", "streamelements", "moobot", "fossabot", "streamlabs", "wizebot", "soundalerts"}
+
+// Отправители, сообщения которых бот не обрабатывает
+type SenderFilter struct {
+	pool        *ConnectionPool
+	allowSelf   bool
+	ignoredBots map[string]bool
+}
+
+func NewSenderFilter(pool *ConnectionPool, allowSelf bool, knownBots []string) *SenderFilter {
+	ignored := make(map[string]bool, len(knownBots))
+	for _, name := range knownBots {
+		ignored[strings.ToLower(strings.TrimPrefix(name, "@"))] = true
+	}
+	return &SenderFilter{pool: pool, allowSelf: allowSelf, ignoredBots: ignored}
+}
+
+// Ignored сообщает, нужно ли пропустить сообщение отправителя
+func (f *SenderFilter) Ignored(user string) bool {
+	if f.ignoredBots[strings.ToLower(user)] {
+		return true
+	}
+	return !f.allowSelf && f.pool.IsOwnAccount(user)
+}
+
+// Предохранитель: при превышении лимита ответов в минуту в канале бот замолкает
+// в нем на минуту. Защищает от петель, которые не удалось отсечь фильтром отправителей.
+type ResponseBreaker struct {
+	limit int
+
+	mu       sync.Mutex
+	channels map[string]*breakerState
+}
+
+type breakerState struct {
+	sent      []time.Time
+	openUntil time.Time
+}
+
+func NewResponseBreaker(limit int) *ResponseBreaker {
+	return &ResponseBreaker{limit: limit, channels: make(map[string]*breakerState)}
+}
+
+// Allow резервирует отправку ответа в канал или возвращает false, если предохранитель сработал
+func (r *ResponseBreaker) Allow(channel string) bool {
+	if r == nil {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	channel = normalizeChannel(channel)
+	st, ok := r.channels[channel]
+	if !ok {
+		st = &breakerState{}
+		r.channels[channel] = st
+	}
+
+	now := time.Now()
+	if now.Before(st.openUntil) {
+		return false
+	}
+
+	fresh := st.sent[:0]
+	for _, t := range st.sent {
+		if now.Sub(t) < time.Minute {
+			fresh = append(fresh, t)
+		}
+	}
+	st.sent = fresh
+
+	if len(st.sent) >= r.limit {
+		st.openUntil = now.Add(time.Minute)
+		st.sent = st.sent[:0]
+		slog.Error("Превышен лимит ответов в минуту, ответы в канале приостановлены на минуту", "limit", r.limit, "channel", channel)
+		return false
+	}
+
+	st.sent = append(st.sent, now)
+	return true
+}
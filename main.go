@@ -2,10 +2,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
-	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -18,46 +18,307 @@ import (
 type Command struct {
 	Command string `yaml:"command"`
 	Text    string `yaml:"text"`
+	// Если text задан списком - сообщения последовательности (Text - они же через пробел)
+	Parts []string `yaml:"-"`
+	// Пауза между сообщениями последовательности (по умолчанию 1.5 с)
+	SequenceDelayMs *int `yaml:"sequence_delay_ms,omitempty"`
+
+	// Расписание доступности (необязательно)
+	OnlyBetween string   `yaml:"only_between,omitempty"`
+	Days        []string `yaml:"days,omitempty"`
+	Timezone    string   `yaml:"timezone,omitempty"`
+
+	// Ограничение доступа: follower, subscriber, vip, moderator, broadcaster
+	Requires      string `yaml:"requires,omitempty"`
+	DeniedMessage string `yaml:"denied_message,omitempty"`
+
+	// Задержка ответа; если не задана, используется глобальная
+	DelayMs  *int `yaml:"delay_ms,omitempty"`
+	JitterMs *int `yaml:"jitter_ms,omitempty"`
+
+	// Описание и категория для !пасты и !помощь
+	Description string `yaml:"description,omitempty"`
+	Category    string `yaml:"category,omitempty"`
+
+	// Способ доставки: say, mention, reply, whisper, announce (по умолчанию RESPOND_AS)
+	RespondAs string `yaml:"respond_as,omitempty"`
+
+	// Сколько раз команду можно вызвать за стрим (0 - без ограничения)
+	MaxUsesPerStream int `yaml:"max_uses_per_stream,omitempty"`
+
+	// Команда модерации (type: moderation): действие timeout, warn или delete.
+	// Text в этом случае - уведомление о выполненном действии (может быть пустым).
+	Type     string `yaml:"type,omitempty"`
+	Action   string `yaml:"action,omitempty"`
+	Duration string `yaml:"duration,omitempty"`
+	Reason   string `yaml:"reason,omitempty"`
+
+	// Команда выключена (!bot disable, Admin API): не отвечает и не видна в списках
+	Disabled bool `yaml:"disabled,omitempty"`
+
+	schedule *Schedule
+}
+
+// Available сообщает, доступна ли команда в момент now
+func (c *Command) Available(now time.Time) bool {
+	return !c.Disabled && c.schedule.Active(now)
 }
 
 type CommandsConfig struct {
 	Messages []Command `yaml:"messages"`
 }
 
-// Структура для отслеживания глобального cooldown
+// Cooldown ответов: общий для всех команд, но свой у каждого канала, чтобы
+// паста в одном канале не заглушала остальные
 type GlobalCooldownManager struct {
 	mu       sync.Mutex
-	lastUsed time.Time
 	duration time.Duration
+	channels map[string]*channelCooldown
+
+	// Если задан, cooldown подстраивается под активность чата канала
+	adaptive *AdaptiveCooldown
+	// Если задан, cooldown общий для нескольких экземпляров бота
+	shared SharedCooldown
+	// Если задан, время последнего ответа сохраняется в базе и переживает
+	// перезапуск. Для каждого канала читается один раз, дальше только запись.
+	persist SharedCooldown
+}
+
+// Состояние cooldown одного канала
+type channelCooldown struct {
+	lastUsed   time.Time
+	noticeSent bool
+}
+
+// Хранилище времени последнего ответа по каналам, общее для нескольких экземпляров
+type SharedCooldown interface {
+	LastUsed(channel string) (time.Time, error)
+	MarkUsed(channel string, at time.Time, ttl time.Duration) error
 }
 
 func NewGlobalCooldownManager(duration time.Duration) *GlobalCooldownManager {
 	return &GlobalCooldownManager{
 		duration: duration,
+		channels: make(map[string]*channelCooldown),
 	}
 }
 
-func (gcm *GlobalCooldownManager) CanUse() bool {
+// Restore включает сохранение cooldown в базе. Время последнего ответа канала
+// загружается из нее при первом обращении к каналу.
+func (gcm *GlobalCooldownManager) Restore(persist SharedCooldown) {
 	gcm.mu.Lock()
 	defer gcm.mu.Unlock()
 
-	return time.Since(gcm.lastUsed) >= gcm.duration
+	gcm.persist = persist
 }
 
-func (gcm *GlobalCooldownManager) Use() {
+// state возвращает состояние канала; вызывается под gcm.mu
+func (gcm *GlobalCooldownManager) state(channel string) *channelCooldown {
+	if st, ok := gcm.channels[channel]; ok {
+		return st
+	}
+
+	st := &channelCooldown{}
+	if gcm.persist != nil {
+		last, err := gcm.persist.LastUsed(channel)
+		if err != nil {
+			slog.Warn("Ошибка чтения cooldown из базы", "error", err, "channel", channel)
+		}
+		st.lastUsed = last
+	}
+	gcm.channels[channel] = st
+	return st
+}
+
+// current возвращает действующую длительность cooldown канала
+func (gcm *GlobalCooldownManager) current(channel string) time.Duration {
+	if gcm.adaptive == nil {
+		return gcm.duration
+	}
+	return gcm.adaptive.Duration(channel, gcm.duration, time.Now())
+}
+
+// Duration возвращает действующую длительность cooldown канала с учетом активности его чата
+func (gcm *GlobalCooldownManager) Duration(channel string) time.Duration {
+	return gcm.current(normalizeChannel(channel))
+}
+
+// last возвращает время последнего ответа в канале. При недоступном общем
+// хранилище используется время последнего ответа этого экземпляра.
+func (gcm *GlobalCooldownManager) last(channel string, st *channelCooldown) time.Time {
+	if gcm.shared == nil {
+		return st.lastUsed
+	}
+	last, err := gcm.shared.LastUsed(channel)
+	if err != nil {
+		slog.Warn("Общий cooldown недоступен", "error", err)
+		return st.lastUsed
+	}
+	if last.After(st.lastUsed) {
+		return last
+	}
+	return st.lastUsed
+}
+
+func (gcm *GlobalCooldownManager) CanUse(channel string) bool {
+	channel = normalizeChannel(channel)
+
 	gcm.mu.Lock()
 	defer gcm.mu.Unlock()
 
-	gcm.lastUsed = time.Now()
+	return time.Since(gcm.last(channel, gcm.state(channel))) >= gcm.current(channel)
+}
+
+func (gcm *GlobalCooldownManager) Use(channel string) {
+	channel = normalizeChannel(channel)
+
+	gcm.mu.Lock()
+	st := gcm.state(channel)
+	st.lastUsed = time.Now()
+	st.noticeSent = false
+
+	at, ttl, persist := st.lastUsed, gcm.current(channel), gcm.persist
+	if gcm.shared != nil {
+		if err := gcm.shared.MarkUsed(channel, at, ttl); err != nil {
+			slog.Warn("Общий cooldown недоступен", "error", err)
+		}
+	}
+	gcm.mu.Unlock()
+
+	// Запись в базу вне блокировки, проверки cooldown ее не ждут
+	if persist != nil {
+		if err := persist.MarkUsed(channel, at, ttl); err != nil {
+			slog.Warn("Ошибка сохранения cooldown", "error", err)
+		}
+	}
+}
+
+// Remaining возвращает оставшееся время cooldown канала (0, если его нет)
+func (gcm *GlobalCooldownManager) Remaining(channel string) time.Duration {
+	channel = normalizeChannel(channel)
+
+	gcm.mu.Lock()
+	defer gcm.mu.Unlock()
+
+	return max(gcm.current(channel)-time.Since(gcm.last(channel, gcm.state(channel))), 0)
+}
+
+// TakeNotice возвращает оставшееся время cooldown канала и разрешает
+// не более одного уведомления о cooldown за период
+func (gcm *GlobalCooldownManager) TakeNotice(channel string) (time.Duration, bool) {
+	channel = normalizeChannel(channel)
+
+	gcm.mu.Lock()
+	defer gcm.mu.Unlock()
+
+	st := gcm.state(channel)
+	remaining := gcm.current(channel) - time.Since(gcm.last(channel, st))
+	if remaining <= 0 || st.noticeSent {
+		return remaining, false
+	}
+
+	st.noticeSent = true
+	return remaining, true
 }
 
 type Bot struct {
-	client      *twitch.Client
-	commands    map[string]string
+	started     time.Time
+	pool        *ConnectionPool
 	cooldown    *GlobalCooldownManager
-	botUsername string
-	channel     string
 	mentionOnly bool
+	audit       *AuditLog
+	usage       UsageStorage
+	store       CommandStorage
+	variables   Variables
+	activity    *ChatActivity
+	pause       *PauseState
+	away        *AwayState
+	awayReplies *NoticeLimiter
+	mentions    *MentionMatcher
+
+	followers *FollowerCache
+	emotes    *EmoteCache
+	// Проверять пасты на смайлы, которых нет в канале
+	checkEmoteRefs bool
+	// Распознавать команды с пунктуацией и смайлами после них (COMMAND_MATCHING=lenient)
+	lenientMatching bool
+
+	// Задержка и способ доставки ответов по умолчанию
+	delay     ResponseDelay
+	respondAs string
+
+	// Подавление повторных вызовов команды
+	duplicates *TriggerDeduper
+
+	// Защита от петель: фильтр отправителей и лимит ответов в минуту
+	senders *SenderFilter
+	breaker *ResponseBreaker
+
+	// Временный игнор пользователей, флудящих командами
+	flood *FloodGuard
+
+	// Шаблоны системных сообщений по умолчанию (до переопределений из config.yaml)
+	baseTemplates TemplatesConfig
+
+	// Резервные копии команд
+	backupDir  string
+	backupKeep int
+
+	// Хосты, с которых разрешен импорт команд
+	importHosts []string
+
+	// Адрес страницы команд; если задан, !пасты отвечает ссылкой
+	commandsPageURL string
+
+	// Встроенные команды по имени
+	handlers map[string]Handler
+	// Отвечать ли на команды, недоступные пользователю, и как часто
+	deniedReply   bool
+	deniedLimiter *NoticeLimiter
+
+	// Статистика текущих стримов; nil - не отслеживается
+	sessions *SessionTracker
+	// Вызовы команд с max_uses_per_stream за текущий стрим
+	streamUses *StreamUses
+	// Последние выполненные команды для !последние
+	recent *RecentCommands
+
+	// Синонимы-транслитерации команд: синоним -> имя команды
+	transliterate bool
+	aliases       map[string]string
+
+	// Пробный запуск: ответы только пользователям из списка
+	softLaunch *SoftLaunch
+
+	// Запись входящих сообщений для replay; nil - не пишется
+	recorder *ChatRecorder
+
+	// Дополнительные звенья обработки сообщений (см. Use)
+	middleware []Middleware
+	duels      *DuelGame
+
+	// Команды и конфигурация заменяются целиком при перезагрузке
+	mu           sync.RWMutex
+	commands     map[string]*Command
+	config       *Config
+	commandsFile string
+	configFile   string
+}
+
+// Commands возвращает текущий набор команд. Набор не изменяется после загрузки.
+func (b *Bot) Commands() map[string]*Command {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.commands
+}
+
+// Config возвращает текущую конфигурацию
+func (b *Bot) Config() *Config {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.config
 }
 
 func main() {
@@ -69,129 +330,530 @@ func main() {
 	// Настройка логгирования
 	setupLogging()
 
-	botUsername := getEnv("TWITCH_BOT_USERNAME", "")
-	oauthToken := getEnv("TWITCH_OAUTH_TOKEN", "")
-	channel := getEnv("TWITCH_CHANNEL", "")
+	// Секреты из SOPS и Vault, чтобы токены не лежали в .env
+	if err := loadExternalSecrets(); err != nil {
+		slog.Error("Ошибка загрузки секретов", "error", err)
+		return
+	}
 
-	// Параметр: отвечать только на упоминания
-	mentionOnly := strings.ToLower(getEnv("MENTION_ONLY", "false")) == "true"
+	// Подкоманды: import, export, setup, replay
+	if handled, code := runCLI(os.Args[1:]); handled {
+		os.Exit(code)
+	}
+
+	botUsername := getEnv("TWITCH_BOT_USERNAME", "")
+	oauthToken := getSecret("TWITCH_OAUTH_TOKEN")
+	// Один или несколько каналов через запятую
+	var channels []string
+	for _, channel := range getEnvList("TWITCH_CHANNEL") {
+		channels = append(channels, normalizeChannel(channel))
+	}
 
 	// Параметр cooldown в секундах (по умолчанию 15 секунд)
 	cooldownSeconds := getEnvInt("COOLDOWN_SECONDS", 15)
 
-	if botUsername == "" || oauthToken == "" || channel == "" {
-		slog.Error("Не все обязательные переменные окружения заданы")
+	// Загрузка конфигурации
+	configFile := getEnv("CONFIG_FILE", "config.yaml")
+	config, err := loadConfig(configFile)
+	if err != nil {
+		slog.Error("Ошибка загрузки конфигурации", "error", err)
 		return
 	}
 
-	// Загрузка команд из файла
-	commands, err := loadCommands("commands.yaml")
+	// Учетные записи бота: из config.yaml или одна из переменных окружения
+	accounts := config.Accounts
+	if len(accounts) == 0 {
+		if botUsername == "" || oauthToken == "" || len(channels) == 0 {
+			slog.Error("Не все обязательные переменные окружения заданы")
+			return
+		}
+		account := Account{Username: botUsername, Token: oauthToken}
+		// Токен из файла перечитывается при изменении
+		if os.Getenv("TWITCH_OAUTH_TOKEN") == "" {
+			account.Token, account.TokenFile = "", os.Getenv("TWITCH_OAUTH_TOKEN_FILE")
+		}
+		accounts = []Account{account}
+	}
+
+	// Уровень лимитов отправки по умолчанию
+	rateTier, err := parseRateTier(getEnv("RATE_LIMIT_TIER", "normal"))
+	if err != nil {
+		slog.Error("Ошибка настройки лимитов", "error", err)
+		return
+	}
+
+	pool, err := NewConnectionPool(accounts, channels, PoolOptions{
+		DefaultTier:      rateTier,
+		DefaultTransport: getEnv("CHAT_TRANSPORT", TransportIRC),
+		ClientID:         getEnv("TWITCH_CLIENT_ID", ""),
+	})
+	if err != nil {
+		slog.Error("Ошибка настройки учетных записей", "error", err)
+		return
+	}
+
+	// База данных: статистика, переменные и команды, добавленные во время работы
+	storage, err := storageFromEnv(false)
+	if err != nil {
+		slog.Error("Ошибка открытия базы данных", "error", err)
+		return
+	}
+	var usage UsageStorage
+	var store CommandStorage
+	var storedCooldown SharedCooldown
+	if storage != nil {
+		defer storage.Close()
+
+		if usage, err = storage.Usage(); err != nil {
+			slog.Error("Ошибка открытия базы статистики", "error", err)
+			return
+		}
+		if store, err = storage.Commands(); err != nil {
+			slog.Error("Ошибка открытия хранилища команд", "error", err)
+			return
+		}
+		if storedCooldown, err = storage.Cooldown(); err != nil {
+			slog.Error("Ошибка открытия хранилища cooldown", "error", err)
+			return
+		}
+	}
+
+	// Общее состояние нескольких экземпляров бота
+	var redisState *RedisState
+	if url := getEnv("REDIS_URL", ""); url != "" {
+		if redisState, err = NewRedisState(url, getEnv("REDIS_PREFIX", "pastebot:")); err != nil {
+			slog.Error("Ошибка подключения к Redis", "error", err)
+			return
+		}
+		defer redisState.Close()
+		slog.Info("Общее состояние хранится в Redis")
+	}
+
+	var variables Variables
+	switch {
+	case redisState != nil:
+		variables = redisState.Variables()
+	case storage != nil:
+		variables, err = storage.Variables()
+	default:
+		variables, err = NewVariableStore(nil)
+	}
+	if err != nil {
+		slog.Error("Ошибка открытия хранилища переменных", "error", err)
+		return
+	}
+
+	// Загрузка команд из файла и базы
+	commandsFile := getEnv("COMMANDS_FILE", "commands.yaml")
+	commands, err := loadEffectiveCommands(commandsFile, store)
 	if err != nil {
 		slog.Error("Ошибка загрузки команд", "error", err)
 		return
 	}
 
-	// Добавляем команду для вывода всех зарегистрированных команд
-	commands["!пасты"] = getAllCommandsText(commands)
+	// Трейсинг OpenTelemetry
+	if strings.ToLower(getEnv("TRACING_ENABLED", "false")) == "true" {
+		shutdown, err := setupTracing(context.Background())
+		if err != nil {
+			slog.Error("Ошибка настройки трейсинга", "error", err)
+			return
+		}
+		defer shutdown(context.Background())
+	}
 
-	// Создание менеджера глобального cooldown
-	cooldownManager := NewGlobalCooldownManager(time.Duration(cooldownSeconds) * time.Second)
+	// Журнал аудита изменений конфигурации и команд
+	var audit *AuditLog
+	if auditFile := getEnvOptional("AUDIT_LOG_FILE", "audit.log"); auditFile != "" {
+		audit, err = NewAuditLog(auditFile)
+		if err != nil {
+			slog.Error("Ошибка открытия журнала аудита", "error", err)
+			return
+		}
+		defer audit.Close()
+	}
+	audit.Record("system", AuditReload, commandsFile, fmt.Sprintf("загружено команд: %d", len(commands)))
 
 	// Создание бота
-	bot := &Bot{
-		commands:    commands,
-		cooldown:    cooldownManager,
-		botUsername: botUsername,
-		channel:     channel,
-		mentionOnly: mentionOnly,
+	bot, err := newBot(botBackends{
+		pool:           pool,
+		helix:          pool.connections[0].helix,
+		config:         config,
+		configFile:     configFile,
+		commands:       commands,
+		commandsFile:   commandsFile,
+		store:          store,
+		usage:          usage,
+		variables:      variables,
+		audit:          audit,
+		redis:          redisState,
+		storedCooldown: storedCooldown,
+		cooldown:       time.Duration(cooldownSeconds) * time.Second,
+	})
+	if err != nil {
+		slog.Error("Ошибка настройки бота", "error", err)
+		return
 	}
 
-	// Создание клиента
-	client := twitch.NewClient(botUsername, oauthToken)
-	bot.client = client
+	// Admin API
+	if adminAddr := getEnv("ADMIN_ADDR", ""); adminAddr != "" {
+		adminToken := getSecret("ADMIN_TOKEN")
+		if adminToken == "" {
+			slog.Error("ADMIN_ADDR задан, но ADMIN_TOKEN пуст")
+			return
+		}
+		NewAdminServer(adminAddr, adminToken, bot).Start()
+	}
 
-	// Обработчик сообщений
-	client.OnPrivateMessage(func(message twitch.PrivateMessage) {
-		bot.handleMessage(message)
-	})
+	// Страница команд для зрителей, отдельно от Admin API
+	if publicAddr := getEnv("PUBLIC_ADDR", ""); publicAddr != "" {
+		NewPublicServer(publicAddr, bot).Start()
+	}
 
 	slog.Info("Бот запущен",
-		"channel", channel,
-		"bot_username", botUsername,
-		"mention_only", mentionOnly,
+		"version", buildInfo().String(),
+		"channels", pool.Channels(),
+		"bot_usernames", pool.Usernames(),
+		"mention_only", bot.mentionOnly,
+		"soft_launch", bot.softLaunch.Enabled(),
 		"cooldown_seconds", cooldownSeconds)
 
-	// Подключение к каналу
-	client.Join(channel)
+	// Автоматические резервные копии команд
+	if hours := getEnvInt("BACKUP_INTERVAL_HOURS", 24); hours > 0 {
+		go bot.runPeriodicBackups(time.Duration(hours) * time.Hour)
+	}
+
+	// Пасты по расписанию; Helix общий с первой учетной записью (nil без TWITCH_CLIENT_ID)
+	scheduleHelix := pool.connections[0].helix
+	go bot.runScheduledPastes(scheduleHelix)
 
-	// Запуск клиента
-	err = client.Connect()
-	if err != nil {
-		slog.Error("Ошибка подключения", "error", err)
+	// Отслеживание стримов и сводка по окончании
+	if scheduleHelix != nil && strings.ToLower(getEnv("STREAM_SESSIONS", "false")) == "true" {
+		bot.sessions = NewSessionTracker(scheduleHelix, pool.Channels())
+		bot.sessions.OnStart(func(channel string) { bot.streamUses.Reset(channel, "") })
+		switch mode := strings.ToLower(getEnv("STREAM_SUMMARY", "log")); mode {
+		case "chat":
+			bot.sessions.OnEnd(bot.postSessionSummary)
+		case "log":
+		default:
+			slog.Error("Неизвестный STREAM_SUMMARY (log, chat)", "stream_summary", mode)
+			return
+		}
+		go bot.sessions.Run(time.Duration(getEnvInt("STREAM_POLL_SECONDS", 120)) * time.Second)
+	}
+
+	if bot.emotes != nil {
+		go bot.runEmoteRefresh(time.Duration(getEnvInt("EMOTE_REFRESH_MINUTES", 60))*time.Minute, bot.checkEmoteRefs)
+	}
+
+	// Оповещения владельца о сбоях
+	alerts.Configure(alertSinksFromEnv(pool), time.Duration(getEnvInt("ALERT_INTERVAL_MINUTES", 30))*time.Minute)
+
+	// Токены из файлов подхватываются без перезапуска
+	go pool.WatchTokenFiles(time.Duration(getEnvInt("TOKEN_FILE_CHECK_SECONDS", 30))*time.Second, func(conn *Connection) {
+		bot.audit.Record("system", AuditTokenRefresh, conn.username, "файл токена")
+	})
+
+	// Запись входящих сообщений для отладки через replay
+	if dir := getEnv("CHAT_RECORD_DIR", ""); dir != "" {
+		if bot.recorder, err = NewChatRecorder(dir); err != nil {
+			slog.Error("Ошибка настройки записи чата", "error", err)
+			return
+		}
+		defer bot.recorder.Close()
 	}
+
+	// Перезагрузка конфигурации по SIGHUP
+	go bot.watchReloadSignal()
+
+	// Уведомления systemd о готовности и watchdog
+	go runSystemdNotifier(pool)
+
+	// Запуск подключений; супервизор перезапускает упавшие
+	pool.Run(ConnectionHandlers{
+		// Обработчик сообщений
+		OnMessage: bot.handleMessage,
+		SetupIRC: func(conn *Connection, client *twitch.Client) {
+			client.OnUserNoticeMessage(bot.handleRaid)
+		},
+		OnConnect: func(conn *Connection) {
+			slog.Info("Подключено",
+				"bot_username", conn.username,
+				"transport", conn.transport,
+				"channels", conn.channels)
+		},
+	})
 }
 
-func (b *Bot) handleMessage(message twitch.PrivateMessage) {
-	// Проверяем глобальный cooldown
-	if !b.cooldown.CanUse() {
-		slog.Debug("Бот в cooldown")
-		return
+// botBackends - то, чем бот в работе отличается от прогона записи (replay):
+// подключения, хранилища и журналы
+type botBackends struct {
+	pool  *ConnectionPool
+	helix *HelixClient // для проверки фолловинга и смайлов; nil без TWITCH_CLIENT_ID
+
+	config       *Config
+	configFile   string
+	commands     map[string]*Command
+	commandsFile string
+
+	store          CommandStorage // nil - команды из чата не сохраняются
+	usage          UsageStorage
+	variables      Variables
+	audit          *AuditLog
+	redis          *RedisState
+	storedCooldown SharedCooldown
+
+	cooldown   time.Duration
+	noCooldown bool // cooldown выключен, в том числе адаптивный
+}
+
+// newBot собирает бота по настройкам из окружения поверх переданных подключений и хранилищ
+func newBot(backends botBackends) (*Bot, error) {
+	// Менеджер cooldown (свой у каждого канала)
+	duration := backends.cooldown
+	if backends.noCooldown {
+		duration = 0
+	}
+	cooldownManager := NewGlobalCooldownManager(duration)
+	if backends.redis != nil {
+		cooldownManager.shared = backends.redis
+	} else if backends.storedCooldown != nil {
+		// Без Redis cooldown хранится в базе и переживает перезапуск
+		cooldownManager.Restore(backends.storedCooldown)
 	}
 
-	// Проверяем, нужно ли отвечать только на упоминания
-	if b.mentionOnly {
-		// Режим "только упоминания" - отвечаем только если есть @botname
-		if strings.Contains(message.Message, "@"+b.botUsername) {
-			b.processCommand(message)
+	// Адаптивный cooldown по активности чата
+	var activity *ChatActivity
+	switch mode := strings.ToLower(getEnv("COOLDOWN_MODE", "fixed")); mode {
+	case "fixed":
+	case "adaptive":
+		if !backends.noCooldown {
+			activity = &ChatActivity{}
+			cooldownManager.adaptive = &AdaptiveCooldown{
+				activity:      activity,
+				referenceRate: max(getEnvInt("COOLDOWN_REFERENCE_RATE", 30), 1),
+				min:           time.Duration(getEnvInt("COOLDOWN_MIN_SECONDS", 5)) * time.Second,
+				max:           time.Duration(getEnvInt("COOLDOWN_MAX_SECONDS", 60)) * time.Second,
+			}
 		}
-	} else {
-		// Режим "все команды" - отвечаем на упоминания и прямые команды
-		botMentioned := strings.Contains(message.Message, "@"+b.botUsername)
-		directCommand := strings.HasPrefix(strings.TrimSpace(message.Message), "!")
+	default:
+		return nil, fmt.Errorf("неизвестный COOLDOWN_MODE %q (fixed, adaptive)", mode)
+	}
+
+	pool := backends.pool
+	bot := &Bot{
+		started:     time.Now(),
+		pool:        pool,
+		cooldown:    cooldownManager,
+		mentionOnly: strings.ToLower(getEnv("MENTION_ONLY", "false")) == "true",
+		audit:       backends.audit,
+		usage:       backends.usage,
+		store:       backends.store,
+		variables:   backends.variables,
+		activity:    activity,
+		pause:       &PauseState{},
+		away:        NewAwayState(getEnv("AWAY_MESSAGE", "@{user}, стример сейчас отдыхает, бот вернется вместе с ним")),
+		awayReplies: NewNoticeLimiter(awayReplyInterval),
+		mentions:    NewMentionMatcher(pool.Usernames(), getEnvList("BOT_ALIASES")),
+		streamUses:  NewStreamUses(),
+		recent:      NewRecentCommands(),
+		softLaunch: NewSoftLaunch(
+			strings.ToLower(getEnv("SOFT_LAUNCH", "false")) == "true",
+			getEnvList("SOFT_LAUNCH_USERS"),
+			strings.ToLower(getEnv("SOFT_LAUNCH_MODS", "true")) == "true",
+		),
+
+		commands:     backends.commands,
+		config:       backends.config,
+		commandsFile: backends.commandsFile,
+		configFile:   backends.configFile,
+
+		backupDir:  getEnv("BACKUP_DIR", "backups"),
+		backupKeep: getEnvInt("BACKUP_KEEP", 10),
+
+		importHosts:     defaultImportHosts,
+		commandsPageURL: getEnv("COMMANDS_PAGE_URL", ""),
+
+		delay: ResponseDelay{
+			Base:   time.Duration(getEnvInt("RESPONSE_DELAY_MS", 0)) * time.Millisecond,
+			Jitter: time.Duration(getEnvInt("RESPONSE_JITTER_MS", 0)) * time.Millisecond,
+		},
+		respondAs: strings.ToLower(getEnv("RESPOND_AS", DeliverReply)),
+	}
+
+	bot.deniedReply = strings.ToLower(getEnv("DENIED_REPLY", "true")) == "true"
+	if seconds := getEnvInt("DENIED_COOLDOWN_SECONDS", 30); seconds > 0 {
+		bot.deniedLimiter = NewNoticeLimiter(time.Duration(seconds) * time.Second)
+	}
+
+	if !deliveryModes[bot.respondAs] {
+		return nil, fmt.Errorf("неизвестный RESPOND_AS %q (say, mention, reply, whisper, announce)", bot.respondAs)
+	}
 
-		if botMentioned || directCommand {
-			b.processCommand(message)
+	knownBots := getEnvList("KNOWN_BOTS")
+	if len(knownBots) == 0 {
+		knownBots = defaultKnownBots
+	}
+	bot.senders = NewSenderFilter(pool, strings.ToLower(getEnv("ALLOW_SELF_COMMANDS", "false")) == "true", knownBots)
+	if limit := getEnvInt("MAX_RESPONSES_PER_MINUTE", 60); limit > 0 {
+		bot.breaker = NewResponseBreaker(limit)
+	}
+
+	if attempts := getEnvInt("FLOOD_MAX_ATTEMPTS", 10); attempts > 0 {
+		bot.flood = NewFloodGuard(attempts, time.Duration(getEnvInt("FLOOD_IGNORE_MINUTES", 5))*time.Minute)
+	}
+
+	if window := getEnvInt("DUPLICATE_WINDOW_SECONDS", 0); window > 0 {
+		duplicates, err := NewTriggerDeduper(time.Duration(window)*time.Second, getEnv("DUPLICATE_MODE", DuplicateSuppress))
+		if err != nil {
+			return nil, fmt.Errorf("ошибка настройки подавления повторов: %w", err)
+		}
+		if backends.redis != nil {
+			duplicates.shared = backends.redis
+		}
+		bot.duplicates = duplicates
+	}
+
+	if hosts := getEnvList("IMPORT_ALLOWED_HOSTS"); len(hosts) > 0 {
+		bot.importHosts = hosts
+	}
+
+	bot.registerBuiltins()
+	if strings.ToLower(getEnv("GAMES_ENABLED", "false")) == "true" {
+		bot.duels = NewDuelGame(time.Duration(getEnvInt("DUEL_TIMEOUT_SECONDS", 60)) * time.Second)
+		bot.registerGames()
+	}
+	if getEnv("TWITCH_CLIENT_ID", "") != "" {
+		bot.registerHelixCommands()
+	}
+
+	switch matching := strings.ToLower(getEnv("COMMAND_MATCHING", MatchStrict)); matching {
+	case MatchStrict:
+	case MatchLenient:
+		bot.lenientMatching = true
+	default:
+		return nil, fmt.Errorf("неизвестный COMMAND_MATCHING %q (strict, lenient)", matching)
+	}
+
+	// Синонимы строятся после регистрации встроенных команд, чтобы учесть и их
+	if strings.ToLower(getEnv("TRANSLIT_ALIASES", "false")) == "true" {
+		bot.transliterate = true
+		bot.setCommandsLocked(backends.commands)
+	}
+
+	bot.baseTemplates = defaultTemplates
+	if deniedMessage := getEnv("DENIED_MESSAGE", ""); deniedMessage != "" {
+		bot.baseTemplates.PermissionDenied = deniedMessage
+	}
+
+	// Проверка фолловинга через Helix
+	if backends.helix != nil {
+		ttl := time.Duration(getEnvInt("FOLLOWER_CACHE_MINUTES", 10)) * time.Minute
+		bot.followers = NewFollowerCache(backends.helix, ttl)
+
+		// Смайлы 7TV, BTTV и FFZ для {random_emote}
+		bot.emotes = NewEmoteCache(backends.helix, pool.Channels())
+		bot.checkEmoteRefs = strings.ToLower(getEnv("EMOTE_CHECK", "false")) == "true"
+	}
+
+	return bot, nil
+}
+
+// isKnownCommand сообщает, есть ли команда среди встроенных или загруженных
+func (b *Bot) isKnownCommand(name string) bool {
+	if b.isBuiltin(name) {
+		return true
+	}
+	if _, exists := b.Commands()[name]; exists {
+		return true
+	}
+	_, alias := b.resolveAlias(name)
+	return alias
+}
+
+// findCommand возвращает индекс первого слова, совпадающего с известной командой, или -1
+func (b *Bot) findCommand(words []string) int {
+	for i, word := range words {
+		if _, ok := b.matchToken(word); ok {
+			return i
 		}
 	}
+	return -1
+}
+
+// respond отправляет ответ на сообщение
+func (b *Bot) respond(ctx context.Context, message twitch.PrivateMessage, response string) {
+	b.deliver(ctx, message, b.renderPrefix(message.Channel, response), DeliverReply)
+}
+
+// deliver отправляет ответ на сообщение указанным способом
+func (b *Bot) deliver(ctx context.Context, message twitch.PrivateMessage, response, mode string) {
+	b.deliverSequence(ctx, message, []string{response}, mode, 0)
 }
 
-func (b *Bot) processCommand(message twitch.PrivateMessage) {
-	// Удаление упоминания бота из сообщения для извлечения команды
-	cleanMessage := strings.TrimSpace(strings.Replace(message.Message, "@"+b.botUsername, "", 1))
+// deliverSequence отправляет несколько сообщений подряд с паузой gap между ними.
+// Ответы на другие сообщения не вклиниваются в последовательность.
+func (b *Bot) deliverSequence(ctx context.Context, message twitch.PrivateMessage, responses []string, mode string, gap time.Duration) {
+	conn := b.pool.For(message.Channel)
+	if conn == nil {
+		slog.Warn("Нет подключения для канала", "channel", message.Channel)
+		return
+	}
 
-	// Извлечение команды
-	commandParts := strings.Fields(cleanMessage)
-	if len(commandParts) == 0 {
+	if !b.breaker.Allow(message.Channel) {
+		slog.Warn("Ответ отброшен предохранителем", "channel", message.Channel, "user", message.User.Name)
 		return
 	}
 
-	cmd := commandParts[0]
+	// Команды со своей учетной записи (ALLOW_SELF_COMMANDS) - без ответа на самого себя
+	if b.pool.IsOwnAccount(message.User.Name) && (mode == DeliverReply || mode == DeliverMention || mode == DeliverWhisper) {
+		mode = DeliverSay
+	}
 
-	// Поиск команды в конфигурации
-	if response, exists := b.commands[cmd]; exists {
-		// Устанавливаем глобальный cooldown перед отправкой ответа
-		b.cooldown.Use()
+	item := outgoing{mode: mode, channel: message.Channel}
+	switch mode {
+	case DeliverSay:
+	case DeliverMention:
+		item.mode = DeliverSay
+		responses = append([]string{"@" + message.User.Name + " " + responses[0]}, responses[1:]...)
+	case DeliverWhisper:
+		item.parentID, item.userID = message.ID, message.User.ID
+	case DeliverAnnounce:
+		item.roomID = message.RoomID
+	default:
+		item.mode, item.parentID = DeliverReply, message.ID
+	}
+	conn.Send(ctx, item, responses, gap)
+}
 
-		if message.User.Name == b.botUsername {
-			b.client.Say(b.channel, response)
-			time.Sleep(1 * time.Second)
-		} else {
-			b.client.Reply(b.channel, message.ID, response)
-		}
+// statsText формирует ответ для !статистика <команда>
+func (b *Bot) statsText(args []string) string {
+	if len(args) == 0 {
+		return "Использование: !статистика <команда>"
+	}
 
-		slog.Info("Команда выполнена",
-			"user", message.User.Name,
-			"command", cmd,
-			"response", response)
-	} else {
-		slog.Debug("Неизвестная команда", "command", cmd, "user", message.User.Name)
-		// Отправляем сообщение о неизвестной команде (без cooldown для этого сообщения)
-		if strings.ToLower(getEnv("MENTION_ONLY", "false")) == "true" {
-			b.client.Reply(b.channel, message.ID, fmt.Sprintf("@%s Неизвестная команда. Используйте !пасты для списка команд.", message.User.Name))
-		}
+	cmd := args[0]
+	if !strings.HasPrefix(cmd, "!") {
+		cmd = "!" + cmd
+	}
+
+	total, err := b.usage.Count(UsageFilter{Command: cmd})
+	if err != nil {
+		slog.Error("Ошибка получения статистики", "error", err, "command", cmd)
+		return "Не удалось получить статистику"
+	}
+
+	today, err := b.usage.Count(UsageFilter{Command: cmd, From: time.Now().Add(-24 * time.Hour)})
+	if err != nil {
+		slog.Error("Ошибка получения статистики", "error", err, "command", cmd)
+		return "Не удалось получить статистику"
 	}
+
+	return fmt.Sprintf("%s: всего вызовов %d, за последние сутки %d", cmd, total, today)
 }
 
+// Открытый файл логов; закрывается при перенастройке логирования
+var logFileHandle *os.File
+
 func setupLogging() {
 	logLevel := getEnv("LOG_LEVEL", "INFO")
 	logFile := getEnv("LOG_FILE", "")
@@ -210,6 +872,9 @@ func setupLogging() {
 		level = slog.LevelInfo
 	}
 
+	previous := logFileHandle
+	logFileHandle = nil
+
 	var handler slog.Handler
 	if logFile != "" {
 		// Логирование в файл
@@ -220,6 +885,7 @@ func setupLogging() {
 			handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level})
 		} else {
 			handler = slog.NewTextHandler(file, &slog.HandlerOptions{Level: level})
+			logFileHandle = file
 		}
 	} else {
 		// Логирование в stdout
@@ -228,6 +894,11 @@ func setupLogging() {
 
 	logger := slog.New(handler)
 	slog.SetDefault(logger)
+
+	// Старый файл логов закрываем после переключения логгера
+	if previous != nil {
+		previous.Close()
+	}
 }
 
 func getEnv(key, defaultValue string) string {
@@ -237,6 +908,15 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvOptional возвращает defaultValue, только если переменная не задана:
+// явно пустое значение (KEY=) отключает соответствующую функцию
+func getEnvOptional(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return strings.TrimSpace(value)
+	}
+	return defaultValue
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	valueStr := getEnv(key, "")
 	if valueStr == "" {
@@ -252,7 +932,18 @@ func getEnvInt(key string, defaultValue int) int {
 	return result
 }
 
-func loadCommands(filename string) (map[string]string, error) {
+// getEnvList разбирает список значений через запятую
+func getEnvList(key string) []string {
+	var result []string
+	for _, item := range strings.Split(getEnv(key, ""), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func loadCommands(filename string) (map[string]*Command, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка чтения файла %s: %w", filename, err)
@@ -263,9 +954,13 @@ func loadCommands(filename string) (map[string]string, error) {
 		return nil, fmt.Errorf("ошибка парсинга YAML: %w", err)
 	}
 
-	commands := make(map[string]string)
-	for _, cmd := range config.Messages {
-		commands[cmd.Command] = cmd.Text
+	commands := make(map[string]*Command)
+	for i := range config.Messages {
+		cmd := &config.Messages[i]
+		if err := prepareCommand(cmd); err != nil {
+			return nil, err
+		}
+		commands[cmd.Command] = cmd
 	}
 
 	slog.Info("Команды загружены", "count", len(commands))
@@ -276,11 +971,95 @@ func loadCommands(filename string) (map[string]string, error) {
 	return commands, nil
 }
 
-func getAllCommandsText(commands map[string]string) string {
-	var commandList []string
-	for cmd := range commands {
-		commandList = append(commandList, cmd)
+// prepareCommand проверяет команду и разбирает ее расписание
+func prepareCommand(cmd *Command) error {
+	if !strings.HasPrefix(cmd.Command, "!") || strings.ContainsAny(cmd.Command, " \t") {
+		return fmt.Errorf("неверное имя команды %q: должно начинаться с ! и не содержать пробелов", cmd.Command)
 	}
-	sort.Strings(commandList)
-	return "Доступные команды: " + strings.Join(commandList, ", ")
+	switch cmd.Type {
+	case "":
+		if strings.TrimSpace(cmd.Text) == "" {
+			return fmt.Errorf("у команды %s пустой текст", cmd.Command)
+		}
+		for _, part := range cmd.Parts {
+			if strings.TrimSpace(part) == "" {
+				return fmt.Errorf("у команды %s пустое сообщение в последовательности", cmd.Command)
+			}
+		}
+		if cmd.SequenceDelayMs != nil && *cmd.SequenceDelayMs < 0 {
+			return fmt.Errorf("у команды %s отрицательный sequence_delay_ms", cmd.Command)
+		}
+	case CommandTypeModeration:
+		if err := prepareModeration(cmd); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("у команды %s неизвестный тип %q", cmd.Command, cmd.Type)
+	}
+
+	schedule, err := parseSchedule(cmd.OnlyBetween, cmd.Days, cmd.Timezone)
+	if err != nil {
+		return fmt.Errorf("ошибка расписания команды %s: %w", cmd.Command, err)
+	}
+	cmd.schedule = schedule
+
+	cmd.RespondAs = strings.ToLower(cmd.RespondAs)
+	if cmd.RespondAs != "" && !deliveryModes[cmd.RespondAs] {
+		return fmt.Errorf("у команды %s неизвестный respond_as %q (say, mention, reply, whisper, announce)", cmd.Command, cmd.RespondAs)
+	}
+
+	if cmd.MaxUsesPerStream < 0 {
+		return fmt.Errorf("у команды %s отрицательный max_uses_per_stream", cmd.Command)
+	}
+
+	if (cmd.DelayMs != nil && *cmd.DelayMs < 0) || (cmd.JitterMs != nil && *cmd.JitterMs < 0) {
+		return fmt.Errorf("у команды %s отрицательная задержка", cmd.Command)
+	}
+
+	if err := validateRequirement(cmd.Requires); err != nil {
+		return fmt.Errorf("ошибка команды %s: %w", cmd.Command, err)
+	}
+	return nil
+}
+
+// loadEffectiveCommands загружает команды из файла и накладывает поверх них
+// команды, добавленные во время работы, и переключатели включения
+func loadEffectiveCommands(filename string, store CommandStorage) (map[string]*Command, error) {
+	commands, err := loadCommands(filename)
+	if err != nil {
+		return nil, err
+	}
+	if store == nil {
+		return commands, nil
+	}
+
+	stored, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range stored {
+		cmd := &stored[i]
+		if err := prepareCommand(cmd); err != nil {
+			slog.Warn("Пропущена команда из базы", "error", err)
+			continue
+		}
+		commands[cmd.Command] = cmd
+	}
+
+	if len(stored) > 0 {
+		slog.Info("Команды из базы загружены", "count", len(stored))
+	}
+
+	// Переключатели !bot enable/disable меняют только Disabled, поэтому правки
+	// команды в commands.yaml продолжают действовать
+	flags, err := store.DisabledFlags()
+	if err != nil {
+		return nil, err
+	}
+	for name, disabled := range flags {
+		if cmd, ok := commands[name]; ok {
+			cmd.Disabled = disabled
+		}
+	}
+	return commands, nil
 }
@@ -0,0 +1,62 @@
+// matching.go
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Режимы распознавания команд
+const (
+	// Только точное совпадение слова с командой
+	MatchStrict = "strict"
+	// "!паста3)" и "!паста3 Kappa" тоже считаются вызовом !паста3
+	MatchLenient = "lenient"
+)
+
+// trimCommandToken убирает знаки препинания и символы в конце слова: "!паста3)))" -> "!паста3"
+func trimCommandToken(word string) string {
+	return strings.TrimRightFunc(word, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	})
+}
+
+// matchToken возвращает команду, которой соответствует слово. В мягком режиме
+// точное совпадение проверяется первым, поэтому команды вроде "!?" не ломаются.
+func (b *Bot) matchToken(word string) (string, bool) {
+	if b.isKnownCommand(word) {
+		return word, true
+	}
+	if !b.lenientMatching {
+		return "", false
+	}
+	trimmed := trimCommandToken(word)
+	if trimmed == word || trimmed == "" {
+		return "", false
+	}
+	return trimmed, b.isKnownCommand(trimmed)
+}
+
+// stripEmoteArgs убирает из аргументов смайлы Twitch и сторонних сервисов,
+// чтобы "!паста3 Kappa" не адресовала пасту пользователю Kappa
+func (b *Bot) stripEmoteArgs(message twitch.PrivateMessage, args []string) []string {
+	if !b.lenientMatching || len(args) == 0 {
+		return args
+	}
+
+	emotes := make(map[string]bool, len(message.Emotes))
+	for _, emote := range message.Emotes {
+		emotes[emote.Name] = true
+	}
+
+	kept := args[:0:0]
+	for _, arg := range args {
+		if emotes[arg] || b.emotes.IsEmote(message.Channel, arg) {
+			continue
+		}
+		kept = append(kept, arg)
+	}
+	return kept
+}
@@ -0,0 +1,90 @@
+// mention.go
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Поиск упоминаний бота в сообщении: без учета регистра, по границам слов,
+// с "@" или без, по имени бота и настроенным псевдонимам
+type MentionMatcher struct {
+	names [][]rune
+}
+
+func NewMentionMatcher(usernames []string, aliases []string) *MentionMatcher {
+	m := &MentionMatcher{}
+	for _, name := range append(usernames, aliases...) {
+		name = strings.TrimPrefix(strings.TrimSpace(name), "@")
+		if name != "" {
+			m.names = append(m.names, []rune(strings.ToLower(name)))
+		}
+	}
+	return m
+}
+
+// Strip ищет первое упоминание бота и возвращает сообщение без него.
+// Знаки препинания сразу после упоминания ("@bot, ...") удаляются вместе с ним.
+func (m *MentionMatcher) Strip(text string) (string, bool) {
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+		if i > 0 && (isWordRune(runes[i-1]) || runes[i-1] == '@') {
+			continue
+		}
+
+		start := i
+		pos := i
+		if runes[pos] == '@' {
+			pos++
+		}
+
+		for _, name := range m.names {
+			end, ok := matchFold(runes, pos, name)
+			if !ok || (end < len(runes) && isWordRune(runes[end])) {
+				continue
+			}
+
+			for end < len(runes) && strings.ContainsRune(",:;.", runes[end]) {
+				end++
+			}
+
+			clean := strings.TrimSpace(string(runes[:start])) + " " + strings.TrimSpace(string(runes[end:]))
+			return strings.TrimSpace(clean), true
+		}
+	}
+
+	return strings.TrimSpace(text), false
+}
+
+// matchFold сравнивает runes начиная с pos с name без учета регистра
+func matchFold(runes []rune, pos int, name []rune) (int, bool) {
+	if pos+len(name) > len(runes) {
+		return 0, false
+	}
+	for j, r := range name {
+		if unicode.ToLower(runes[pos+j]) != r {
+			return 0, false
+		}
+	}
+	return pos + len(name), true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// stripReplyPrefix убирает "@автор " в начале ответа на сообщение: клиенты
+// Twitch подставляют его сами, и без этого "@user !команда" не было бы командой
+func stripReplyPrefix(text string, tags map[string]string) string {
+	parent := tags["reply-parent-user-login"]
+	if parent == "" || tags["reply-parent-msg-id"] == "" {
+		return text
+	}
+
+	word, rest, _ := strings.Cut(strings.TrimSpace(text), " ")
+	if !strings.EqualFold(strings.TrimPrefix(word, "@"), parent) || !strings.HasPrefix(word, "@") {
+		return text
+	}
+	return strings.TrimSpace(rest)
+}
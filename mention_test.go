@@ -0,0 +1,32 @@
+// mention_test.go
+package main
+
+import "testing"
+
+func TestMentionStrip(t *testing.T) {
+	m := NewMentionMatcher([]string{"PasteBot"}, []string{" @бот ", ""})
+	tests := []struct {
+		in        string
+		want      string
+		mentioned bool
+	}{
+		{"@pastebot !паста", "!паста", true},
+		{"PASTEBOT, !паста", "!паста", true},
+		{"!паста @PasteBot", "!паста", true},
+		{"эй бот: !паста", "эй !паста", true},
+		{"@Бот. привет", "привет", true},
+		{"pastebot2 !паста", "pastebot2 !паста", false},
+		{"mypastebot !паста", "mypastebot !паста", false},
+		{"роботы !паста", "роботы !паста", false},
+		{"email@pastebot !паста", "email@pastebot !паста", false},
+		{"  !паста  ", "!паста", false},
+		{"", "", false},
+		{"@pastebot", "", true},
+	}
+	for _, tt := range tests {
+		got, mentioned := m.Strip(tt.in)
+		if got != tt.want || mentioned != tt.mentioned {
+			t.Errorf("Strip(%q) = %q, %v; ожидалось %q, %v", tt.in, got, mentioned, tt.want, tt.mentioned)
+		}
+	}
+}
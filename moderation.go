@@ -0,0 +1,227 @@
+// moderation.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Тип команды, выполняющей действие модерации вместо отправки пасты
+const CommandTypeModeration = "moderation"
+
+// Действия модерационных команд
+const (
+	ModerationTimeout = "timeout"
+	ModerationWarn    = "warn"
+	ModerationDelete  = "delete"
+)
+
+const (
+	defaultModerationTimeout = 10 * time.Minute
+	// Максимальный таймаут, который принимает Twitch
+	maxModerationTimeout = 14 * 24 * time.Hour
+	// Предупреждение в Twitch не отправляется без причины
+	defaultModerationReason = "нарушение правил чата"
+)
+
+// IsModeration сообщает, что команда выполняет действие модерации
+func (c *Command) IsModeration() bool {
+	return c.Type == CommandTypeModeration
+}
+
+// prepareModeration проверяет настройки модерационной команды. Такие команды
+// всегда требуют как минимум прав модератора.
+func prepareModeration(cmd *Command) error {
+	cmd.Action = strings.ToLower(cmd.Action)
+	switch cmd.Action {
+	case ModerationTimeout, ModerationWarn, ModerationDelete:
+	default:
+		return fmt.Errorf("у команды %s неизвестное действие %q (timeout, warn, delete)", cmd.Command, cmd.Action)
+	}
+
+	switch cmd.Requires {
+	case "":
+		cmd.Requires = RequiresModerator
+	case RequiresModerator, RequiresBroadcaster:
+	default:
+		return fmt.Errorf("команда модерации %s не может быть доступна %s", cmd.Command, requirementNames[cmd.Requires])
+	}
+
+	if cmd.Duration != "" {
+		duration, err := time.ParseDuration(cmd.Duration)
+		if err != nil || duration <= 0 || duration > maxModerationTimeout {
+			return fmt.Errorf("у команды %s неверная длительность %q", cmd.Command, cmd.Duration)
+		}
+	}
+	return nil
+}
+
+// Цель и параметры действия модерации
+type moderationRequest struct {
+	TargetLogin string
+	TargetID    string
+	MessageID   string
+	Duration    time.Duration
+	Reason      string
+}
+
+// parseModerationArgs разбирает "@user [10m] [причина]". Без цели берется
+// автор сообщения, на которое ответил модератор.
+func parseModerationArgs(command *Command, tags map[string]string, args []string) (moderationRequest, error) {
+	req := moderationRequest{Duration: defaultModerationTimeout, Reason: command.Reason}
+	if command.Duration != "" {
+		req.Duration, _ = time.ParseDuration(command.Duration)
+	}
+
+	if len(args) > 0 && strings.HasPrefix(args[0], "@") {
+		req.TargetLogin = strings.ToLower(strings.TrimPrefix(args[0], "@"))
+		args = args[1:]
+	} else if parent := tags["reply-parent-user-login"]; parent != "" {
+		req.TargetLogin = strings.ToLower(parent)
+		req.TargetID = tags["reply-parent-user-id"]
+		req.MessageID = tags["reply-parent-msg-id"]
+	} else if len(args) > 0 {
+		req.TargetLogin = strings.ToLower(args[0])
+		args = args[1:]
+	}
+	if req.TargetLogin == "" {
+		return req, fmt.Errorf("укажите пользователя: %s @user [причина]", command.Command)
+	}
+	if command.Action == ModerationDelete && req.MessageID == "" {
+		return req, fmt.Errorf("ответьте командой %s на сообщение, которое нужно удалить", command.Command)
+	}
+
+	if command.Action == ModerationTimeout && len(args) > 0 {
+		if duration, err := time.ParseDuration(args[0]); err == nil && duration > 0 {
+			req.Duration = min(duration, maxModerationTimeout)
+			args = args[1:]
+		}
+	}
+
+	if reason := strings.Join(args, " "); reason != "" {
+		req.Reason = reason
+	}
+	if req.Reason == "" && command.Action == ModerationWarn {
+		req.Reason = defaultModerationReason
+	}
+	return req, nil
+}
+
+// moderate выполняет модерационную команду и сообщает о результате в чат
+func (b *Bot) moderate(ctx context.Context, mc *MessageContext) {
+	message, command := mc.Message, mc.Command
+
+	// Уровень доступа уже проверен, но действие над зрителем проверяем еще раз
+	// напрямую по значкам, а не по настройке команды
+	if !message.User.IsMod && !message.User.IsBroadcaster {
+		slog.Warn("Команда модерации вызвана без прав модератора", "command", command.Command, "user", message.User.Name)
+		return
+	}
+
+	req, err := parseModerationArgs(command, message.Tags, mc.Args)
+	if err != nil {
+		b.respond(ctx, message, fmt.Sprintf("@%s, %v", message.User.Name, err))
+		return
+	}
+	if err := b.checkModerationTarget(message.Channel, message.User.Name, req.TargetLogin); err != nil {
+		b.respond(ctx, message, fmt.Sprintf("@%s, %v", message.User.Name, err))
+		return
+	}
+
+	conn := b.pool.For(message.Channel)
+	if conn == nil {
+		slog.Warn("Нет подключения для канала", "channel", message.Channel)
+		return
+	}
+	if err := conn.Moderate(message.RoomID, command.Action, req); err != nil {
+		slog.Error("Ошибка действия модерации",
+			"error", err,
+			"command", command.Command,
+			"action", command.Action,
+			"moderator", message.User.Name,
+			"target", req.TargetLogin)
+		b.respond(ctx, message, fmt.Sprintf("@%s, не удалось выполнить %s для %s", message.User.Name, command.Action, req.TargetLogin))
+		return
+	}
+
+	details := command.Action
+	if command.Action == ModerationTimeout {
+		details += " " + req.Duration.String()
+	}
+	if req.Reason != "" {
+		details += ": " + req.Reason
+	}
+	b.audit.Record(message.User.Name, AuditModeration, req.TargetLogin, details)
+	slog.Info("Выполнено действие модерации",
+		"command", command.Command,
+		"action", command.Action,
+		"moderator", message.User.Name,
+		"target", req.TargetLogin,
+		"channel", message.Channel)
+
+	if strings.TrimSpace(command.Text) == "" {
+		return
+	}
+	b.respondDelayed(ctx, message, renderTemplate(command.Text, map[string]string{
+		"user":     message.User.Name,
+		"target":   req.TargetLogin,
+		"reason":   req.Reason,
+		"duration": req.Duration.String(),
+	}), command)
+}
+
+// checkModerationTarget не дает применить действие к себе, стримеру или боту
+func (b *Bot) checkModerationTarget(channel, moderator, target string) error {
+	if strings.EqualFold(target, moderator) {
+		return fmt.Errorf("нельзя применить команду к себе")
+	}
+	if strings.EqualFold(target, normalizeChannel(channel)) {
+		return fmt.Errorf("нельзя применить команду к стримеру")
+	}
+	for _, username := range b.pool.Usernames() {
+		if strings.EqualFold(target, username) {
+			return fmt.Errorf("нельзя применить команду к боту")
+		}
+	}
+	return nil
+}
+
+// Moderate выполняет действие модерации от имени учетной записи бота.
+// Бот должен быть модератором канала, а токен - иметь нужные права.
+func (c *Connection) Moderate(broadcasterID, action string, req moderationRequest) error {
+	if c.queue.dryRun != nil {
+		fmt.Fprintf(c.queue.dryRun, "  -> %s %s (%s)\n", action, req.TargetLogin, req.Reason)
+		return nil
+	}
+	if c.helix == nil {
+		return fmt.Errorf("не задан TWITCH_CLIENT_ID")
+	}
+	moderatorID, err := c.selfID()
+	if err != nil {
+		return err
+	}
+
+	if action == ModerationDelete {
+		return c.helix.DeleteChatMessage(broadcasterID, moderatorID, req.MessageID)
+	}
+
+	targetID := req.TargetID
+	if targetID == "" {
+		users, err := c.helix.GetUsers(req.TargetLogin)
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return fmt.Errorf("пользователь %s не найден", req.TargetLogin)
+		}
+		targetID = users[0].ID
+	}
+
+	if action == ModerationWarn {
+		return c.helix.WarnUser(broadcasterID, moderatorID, targetID, req.Reason)
+	}
+	return c.helix.TimeoutUser(broadcasterID, moderatorID, targetID, req.Duration, req.Reason)
+}
@@ -0,0 +1,152 @@
+// moderation_test.go
+package main
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Ответ модератора на сообщение спамера в том виде, в каком его присылает Twitch
+const replyLine = `@badge-info=;badges=moderator/1;color=#1E90FF;display-name=ModUser;emotes=;first-msg=0;flags=;id=5f2a9c1e-0d3b-4c8e-9a7f-2b6d1e4c8a90;mod=1;reply-parent-display-name=Spammer;reply-parent-msg-body=buy\sfollowers\scheap;reply-parent-msg-id=b34ccfc7-4977-403a-8a94-33c6bac34fb8;reply-parent-user-id=123456;reply-parent-user-login=spammer;reply-thread-parent-display-name=Spammer;reply-thread-parent-msg-id=b34ccfc7-4977-403a-8a94-33c6bac34fb8;reply-thread-parent-user-id=123456;reply-thread-parent-user-login=spammer;returning-chatter=0;room-id=999;subscriber=0;tmi-sent-ts=1700000000000;turbo=0;user-id=555;user-type=mod :moduser!moduser@moduser.tmi.twitch.tv PRIVMSG #channel :`
+
+func parseReply(t *testing.T, text string) twitch.PrivateMessage {
+	t.Helper()
+	message, ok := twitch.ParseMessage(replyLine + text).(*twitch.PrivateMessage)
+	if !ok {
+		t.Fatal("не удалось разобрать сообщение")
+	}
+	return *message
+}
+
+func TestModerationReplyDelete(t *testing.T) {
+	deleteCmd := &Command{Command: "!удалить", Type: CommandTypeModeration, Action: ModerationDelete}
+	timeoutCmd := &Command{Command: "!таймаут", Type: CommandTypeModeration, Action: ModerationTimeout}
+	b := &Bot{
+		config:   &Config{},
+		mentions: NewMentionMatcher([]string{"paste_bot"}, nil),
+		pool:     newDryRunPool([]string{"paste_bot"}, []string{"channel"}, io.Discard),
+		commands: map[string]*Command{deleteCmd.Command: deleteCmd, timeoutCmd.Command: timeoutCmd},
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		command  *Command
+		wantArgs int
+		want     moderationRequest
+	}{
+		{
+			name:    "удаление ответом",
+			text:    "@spammer !удалить",
+			command: deleteCmd,
+			want: moderationRequest{
+				TargetLogin: "spammer",
+				TargetID:    "123456",
+				MessageID:   "b34ccfc7-4977-403a-8a94-33c6bac34fb8",
+				Duration:    defaultModerationTimeout,
+			},
+		},
+		{
+			name:     "таймаут ответом с длительностью и причиной",
+			text:     "@Spammer !таймаут 10m реклама",
+			command:  timeoutCmd,
+			wantArgs: 2,
+			want: moderationRequest{
+				TargetLogin: "spammer",
+				TargetID:    "123456",
+				MessageID:   "b34ccfc7-4977-403a-8a94-33c6bac34fb8",
+				Duration:    10 * time.Minute,
+				Reason:      "реклама",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := &MessageContext{Ctx: context.Background(), Message: parseReply(t, tt.text)}
+			parseMiddleware(b, mc, func() {})
+			if !mc.Prefixed {
+				t.Fatalf("ответ %q не распознан как команда: %q", tt.text, mc.Clean)
+			}
+			matchMiddleware(b, mc, func() {})
+			if mc.Command != tt.command || len(mc.Args) != tt.wantArgs {
+				t.Fatalf("найдена команда %q с аргументами %q", mc.Name, mc.Args)
+			}
+
+			req, err := parseModerationArgs(mc.Command, mc.Message.Tags, mc.Args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req != tt.want {
+				t.Errorf("получено %+v, ожидалось %+v", req, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripReplyPrefix(t *testing.T) {
+	tags := map[string]string{"reply-parent-user-login": "spammer", "reply-parent-msg-id": "1"}
+
+	tests := []struct {
+		text string
+		tags map[string]string
+		want string
+	}{
+		{"@spammer !удалить", tags, "!удалить"},
+		{"@SPAMMER   !удалить", tags, "!удалить"},
+		{"@spammer", tags, ""},
+		{"spammer !удалить", tags, "spammer !удалить"},
+		{"@other !удалить", tags, "@other !удалить"},
+		{"@spammer !удалить", nil, "@spammer !удалить"},
+		{"@spammer !удалить", map[string]string{"reply-parent-user-login": "spammer"}, "@spammer !удалить"},
+	}
+
+	for _, tt := range tests {
+		if got := stripReplyPrefix(tt.text, tt.tags); got != tt.want {
+			t.Errorf("stripReplyPrefix(%q) = %q, ожидалось %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseModerationArgs(t *testing.T) {
+	timeoutCmd := &Command{Command: "!таймаут", Action: ModerationTimeout, Duration: "5m"}
+	warnCmd := &Command{Command: "!варн", Action: ModerationWarn}
+	deleteCmd := &Command{Command: "!удалить", Action: ModerationDelete}
+
+	tests := []struct {
+		name    string
+		command *Command
+		args    []string
+		want    moderationRequest
+		wantErr bool
+	}{
+		{"цель и длительность", timeoutCmd, []string{"@User", "1h", "флуд"}, moderationRequest{TargetLogin: "user", Duration: time.Hour, Reason: "флуд"}, false},
+		{"длительность команды", timeoutCmd, []string{"user"}, moderationRequest{TargetLogin: "user", Duration: 5 * time.Minute}, false},
+		{"длительность ограничена", timeoutCmd, []string{"@user", "9999h"}, moderationRequest{TargetLogin: "user", Duration: maxModerationTimeout}, false},
+		{"причина предупреждения по умолчанию", warnCmd, []string{"@user"}, moderationRequest{TargetLogin: "user", Duration: defaultModerationTimeout, Reason: defaultModerationReason}, false},
+		{"без цели", warnCmd, nil, moderationRequest{}, true},
+		{"удаление без ответа", deleteCmd, []string{"@user"}, moderationRequest{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseModerationArgs(tt.command, nil, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ожидалась ошибка, получено %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("получено %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// partials.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Наибольшая вложенность частей. Циклы вида a -> b -> a отклоняются при загрузке config.yaml.
+const maxPartialDepth = 5
+
+// Ссылка на общую часть шаблона: {{> rules_footer}}
+var partialRe = regexp.MustCompile(`\{\{>\s*([^{}\s]+)\s*\}\}`)
+
+// Partial возвращает часть шаблона: канал > config.yaml
+func (c *Config) Partial(channel, name string) (string, bool) {
+	if text, ok := c.Channels[normalizeChannel(channel)].Partials[name]; ok {
+		return text, true
+	}
+	text, ok := c.Partials[name]
+	return text, ok
+}
+
+// expandPartials подставляет в текст части шаблонов из config.yaml. Части
+// берутся из текущей конфигурации, поэтому после перезагрузки все пасты
+// сразу используют новый текст. Неизвестные ссылки остаются как есть.
+func (b *Bot) expandPartials(channel, text string) string {
+	config := b.Config()
+	expanded := true
+	for depth := 0; depth < maxPartialDepth && expanded; depth++ {
+		expanded = false
+		text = partialRe.ReplaceAllStringFunc(text, func(ref string) string {
+			partial, ok := config.Partial(channel, partialRe.FindStringSubmatch(ref)[1])
+			if !ok {
+				return ref
+			}
+			expanded = true
+			return partial
+		})
+	}
+	if !expanded {
+		return text
+	}
+
+	// Ссылки глубже предела не раскрываются, но и разметкой в чат не уходят
+	stripped := false
+	text = partialRe.ReplaceAllStringFunc(text, func(ref string) string {
+		if _, ok := config.Partial(channel, partialRe.FindStringSubmatch(ref)[1]); !ok {
+			return ref
+		}
+		stripped = true
+		return ""
+	})
+	if stripped {
+		slog.Warn("Части шаблонов вложены глубже предела, оставшиеся ссылки убраны",
+			"channel", channel, "max_depth", maxPartialDepth)
+	}
+	return text
+}
+
+// validatePartials проверяет, что части шаблонов раскрываются до конца:
+// без циклов и не глубже maxPartialDepth, в том числе с частями каналов
+func (c *Config) validatePartials() error {
+	scopes := []string{""}
+	for channel, settings := range c.Channels {
+		if len(settings.Partials) > 0 {
+			scopes = append(scopes, channel)
+		}
+	}
+	sort.Strings(scopes)
+
+	for _, channel := range scopes {
+		where := ""
+		if channel != "" {
+			where = " канала " + channel
+		}
+
+		depths := make(map[string]int)
+		var visit func(name string, path []string) (int, error)
+		visit = func(name string, path []string) (int, error) {
+			if depth, ok := depths[name]; ok {
+				return depth, nil
+			}
+			for i, seen := range path {
+				if seen == name {
+					return 0, fmt.Errorf("цикл в частях шаблонов%s: %s", where, strings.Join(append(path[i:], name), " -> "))
+				}
+			}
+
+			text, _ := c.Partial(channel, name)
+			path = append(path, name)
+			depth := 1
+			for _, match := range partialRe.FindAllStringSubmatch(text, -1) {
+				if _, ok := c.Partial(channel, match[1]); !ok {
+					continue
+				}
+				nested, err := visit(match[1], path)
+				if err != nil {
+					return 0, err
+				}
+				depth = max(depth, nested+1)
+			}
+			depths[name] = depth
+			return depth, nil
+		}
+
+		names := make([]string, 0, len(c.Partials)+len(c.Channels[channel].Partials))
+		for name := range c.Partials {
+			names = append(names, name)
+		}
+		for name := range c.Channels[channel].Partials {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			depth, err := visit(name, nil)
+			if err != nil {
+				return err
+			}
+			if depth > maxPartialDepth {
+				return fmt.Errorf("часть шаблона %s%s вложена глубже %d уровней", name, where, maxPartialDepth)
+			}
+		}
+	}
+	return nil
+}
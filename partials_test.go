@@ -0,0 +1,104 @@
+// partials_test.go
+package main
+
+import "testing"
+
+func TestExpandPartials(t *testing.T) {
+	// Цикл loop_a -> loop_b не прошел бы проверку config.yaml, здесь он
+	// проверяет, что разметка не уходит в чат
+	b := &Bot{config: &Config{
+		Partials: map[string]string{
+			"footer": "Правила: {{>rules}}",
+			"rules":  "не спамить",
+			"site":   "example.com",
+			"loop_a": "{{> loop_b}}",
+			"loop_b": "{{> loop_a}}",
+		},
+		Channels: map[string]ChannelConfig{
+			"канал": {Partials: map[string]string{"site": "канал.рф"}},
+		},
+	}}
+
+	tests := []struct {
+		name    string
+		channel string
+		text    string
+		want    string
+	}{
+		{"без частей", "другой", "просто текст", "просто текст"},
+		{"простая часть", "другой", "Сайт: {{> site}}", "Сайт: example.com"},
+		{"часть канала важнее общей", "#Канал", "Сайт: {{> site}}", "Сайт: канал.рф"},
+		{"вложенная часть", "другой", "{{> footer}}!", "Правила: не спамить!"},
+		{"неизвестная часть", "другой", "{{> нет}} {{>site}}", "{{> нет}} example.com"},
+		{"ссылки глубже предела убираются", "другой", "до {{> loop_a}} после", "до  после"},
+	}
+	for _, tt := range tests {
+		if got := b.expandPartials(tt.channel, tt.text); got != tt.want {
+			t.Errorf("%s: expandPartials(%q) = %q, ожидалось %q", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePartials(t *testing.T) {
+	chain := map[string]string{"p1": "{{> p2}}", "p2": "{{> p3}}", "p3": "{{> p4}}", "p4": "{{> p5}}", "p5": "конец"}
+	tooDeep := map[string]string{"p0": "{{> p1}}"}
+	for name, text := range chain {
+		tooDeep[name] = text
+	}
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{
+			name:   "без циклов",
+			config: Config{Partials: map[string]string{"footer": "Правила: {{> rules}}", "rules": "не спамить"}},
+		},
+		{
+			name:   "неизвестная ссылка",
+			config: Config{Partials: map[string]string{"footer": "{{> нет}}"}},
+		},
+		{
+			name:   "вложенность на пределе",
+			config: Config{Partials: chain},
+		},
+		{
+			name:    "цикл",
+			config:  Config{Partials: map[string]string{"a": "{{> b}}", "b": "x {{> a}}"}},
+			wantErr: "цикл в частях шаблонов: a -> b -> a",
+		},
+		{
+			name:    "ссылка на себя",
+			config:  Config{Partials: map[string]string{"a": "{{>a}}"}},
+			wantErr: "цикл в частях шаблонов: a -> a",
+		},
+		{
+			name: "цикл через часть канала",
+			config: Config{
+				Partials: map[string]string{"footer": "{{> site}}", "site": "example.com"},
+				Channels: map[string]ChannelConfig{
+					"канал": {Partials: map[string]string{"site": "канал.рф {{> footer}}"}},
+				},
+			},
+			wantErr: "цикл в частях шаблонов канала канал: footer -> site -> footer",
+		},
+		{
+			name:    "слишком глубоко",
+			config:  Config{Partials: tooDeep},
+			wantErr: "часть шаблона p0 вложена глубже 5 уровней",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.validatePartials()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("неожиданная ошибка: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("ошибка = %v, ожидалась %q", err, tt.wantErr)
+			}
+		})
+	}
+}
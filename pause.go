@@ -0,0 +1,65 @@
+// pause.go
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Состояние паузы: бот остается подключенным, но не реагирует на команды
+type PauseState struct {
+	mu     sync.Mutex
+	paused bool
+	until  time.Time
+	timer  *time.Timer
+}
+
+// Pause ставит бота на паузу. При duration > 0 пауза снимается автоматически.
+func (p *PauseState) Pause(duration time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+
+	p.paused = true
+	p.until = time.Time{}
+
+	if duration > 0 {
+		p.until = time.Now().Add(duration)
+		p.timer = time.AfterFunc(duration, func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+
+			p.paused = false
+			p.until = time.Time{}
+			p.timer = nil
+			slog.Info("Пауза снята автоматически")
+		})
+	}
+}
+
+// Resume снимает паузу
+func (p *PauseState) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+
+	p.paused = false
+	p.until = time.Time{}
+}
+
+// Paused сообщает, стоит ли бот на паузе, и время автоматического снятия (если задано)
+func (p *PauseState) Paused() (bool, time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.paused, p.until
+}
@@ -0,0 +1,230 @@
+// permissions.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Уровни доступа к командам (поле requires)
+const (
+	RequiresFollower    = "follower"
+	RequiresSubscriber  = "subscriber"
+	RequiresVIP         = "vip"
+	RequiresModerator   = "moderator"
+	RequiresBroadcaster = "broadcaster"
+)
+
+// Названия уровней доступа для сообщения об отказе
+var requirementNames = map[string]string{
+	RequiresFollower:    "фолловерам",
+	RequiresSubscriber:  "сабам",
+	RequiresVIP:         "VIP",
+	RequiresModerator:   "модераторам",
+	RequiresBroadcaster: "стримеру",
+}
+
+const defaultDeniedMessage = "@{user}, команда {command} доступна только {requirement}"
+
+func validateRequirement(requires string) error {
+	if requires == "" {
+		return nil
+	}
+	if _, ok := requirementNames[requires]; !ok {
+		return fmt.Errorf("неизвестный уровень доступа %q", requires)
+	}
+	return nil
+}
+
+const (
+	// Сколько ждать ответа Twitch о фолловинге, прежде чем отказаться от команды
+	followerLookupTimeout = 5 * time.Second
+	// Пауза перед повторным запросом после ошибки
+	followerRetryAfter = 30 * time.Second
+)
+
+// Кэш проверок фолловинга через Helix. Запросы идут в фоне, чтобы не задерживать
+// чтение чата. При ошибке Twitch используется прошлый статус, если он не старше
+// helixStaleLimit, и запрос повторяется не раньше чем через followerRetryAfter.
+type FollowerCache struct {
+	mu      sync.Mutex
+	lookup  func(broadcasterID, userID string) (bool, error)
+	ttl     time.Duration
+	entries map[string]followerEntry
+	pending map[string]chan struct{}
+	swept   time.Time
+}
+
+type followerEntry struct {
+	follows bool
+	// Когда статус получен от Twitch; пусто, если получить его не удалось
+	fetched time.Time
+	expires time.Time
+}
+
+func NewFollowerCache(helix *HelixClient, ttl time.Duration) *FollowerCache {
+	return newFollowerCache(helix.IsFollower, ttl)
+}
+
+func newFollowerCache(lookup func(broadcasterID, userID string) (bool, error), ttl time.Duration) *FollowerCache {
+	return &FollowerCache{
+		lookup:  lookup,
+		ttl:     ttl,
+		entries: make(map[string]followerEntry),
+		pending: make(map[string]chan struct{}),
+		swept:   time.Now(),
+	}
+}
+
+// Status возвращает статус фолловинга из кэша. Если его еще нет, запускает
+// запрос в фоне и возвращает канал, который закроется по его завершении.
+func (fc *FollowerCache) Status(broadcasterID, userID string) (follows bool, ready <-chan struct{}) {
+	key := broadcasterID + ":" + userID
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if entry, ok := fc.entries[key]; ok && time.Now().Before(entry.expires) {
+		return entry.follows, nil
+	}
+	done, ok := fc.pending[key]
+	if !ok {
+		done = make(chan struct{})
+		fc.pending[key] = done
+		go fc.fetch(key, broadcasterID, userID, done)
+	}
+	return false, done
+}
+
+func (fc *FollowerCache) fetch(key, broadcasterID, userID string, done chan struct{}) {
+	follows, err := fc.lookup(broadcasterID, userID)
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	defer close(done)
+
+	delete(fc.pending, key)
+	now := time.Now()
+	switch {
+	case err == nil:
+		fc.entries[key] = followerEntry{follows: follows, fetched: now, expires: now.Add(fc.ttl)}
+	case !fc.entries[key].fetched.IsZero() && now.Sub(fc.entries[key].fetched) < helixStaleLimit:
+		entry := fc.entries[key]
+		entry.expires = now.Add(followerRetryAfter)
+		fc.entries[key] = entry
+		slog.Warn("Ошибка проверки фолловинга, используется прошлый статус", "error", err, "user_id", userID, "age", now.Sub(entry.fetched))
+	default:
+		fc.entries[key] = followerEntry{expires: now.Add(followerRetryAfter)}
+		slog.Warn("Ошибка проверки фолловинга", "error", err, "user_id", userID)
+	}
+	fc.evictLocked(now)
+}
+
+// evictLocked не чаще раза за ttl удаляет истекшие записи, кроме тех, что еще
+// могут пригодиться как прошлый статус при недоступности Twitch
+func (fc *FollowerCache) evictLocked(now time.Time) {
+	if now.Sub(fc.swept) < fc.ttl {
+		return
+	}
+	fc.swept = now
+	for key, entry := range fc.entries {
+		if !now.Before(entry.expires) && (entry.fetched.IsZero() || now.Sub(entry.fetched) >= helixStaleLimit) {
+			delete(fc.entries, key)
+		}
+	}
+}
+
+// hasAccess проверяет, удовлетворяет ли автор сообщения требованию команды.
+// Непустой ready означает, что статус фолловинга еще запрашивается: проверку
+// нужно повторить, когда канал закроется.
+func (b *Bot) hasAccess(message twitch.PrivateMessage, requires string) (allowed bool, ready <-chan struct{}) {
+	user := message.User
+
+	switch requires {
+	case "":
+		return true, nil
+	case RequiresBroadcaster:
+		return user.IsBroadcaster, nil
+	case RequiresModerator:
+		return user.IsBroadcaster || user.IsMod, nil
+	case RequiresVIP:
+		return user.IsBroadcaster || user.IsMod || user.IsVip, nil
+	case RequiresSubscriber:
+		_, subscriber := user.Badges["subscriber"]
+		_, founder := user.Badges["founder"]
+		return user.IsBroadcaster || subscriber || founder, nil
+	case RequiresFollower:
+		// Значки не показывают фолловинг, поэтому спрашиваем Helix
+		if user.IsBroadcaster || user.IsMod || user.IsVip {
+			return true, nil
+		}
+		if b.followers == nil {
+			slog.Warn("Проверка фолловинга недоступна: не задан TWITCH_CLIENT_ID")
+			return false, nil
+		}
+		return b.followers.Status(message.RoomID, user.ID)
+	}
+
+	return false, nil
+}
+
+// Ограничитель уведомлений об отказе: не чаще одного за window на канал,
+// чтобы отказами нельзя было заспамить чат
+type NoticeLimiter struct {
+	window time.Duration
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func NewNoticeLimiter(window time.Duration) *NoticeLimiter {
+	return &NoticeLimiter{window: window, last: make(map[string]time.Time)}
+}
+
+// Allow сообщает, можно ли отправить уведомление в канал сейчас
+func (l *NoticeLimiter) Allow(channel string) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.last[channel]) < l.window {
+		return false
+	}
+	l.last[channel] = now
+	return true
+}
+
+// deniedNotice отвечает на недоступную команду, если ответы включены и
+// ограничитель позволяет. template - шаблон отказа или недоступности.
+func (b *Bot) deniedNotice(ctx context.Context, message twitch.PrivateMessage, command *Command, template string) {
+	if !b.deniedReply || template == "" {
+		return
+	}
+	if !b.deniedLimiter.Allow(normalizeChannel(message.Channel)) {
+		slog.Debug("Уведомление об отказе подавлено", "command", command.Command, "user", message.User.Name)
+		return
+	}
+
+	b.respond(ctx, message, renderTemplate(template, map[string]string{
+		"user":        message.User.Name,
+		"command":     command.Command,
+		"requirement": requirementNames[command.Requires],
+	}))
+}
+
+// deniedTemplate возвращает шаблон вежливого отказа для команды с ограниченным доступом
+func (b *Bot) deniedTemplate(message twitch.PrivateMessage, command *Command) string {
+	if command.DeniedMessage != "" {
+		return command.DeniedMessage
+	}
+	return b.templates(message).PermissionDenied
+}
@@ -0,0 +1,199 @@
+// permissions_test.go
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Запрос к Helix не должен задерживать чтение чата
+func TestFollowerCacheNonBlocking(t *testing.T) {
+	release := make(chan struct{})
+	calls := make(chan string, 10)
+	fc := newFollowerCache(func(broadcasterID, userID string) (bool, error) {
+		calls <- userID
+		<-release
+		return userID == "1", nil
+	}, time.Minute)
+
+	var ready <-chan struct{}
+	done := make(chan struct{})
+	go func() {
+		for range 3 {
+			if _, ready = fc.Status("100", "1"); ready == nil {
+				t.Error("статус известен до ответа Helix")
+			}
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Status ждет ответа Helix")
+	}
+
+	close(release)
+	select {
+	case <-ready:
+	case <-time.After(time.Second):
+		t.Fatal("статус так и не получен")
+	}
+	if follows, ready := fc.Status("100", "1"); ready != nil || !follows {
+		t.Errorf("фолловер не распознан: %v, %v", follows, ready)
+	}
+	if len(calls) != 1 {
+		t.Errorf("запросов к Helix: %d, ожидался один", len(calls))
+	}
+}
+
+// При сбое Twitch используется прошлый статус, а запросы не повторяются на каждом сообщении
+func TestFollowerCacheFailure(t *testing.T) {
+	calls := 0
+	fail := false
+	fc := newFollowerCache(func(broadcasterID, userID string) (bool, error) {
+		calls++
+		if fail {
+			return false, ErrHelixUnavailable
+		}
+		return true, nil
+	}, time.Minute)
+	status := func(userID string) bool {
+		follows, ready := fc.Status("100", userID)
+		if ready != nil {
+			<-ready
+			follows, ready = fc.Status("100", userID)
+			if ready != nil {
+				t.Fatal("статус не сохранен после запроса")
+			}
+		}
+		return follows
+	}
+
+	if !status("1") {
+		t.Fatal("фолловер не распознан")
+	}
+	fail = true
+	entry := fc.entries["100:1"]
+	entry.expires = time.Now()
+	fc.entries["100:1"] = entry
+
+	if !status("1") {
+		t.Error("при сбое Twitch не использован прошлый статус")
+	}
+	if status("2") {
+		t.Error("без прошлого статуса зритель принят за фолловера")
+	}
+	for range 5 {
+		status("2")
+	}
+	if calls != 3 {
+		t.Errorf("запросов к Helix: %d, ожидалось 3", calls)
+	}
+}
+
+func TestFollowerCacheEviction(t *testing.T) {
+	fc := newFollowerCache(nil, time.Minute)
+	now := time.Now()
+	fc.entries["100:1"] = followerEntry{follows: true, fetched: now.Add(-helixStaleLimit), expires: now.Add(-time.Second)}
+	fc.entries["100:2"] = followerEntry{follows: true, fetched: now, expires: now.Add(time.Minute)}
+	fc.entries["100:3"] = followerEntry{expires: now.Add(time.Second)}
+
+	fc.evictLocked(now)
+	if len(fc.entries) != 3 {
+		t.Fatalf("очистка раньше ttl: осталось %d", len(fc.entries))
+	}
+
+	fc.evictLocked(now.Add(2 * time.Minute))
+	if _, ok := fc.entries["100:2"]; !ok || len(fc.entries) != 1 {
+		t.Errorf("после очистки осталось %v, ожидался только прошлый статус 100:2", fc.entries)
+	}
+}
+
+func TestHasAccess(t *testing.T) {
+	b := &Bot{}
+	tests := []struct {
+		name     string
+		user     twitch.User
+		requires string
+		allowed  bool
+	}{
+		{"без требований", twitch.User{}, "", true},
+		{"модератор", twitch.User{IsMod: true}, RequiresModerator, true},
+		{"зритель и модераторы", twitch.User{}, RequiresModerator, false},
+		{"стример и VIP", twitch.User{IsBroadcaster: true}, RequiresVIP, true},
+		{"саб по значку", twitch.User{Badges: map[string]int{"subscriber": 12}}, RequiresSubscriber, true},
+		{"основатель", twitch.User{Badges: map[string]int{"founder": 0}}, RequiresSubscriber, true},
+		{"VIP и фолловеры", twitch.User{IsVip: true}, RequiresFollower, true},
+		{"фолловинг без Helix", twitch.User{}, RequiresFollower, false},
+		{"модератор и стример", twitch.User{IsMod: true}, RequiresBroadcaster, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, ready := b.hasAccess(twitch.PrivateMessage{User: tt.user}, tt.requires)
+			if allowed != tt.allowed || ready != nil {
+				t.Errorf("hasAccess = %v, %v, ожидалось %v", allowed, ready, tt.allowed)
+			}
+		})
+	}
+}
+
+// Первый вызов команды для фолловеров получает ответ, когда Twitch ответит
+func TestFollowerCommandAnsweredAfterLookup(t *testing.T) {
+	var mu sync.Mutex
+	var out bytes.Buffer
+	b := &Bot{
+		config:        &Config{},
+		baseTemplates: defaultTemplates,
+		mentions:      NewMentionMatcher([]string{"paste_bot"}, nil),
+		pool:          newDryRunPool([]string{"paste_bot"}, []string{"channel"}, lockedWriter{&mu, &out}),
+		pause:         &PauseState{},
+		away:          NewAwayState(""),
+		cooldown:      NewGlobalCooldownManager(0),
+		recent:        NewRecentCommands(),
+		commands: map[string]*Command{
+			"!фолл": {Command: "!фолл", Text: "только для своих", Requires: RequiresFollower},
+		},
+	}
+	b.senders = NewSenderFilter(b.pool, false, nil)
+
+	release := make(chan struct{})
+	b.followers = newFollowerCache(func(broadcasterID, userID string) (bool, error) {
+		<-release
+		return true, nil
+	}, time.Minute)
+
+	b.processMessage(twitch.PrivateMessage{Channel: "channel", ID: "1", RoomID: "100", User: twitch.User{ID: "7", Name: "viewer"}, Message: "!фолл"}, time.Now())
+	close(release)
+
+	deadline := time.After(2 * time.Second)
+	for {
+		mu.Lock()
+		got := out.String()
+		mu.Unlock()
+		if strings.Contains(got, "только для своих") {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("нет ответа после получения статуса: %q", got)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (l lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
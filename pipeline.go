@@ -0,0 +1,439 @@
+// pipeline.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Состояние обработки одного сообщения чата. Заполняется по ходу цепочки
+// middleware: каждое звено читает то, что подготовили предыдущие.
+type MessageContext struct {
+	Ctx        context.Context
+	Message    twitch.PrivateMessage
+	ReceivedAt time.Time
+
+	// Настройки канала из config.yaml
+	Channel ChannelConfig
+	// Модератор или стример
+	Privileged bool
+
+	// Текст без упоминания бота и префикса канала
+	Clean string
+	// Бот упомянут в сообщении
+	Mentioned bool
+	// Сообщение начинается с префикса команд канала
+	Prefixed bool
+
+	// Найденная команда и ее аргументы
+	Name    string
+	Args    []string
+	Handler Handler
+	Command *Command
+}
+
+// Known сообщает, найдена ли встроенная или загруженная команда
+func (mc *MessageContext) Known() bool {
+	return mc.Handler != nil || mc.Command != nil
+}
+
+// Звено обработки сообщения. Чтобы передать сообщение дальше, middleware
+// вызывает next; не вызвав его, оно прекращает обработку.
+type Middleware func(b *Bot, mc *MessageContext, next func())
+
+// Звенья до поиска команды: фильтры отправителей и адресации
+var prefilterPipeline = []Middleware{
+	traceMiddleware,
+	ignoreMiddleware,
+	parseMiddleware,
+	// Служебные команды модераторов до пробного запуска, иначе его нельзя
+	// было бы выключить из чата при SOFT_LAUNCH_MODS=false
+	adminMiddleware,
+	softLaunchMiddleware,
+	pauseMiddleware,
+	awayMiddleware,
+	addressMiddleware,
+	floodMiddleware,
+}
+
+// Звенья от поиска команды до ответа
+var commandPipeline = []Middleware{
+	matchMiddleware,
+	permissionMiddleware,
+	cooldownMiddleware,
+	respondMiddleware,
+}
+
+// Use добавляет middleware между фильтрами и поиском команды, чтобы оно
+// видело каждое адресованное боту сообщение. Вызывается до подключения к чату.
+func (b *Bot) Use(middleware Middleware) {
+	b.middleware = append(b.middleware, middleware)
+}
+
+func (b *Bot) handleMessage(message twitch.PrivateMessage) {
+	receivedAt := time.Now()
+	b.recorder.Record(receivedAt, message)
+	b.processMessage(message, receivedAt)
+}
+
+// processMessage прогоняет сообщение через всю цепочку обработки
+func (b *Bot) processMessage(message twitch.PrivateMessage, receivedAt time.Time) {
+	pipeline := make([]Middleware, 0, len(prefilterPipeline)+len(b.middleware)+len(commandPipeline))
+	pipeline = append(pipeline, prefilterPipeline...)
+	pipeline = append(pipeline, b.middleware...)
+	pipeline = append(pipeline, commandPipeline...)
+
+	b.runPipeline(pipeline, &MessageContext{
+		Ctx:        context.Background(),
+		Message:    message,
+		ReceivedAt: receivedAt,
+	})
+}
+
+func (b *Bot) runPipeline(pipeline []Middleware, mc *MessageContext) {
+	if len(pipeline) == 0 {
+		return
+	}
+	pipeline[0](b, mc, func() { b.runPipeline(pipeline[1:], mc) })
+}
+
+// traceMiddleware открывает span на все время обработки
+func traceMiddleware(b *Bot, mc *MessageContext, next func()) {
+	ctx, span := tracer.Start(mc.Ctx, "receive", trace.WithAttributes(
+		attribute.String("channel", mc.Message.Channel),
+		attribute.String("user", mc.Message.User.Name),
+	))
+	defer span.End()
+
+	mc.Ctx = ctx
+	next()
+}
+
+// ignoreMiddleware отсекает свои сообщения и сообщения других ботов, чтобы не зациклиться
+func ignoreMiddleware(b *Bot, mc *MessageContext, next func()) {
+	if b.senders.Ignored(mc.Message.User.Name) {
+		return
+	}
+	if b.activity != nil {
+		b.activity.Record(mc.Message.Channel, mc.ReceivedAt)
+	}
+	next()
+}
+
+// parseMiddleware убирает упоминание бота и префикс канала
+func parseMiddleware(b *Bot, mc *MessageContext, next func()) {
+	mc.Channel = b.Config().Channel(mc.Message.Channel)
+	mc.Privileged = isPrivileged(mc.Message.User)
+
+	text := mc.Message.Message
+	// Ответ на сообщение самого бота остается упоминанием бота
+	if parent := mc.Message.Tags["reply-parent-user-login"]; parent != "" && !b.pool.IsOwnAccount(parent) {
+		text = stripReplyPrefix(text, mc.Message.Tags)
+	}
+	mc.Clean, mc.Mentioned = b.mentions.Strip(text)
+	trace.SpanFromContext(mc.Ctx).SetAttributes(attribute.Bool("mentioned", mc.Mentioned))
+
+	mc.Clean, mc.Prefixed = applyPrefix(mc.Clean, b.commandPrefix(mc.Message.Channel))
+	next()
+}
+
+// adminMiddleware обрабатывает служебные команды модераторов вне cooldown и паузы
+func adminMiddleware(b *Bot, mc *MessageContext, next func()) {
+	if b.handleAdminCommand(mc.Ctx, mc.Message, strings.Fields(mc.Clean)) {
+		return
+	}
+	next()
+}
+
+func pauseMiddleware(b *Bot, mc *MessageContext, next func()) {
+	if paused, _ := b.pause.Paused(); paused {
+		slog.Debug("Бот на паузе")
+		return
+	}
+	next()
+}
+
+// addressMiddleware пропускает сообщения с упоминанием бота, а в режиме
+// "все команды" - также прямые команды
+func addressMiddleware(b *Bot, mc *MessageContext, next func()) {
+	directCommand := !b.mentionOnlyFor(mc.Message.Channel) && mc.Prefixed
+	if !mc.Mentioned && !directCommand {
+		return
+	}
+	next()
+}
+
+// floodMiddleware считает попытки до cooldown, иначе флудер просто держит бота в cooldown
+func floodMiddleware(b *Bot, mc *MessageContext, next func()) {
+	if !mc.Privileged {
+		if ignored, started := b.flood.Attempt(mc.Message.Channel, mc.Message.User.Name); ignored {
+			if notice := b.templates(mc.Message).FloodNotice; started && notice != "" {
+				b.respond(mc.Ctx, mc.Message, renderTemplate(notice, map[string]string{
+					"user":    mc.Message.User.Name,
+					"minutes": fmt.Sprintf("%d", int(b.flood.ignoreFor.Minutes())),
+				}))
+			}
+			return
+		}
+	}
+	next()
+}
+
+// matchMiddleware извлекает команду и аргументы
+func matchMiddleware(b *Bot, mc *MessageContext, next func()) {
+	_, matchSpan := tracer.Start(mc.Ctx, "match")
+
+	commandParts := strings.Fields(mc.Clean)
+	if len(commandParts) == 0 {
+		matchSpan.End()
+		return
+	}
+
+	// При упоминании команда может стоять в любом месте сообщения:
+	// "эй @bot скинь !паста3 плиз"
+	if mc.Mentioned {
+		if i := b.findCommand(commandParts); i > 0 {
+			commandParts = commandParts[i:]
+		}
+	}
+
+	mc.Name, mc.Args = commandParts[0], b.stripEmoteArgs(mc.Message, commandParts[1:])
+	if name, ok := b.matchToken(mc.Name); ok {
+		mc.Name = name
+	}
+	if !b.isBuiltin(mc.Name) {
+		if _, ok := b.Commands()[mc.Name]; !ok {
+			if name, ok := b.resolveAlias(mc.Name); ok {
+				mc.Name = name
+			}
+		}
+	}
+	if handler, ok := b.handlers[mc.Name]; ok {
+		mc.Handler = handler
+	} else if command, ok := b.Commands()[mc.Name]; ok {
+		mc.Command = command
+	}
+
+	matchSpan.SetAttributes(attribute.String("command", mc.Name), attribute.Bool("known", mc.Known()))
+	matchSpan.End()
+	next()
+}
+
+// cooldownMiddleware проверяет cooldown канала
+func cooldownMiddleware(b *Bot, mc *MessageContext, next func()) {
+	_, exempt := mc.Handler.(cooldownExempt)
+	// Модерация не должна ждать, пока бот остынет после пасты
+	exempt = exempt || (mc.Command != nil && mc.Command.IsModeration())
+	// Повтор в открытом окне только подсчитывается (DUPLICATE_MODE=aggregate),
+	// а первый вызов окна уже включил cooldown
+	exempt = exempt || (mc.Command != nil && b.duplicates.Pending(mc.Message.Channel, mc.Name))
+
+	_, cooldownSpan := tracer.Start(mc.Ctx, "cooldown")
+	canUse := exempt || b.cooldown.CanUse(mc.Message.Channel)
+	cooldownSpan.SetAttributes(attribute.Bool("active", !canUse))
+	cooldownSpan.End()
+
+	if !canUse {
+		slog.Debug("Бот в cooldown")
+		b.cooldownNotice(mc)
+		return
+	}
+	next()
+}
+
+// cooldownNotice сообщает о cooldown, если для канала задан шаблон уведомления
+func (b *Bot) cooldownNotice(mc *MessageContext) {
+	template := b.templates(mc.Message).CooldownNotice
+	if template == "" || !mc.Known() {
+		return
+	}
+
+	remaining, ok := b.cooldown.TakeNotice(mc.Message.Channel)
+	if !ok {
+		return
+	}
+
+	b.respond(mc.Ctx, mc.Message, renderTemplate(template, map[string]string{
+		"user":      mc.Message.User.Name,
+		"remaining": fmt.Sprintf("%d", int(remaining.Seconds())+1),
+	}))
+}
+
+// permissionMiddleware проверяет расписание и уровень доступа команды
+func permissionMiddleware(b *Bot, mc *MessageContext, next func()) {
+	command := mc.Command
+	if command == nil {
+		next()
+		return
+	}
+
+	if !command.Available(time.Now()) {
+		slog.Debug("Команда недоступна по расписанию", "command", mc.Name, "user", mc.Message.User.Name)
+		b.deniedNotice(mc.Ctx, mc.Message, command, b.templates(mc.Message).Unavailable)
+		return
+	}
+
+	_, permissionSpan := tracer.Start(mc.Ctx, "permission", trace.WithAttributes(
+		attribute.String("requires", command.Requires),
+	))
+	allowed, ready := b.hasAccess(mc.Message, command.Requires)
+	permissionSpan.SetAttributes(attribute.Bool("allowed", allowed), attribute.Bool("pending", ready != nil))
+	permissionSpan.End()
+
+	if ready == nil {
+		b.permitted(mc, allowed, next)
+		return
+	}
+
+	// Статус фолловинга запрашивается: ответим, когда он придет, не задерживая чтение чата
+	go func() {
+		select {
+		case <-ready:
+		case <-time.After(followerLookupTimeout):
+		}
+		allowed, ready := b.hasAccess(mc.Message, command.Requires)
+		if ready != nil {
+			slog.Warn("Twitch не ответил о фолловинге вовремя, команда пропущена", "command", mc.Name, "user", mc.Message.User.Name)
+			return
+		}
+		b.permitted(mc, allowed, next)
+	}()
+}
+
+// permitted продолжает обработку или отвечает отказом по результату проверки прав
+func (b *Bot) permitted(mc *MessageContext, allowed bool, next func()) {
+	if !allowed {
+		slog.Debug("Недостаточно прав для команды", "command", mc.Name, "user", mc.Message.User.Name, "requires", mc.Command.Requires)
+		b.deniedNotice(mc.Ctx, mc.Message, mc.Command, b.deniedTemplate(mc.Message, mc.Command))
+		return
+	}
+	next()
+}
+
+// respondMiddleware выполняет найденную команду
+func respondMiddleware(b *Bot, mc *MessageContext, next func()) {
+	ctx, message := mc.Ctx, mc.Message
+	if mc.Known() {
+		ctx = withLatencyOrigin(ctx, mc.Name, mc.ReceivedAt, b.usageRecorder(mc))
+	}
+
+	// Встроенные команды. Обращения к Twitch API не должны задерживать
+	// чтение соединения, поэтому такие обработчики выполняются отдельно.
+	if mc.Handler != nil {
+		if _, async := mc.Handler.(asyncHandler); async {
+			go b.runHandler(ctx, mc)
+			return
+		}
+		b.runHandler(ctx, mc)
+		return
+	}
+
+	command := mc.Command
+	if command == nil {
+		slog.Debug("Неизвестная команда", "command", mc.Name, "user", message.User.Name)
+		// Отправляем сообщение о неизвестной команде (без cooldown для этого сообщения)
+		if b.mentionOnlyFor(message.Channel) {
+			b.respond(ctx, message, renderTemplate(b.templates(message).UnknownCommand, map[string]string{
+				"user":    message.User.Name,
+				"command": mc.Name,
+			}))
+		}
+		return
+	}
+
+	if command.IsModeration() {
+		// Действие идет через Twitch API, не задерживаем чтение чата
+		go b.moderate(ctx, mc)
+		return
+	}
+
+	if b.streamUses.Exhausted(message.Channel, command) {
+		slog.Debug("Лимит вызовов команды за стрим исчерпан", "command", mc.Name, "user", message.User.Name)
+		// Отказ не считается выполнением команды
+		b.deniedNotice(mc.Ctx, message, command, b.templates(message).Exhausted)
+		return
+	}
+
+	var responses []string
+	for _, text := range command.Messages() {
+		responses = append(responses, b.renderPaste(message, mc.Args, text))
+	}
+
+	// Одинаковые вызовы в коротком окне: отвечаем один раз
+	first := b.duplicates.Trigger(message.Channel, mc.Name, func(count int) {
+		if count > 1 {
+			last := len(responses) - 1
+			responses[last] = renderTemplate(b.templates(message).DuplicateAggregate, map[string]string{
+				"text":  responses[last],
+				"count": fmt.Sprintf("%d", count),
+			})
+		}
+		b.respondSequenceDelayed(ctx, message, responses, command)
+	})
+	if !first {
+		slog.Debug("Повторный вызов команды подавлен", "command", mc.Name, "user", message.User.Name)
+		return
+	}
+
+	// Устанавливаем cooldown канала перед отправкой ответа
+	b.cooldown.Use(message.Channel)
+	b.streamUses.Use(message.Channel, command)
+
+	b.recent.Record(UsageRecord{Time: mc.ReceivedAt, Channel: message.Channel, User: message.User.Name, Command: mc.Name})
+
+	trace.SpanFromContext(ctx).AddEvent("command_executed", trace.WithAttributes(
+		attribute.String("command", mc.Name),
+	))
+
+	slog.Info("Команда выполнена",
+		"user", message.User.Name,
+		"command", mc.Name,
+		"response", strings.Join(responses, " / "))
+}
+
+// usageRecorder возвращает запись в журнал использования и сводку стрима, которая
+// выполняется при отправке ответа: так в журнал попадает задержка до чата, а
+// отклоненные и подавленные вызовы не учитываются
+func (b *Bot) usageRecorder(mc *MessageContext) func(time.Duration) {
+	if b.usage == nil && b.sessions == nil {
+		return nil
+	}
+	rec := UsageRecord{
+		Time:    mc.ReceivedAt,
+		Channel: mc.Message.Channel,
+		User:    mc.Message.User.Name,
+		Command: mc.Name,
+	}
+	return func(latency time.Duration) {
+		b.sessions.RecordCommand(rec.Channel, rec.User, rec.Command)
+		if b.usage == nil {
+			return
+		}
+		sent := rec
+		sent.Latency = latency
+		b.usage.Record(sent)
+	}
+}
+
+// runHandler выполняет встроенную команду и отправляет ее ответ через очередь.
+// Cooldown включает только ответ: молча отклоненный вызов (например, !so от
+// зрителя) не должен держать бота в cooldown.
+func (b *Bot) runHandler(ctx context.Context, mc *MessageContext) {
+	message := mc.Message
+	response := mc.Handler.Handle(ctx, message, mc.Args)
+	if response == "" {
+		return
+	}
+	if _, exempt := mc.Handler.(cooldownExempt); !exempt {
+		b.cooldown.Use(message.Channel)
+	}
+	b.recent.Record(UsageRecord{Time: mc.ReceivedAt, Channel: message.Channel, User: message.User.Name, Command: mc.Name})
+	b.respondDelayed(ctx, message, b.renderPrefix(message.Channel, response), nil)
+}
@@ -0,0 +1,211 @@
+// pipeline_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Обработчики с обращениями к Twitch API не должны держать чтение чата
+func TestRespondMiddlewareAsyncHandler(t *testing.T) {
+	b := &Bot{cooldown: NewGlobalCooldownManager(0), recent: NewRecentCommands()}
+
+	release := make(chan struct{})
+	done := make(chan struct{})
+	handler := asyncHandler{handlerFunc{[]string{"!uptime"}, func(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+		<-release
+		close(done)
+		return ""
+	}}}
+
+	returned := make(chan struct{})
+	go func() {
+		respondMiddleware(b, &MessageContext{Ctx: context.Background(), Name: "!uptime", Handler: handler}, func() {})
+		close(returned)
+	}()
+
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("respondMiddleware ждет обработчик Twitch API")
+	}
+	close(release)
+	<-done
+}
+
+// Молча отклоненная встроенная команда не включает cooldown
+func TestRunHandlerEmptyResponseKeepsCooldown(t *testing.T) {
+	b := &Bot{cooldown: NewGlobalCooldownManager(time.Minute), recent: NewRecentCommands()}
+	handler := handlerFunc{[]string{"!последние"}, func(ctx context.Context, message twitch.PrivateMessage, args []string) string {
+		return ""
+	}}
+
+	b.runHandler(context.Background(), &MessageContext{Name: "!последние", Handler: handler})
+	if !b.cooldown.CanUse("") {
+		t.Error("пустой ответ включил cooldown")
+	}
+	if len(b.recent.Last("", 1)) != 0 {
+		t.Error("пустой ответ попал в историю команд")
+	}
+}
+
+func TestUnknownCommandReplyPerChannel(t *testing.T) {
+	on, off := true, false
+	config := &Config{Channels: map[string]ChannelConfig{
+		"quiet": {MentionOnly: &off},
+		"loud":  {MentionOnly: &on},
+	}}
+
+	tests := []struct {
+		name      string
+		channel   string
+		global    bool
+		wantReply bool
+	}{
+		{"включено в канале", "loud", false, true},
+		{"выключено в канале", "quiet", true, false},
+		{"глобальная настройка", "other", true, true},
+		{"нет подключения к каналу", "missing", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			b := &Bot{
+				config:        config,
+				mentionOnly:   tt.global,
+				baseTemplates: defaultTemplates,
+				pool:          newDryRunPool([]string{"bot"}, []string{"quiet", "loud", "other"}, &out),
+			}
+			message := twitch.PrivateMessage{Channel: tt.channel, ID: "1", User: twitch.User{Name: "viewer"}}
+
+			respondMiddleware(b, &MessageContext{Ctx: context.Background(), Message: message, Name: "!нет"}, func() {})
+
+			if got := strings.Contains(out.String(), "viewer"); got != tt.wantReply {
+				t.Errorf("ответ отправлен: %v, ожидалось %v (%q)", got, tt.wantReply, out.String())
+			}
+		})
+	}
+}
+
+// Журнал использования в памяти для тестов
+type memoryUsage struct {
+	mu      sync.Mutex
+	records []UsageRecord
+}
+
+func (u *memoryUsage) Record(rec UsageRecord) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.records = append(u.records, rec)
+}
+
+func (u *memoryUsage) Count(UsageFilter) (int, error)           { return len(u.records), nil }
+func (u *memoryUsage) Query(UsageFilter) ([]UsageRecord, error) { return u.records, nil }
+func (u *memoryUsage) CountsByCommand() (map[string]int, error) { return nil, nil }
+
+// Задержка в журнале считается до отправки ответа, и встроенные команды тоже учитываются
+func TestUsageRecordedOnSend(t *testing.T) {
+	usage := &memoryUsage{}
+	b := &Bot{usage: usage}
+	mc := &MessageContext{
+		Message:    twitch.PrivateMessage{Channel: "channel", User: twitch.User{Name: "viewer"}},
+		ReceivedAt: time.Now().Add(-200 * time.Millisecond),
+		Name:       "!время",
+	}
+
+	ctx := withLatencyOrigin(context.Background(), mc.Name, mc.ReceivedAt, b.usageRecorder(mc))
+	if len(usage.records) != 0 {
+		t.Fatal("запись сделана до отправки")
+	}
+
+	item := outgoing{channel: "channel", origin: latencyOriginFrom(ctx), enqueuedAt: time.Now()}
+	item.sent()
+
+	if len(usage.records) != 1 {
+		t.Fatalf("записей %d, ожидалась одна", len(usage.records))
+	}
+	rec := usage.records[0]
+	if rec.Command != "!время" || rec.User != "viewer" || rec.Latency < 200*time.Millisecond {
+		t.Errorf("неожиданная запись %+v", rec)
+	}
+
+	// Продолжения пасты идут без origin и второй раз не записываются
+	(outgoing{channel: "channel"}).sent()
+	if len(usage.records) != 1 {
+		t.Errorf("продолжение пасты записано отдельно")
+	}
+}
+
+// Порядок: права проверяются до cooldown, поэтому зритель без прав получает отказ,
+// а не уведомление о cooldown, и не тратит его
+func TestPermissionBeforeCooldown(t *testing.T) {
+	var out bytes.Buffer
+	templates := defaultTemplates
+	templates.CooldownNotice = "cooldown {remaining}"
+	b := &Bot{
+		config:        &Config{},
+		baseTemplates: templates,
+		mentions:      NewMentionMatcher([]string{"paste_bot"}, nil),
+		pool:          newDryRunPool([]string{"paste_bot"}, []string{"channel"}, &out),
+		pause:         &PauseState{},
+		away:          NewAwayState(""),
+		cooldown:      NewGlobalCooldownManager(time.Minute),
+		deniedReply:   true,
+		deniedLimiter: NewNoticeLimiter(0),
+		commands: map[string]*Command{
+			"!мод": {Command: "!мод", Text: "для модераторов", Requires: RequiresModerator},
+		},
+	}
+	b.senders = NewSenderFilter(b.pool, false, nil)
+	b.cooldown.Use("channel")
+
+	b.processMessage(twitch.PrivateMessage{Channel: "channel", ID: "1", User: twitch.User{Name: "viewer"}, Message: "!мод"}, time.Now())
+
+	if strings.Contains(out.String(), "cooldown") {
+		t.Errorf("зритель без прав получил уведомление о cooldown: %q", out.String())
+	}
+	if !strings.Contains(out.String(), "!мод") {
+		t.Errorf("нет отказа по правам: %q", out.String())
+	}
+	if _, ok := b.cooldown.TakeNotice("channel"); !ok {
+		t.Error("отказ по правам израсходовал уведомление о cooldown")
+	}
+}
+
+// Сводка стрима учитывает только вызовы, на которые бот ответил
+func TestSessionCountsSentOnly(t *testing.T) {
+	var out bytes.Buffer
+	session := &StreamSession{Commands: make(map[string]int), Users: make(map[string]int)}
+	b := &Bot{
+		config:        &Config{},
+		baseTemplates: defaultTemplates,
+		mentions:      NewMentionMatcher([]string{"paste_bot"}, nil),
+		pool:          newDryRunPool([]string{"paste_bot"}, []string{"channel"}, &out),
+		pause:         &PauseState{},
+		away:          NewAwayState(""),
+		cooldown:      NewGlobalCooldownManager(0),
+		recent:        NewRecentCommands(),
+		streamUses:    NewStreamUses(),
+		sessions:      &SessionTracker{sessions: map[string]*StreamSession{"channel": session}},
+		commands: map[string]*Command{
+			"!раз": {Command: "!раз", Text: "один раз за стрим", MaxUsesPerStream: 1},
+		},
+	}
+	b.senders = NewSenderFilter(b.pool, false, nil)
+
+	for i, user := range []string{"first", "second"} {
+		b.processMessage(twitch.PrivateMessage{Channel: "channel", ID: fmt.Sprint(i), User: twitch.User{Name: user}, Message: "!раз"}, time.Now())
+	}
+
+	if session.Commands["!раз"] != 1 || len(session.Users) != 1 || session.Users["first"] != 1 {
+		t.Errorf("в сводке %v, %v; ожидался один вызов от first", session.Commands, session.Users)
+	}
+}
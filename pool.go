@@ -0,0 +1,519 @@
+// pool.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Учетная запись бота из config.yaml
+type Account struct {
+	Username string `yaml:"username"`
+	Token    string `yaml:"token,omitempty"`
+	TokenEnv string `yaml:"token_env,omitempty"`
+	// Файл с токеном; перечитывается при изменении
+	TokenFile string   `yaml:"token_file,omitempty"`
+	Channels  []string `yaml:"channels,omitempty"`
+	// Уровень лимитов: normal, known, verified (по умолчанию RATE_LIMIT_TIER)
+	RateLimitTier string `yaml:"rate_limit_tier,omitempty"`
+	// Транспорт чата: irc или eventsub (по умолчанию CHAT_TRANSPORT)
+	Transport string `yaml:"transport,omitempty"`
+}
+
+// Транспорты чата
+const (
+	TransportIRC      = "irc"
+	TransportEventSub = "eventsub"
+)
+
+// Клиент чата, через который очередь отправляет сообщения
+type ChatClient interface {
+	Say(channel, text string)
+	Reply(channel, parentMsgID, text string)
+}
+
+// Обработчики событий подключения
+type ConnectionHandlers struct {
+	OnMessage func(message twitch.PrivateMessage)
+	OnConnect func(conn *Connection)
+	// Дополнительная настройка IRC-клиента (события, которых нет в EventSub)
+	SetupIRC func(conn *Connection, client *twitch.Client)
+}
+
+// Общие настройки пула подключений
+type PoolOptions struct {
+	DefaultTier      RateTier
+	DefaultTransport string
+	// Client ID приложения Twitch, нужен для транспорта eventsub
+	ClientID string
+}
+
+// ResolveToken возвращает токен из конфига, переменной окружения token_env или файла token_file
+func (a Account) ResolveToken() string {
+	if a.Token != "" {
+		return a.Token
+	}
+	if a.TokenEnv != "" {
+		return getSecret(a.TokenEnv)
+	}
+	if a.TokenFile != "" {
+		token, err := readSecretFile(a.TokenFile)
+		if err != nil {
+			slog.Warn("Ошибка чтения файла токена", "bot_username", a.Username, "error", err)
+		}
+		return token
+	}
+	return ""
+}
+
+// Подключение одной учетной записи бота со своим клиентом и токеном
+type Connection struct {
+	username  string
+	token     string
+	tokenFile string
+	channels  []string
+	queue     *SendQueue
+	transport string
+	clientID  string
+
+	// Время последней активности соединения (unix nano) для watchdog
+	lastAlive atomic.Int64
+
+	// Helix для личных сообщений и объявлений (nil без TWITCH_CLIENT_ID)
+	helix *HelixClient
+
+	mu     sync.RWMutex
+	client ChatClient
+	joined map[string]bool
+	userID string
+}
+
+// markAlive отмечает, что от Twitch пришли данные
+func (c *Connection) markAlive() {
+	c.lastAlive.Store(time.Now().UnixNano())
+}
+
+// Alive сообщает, была ли активность соединения за последние timeout
+func (c *Connection) Alive(timeout time.Duration) bool {
+	last := c.lastAlive.Load()
+	return last != 0 && time.Since(time.Unix(0, last)) < timeout
+}
+
+func (c *Connection) setJoined(channel string, joined bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.joined == nil {
+		c.joined = make(map[string]bool)
+	}
+	if joined {
+		c.joined[channel] = true
+	} else {
+		delete(c.joined, channel)
+	}
+}
+
+func (c *Connection) resetJoined() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.joined = nil
+}
+
+// Token возвращает текущий токен учетной записи
+func (c *Connection) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.token
+}
+
+// SetToken подменяет токен. IRC-клиент переподключается с новым токеном,
+// Helix и EventSub начинают использовать его со следующего запроса.
+func (c *Connection) SetToken(token string) {
+	c.mu.Lock()
+	c.token = token
+	client := c.client
+	c.mu.Unlock()
+
+	if c.helix != nil {
+		c.helix.SetToken(token)
+	}
+	if irc, ok := client.(*twitch.Client); ok {
+		irc.Disconnect()
+	}
+}
+
+// Client возвращает текущий клиент подключения (меняется при перезапуске)
+func (c *Connection) Client() ChatClient {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.client
+}
+
+// CancelMultipart отменяет недоотправленные многочастные сообщения в канале
+func (c *Connection) CancelMultipart(channel string) int {
+	return c.queue.Cancel(channel)
+}
+
+// Send ставит в очередь сообщения texts одним заданием с паузой gap между ними.
+// Способ доставки и адресат берутся из message.
+func (c *Connection) Send(ctx context.Context, message outgoing, texts []string, gap time.Duration) {
+	c.queue.EnqueueSequence(ctx, message, texts, gap)
+}
+
+// Say ставит сообщение в очередь отправки канала
+func (c *Connection) Say(ctx context.Context, channel, text string) {
+	c.queue.Enqueue(ctx, outgoing{mode: DeliverSay, channel: channel, text: text})
+}
+
+// Reply ставит ответ на сообщение в очередь отправки канала
+func (c *Connection) Reply(ctx context.Context, channel, parentMsgID, text string) {
+	c.queue.Enqueue(ctx, outgoing{mode: DeliverReply, channel: channel, parentID: parentMsgID, text: text})
+}
+
+// Whisper ставит в очередь личное сообщение пользователю. Если доставить его
+// не удастся, оно уйдет ответом на parentMsgID в канал.
+func (c *Connection) Whisper(ctx context.Context, channel, parentMsgID, userID, text string) {
+	c.queue.Enqueue(ctx, outgoing{mode: DeliverWhisper, channel: channel, parentID: parentMsgID, userID: userID, text: text})
+}
+
+// Announce ставит в очередь объявление в канале roomID
+func (c *Connection) Announce(ctx context.Context, channel, roomID, text string) {
+	c.queue.Enqueue(ctx, outgoing{mode: DeliverAnnounce, channel: channel, roomID: roomID, text: text})
+}
+
+// selfID возвращает ID учетной записи бота, запрашивая его у Helix один раз
+func (c *Connection) selfID() (string, error) {
+	c.mu.RLock()
+	id := c.userID
+	c.mu.RUnlock()
+	if id != "" {
+		return id, nil
+	}
+
+	users, err := c.helix.GetUsers()
+	if err != nil {
+		return "", fmt.Errorf("ошибка получения ID бота: %w", err)
+	}
+	if len(users) == 0 {
+		return "", fmt.Errorf("токен не принадлежит ни одному пользователю")
+	}
+
+	c.mu.Lock()
+	c.userID = users[0].ID
+	c.mu.Unlock()
+	return users[0].ID, nil
+}
+
+// sendHelix доставляет личное сообщение или объявление через Helix
+func (c *Connection) sendHelix(item outgoing) error {
+	if c.helix == nil {
+		return fmt.Errorf("не задан TWITCH_CLIENT_ID")
+	}
+	botID, err := c.selfID()
+	if err != nil {
+		return err
+	}
+
+	if item.mode == DeliverWhisper {
+		return c.helix.SendWhisper(botID, item.userID, item.text)
+	}
+	return c.helix.SendAnnouncement(item.roomID, botID, item.text)
+}
+
+// supervise держит подключение живым: при обрыве создает новый клиент
+// и переподключается с экспоненциальной задержкой
+func (c *Connection) supervise(handlers ConnectionHandlers) {
+	backoff := time.Second
+	const maxBackoff = 5 * time.Minute
+
+	for {
+		started := time.Now()
+
+		var err error
+		switch c.transport {
+		case TransportEventSub:
+			err = c.runEventSub(handlers)
+		default:
+			err = c.runIRC(handlers)
+		}
+
+		// Долго проработавшее подключение сбрасывает задержку
+		if time.Since(started) > maxBackoff {
+			backoff = time.Second
+		}
+
+		slog.Error("Подключение разорвано, перезапуск",
+			"bot_username", c.username,
+			"transport", c.transport,
+			"error", err,
+			"retry_in", backoff)
+
+		if errors.Is(err, twitch.ErrLoginAuthenticationFailed) {
+			alerts.Alert(AlertAuth, fmt.Sprintf("Twitch не принял токен %s, бот не может подключиться к чату", c.username))
+		}
+		alerts.Count(AlertReconnect, 5, 10*time.Minute, fmt.Sprintf("Подключение %s постоянно рвется: %v", c.username, err))
+
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func (c *Connection) setClient(client ChatClient) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.client = client
+}
+
+// runIRC подключается через IRC и блокируется до обрыва
+func (c *Connection) runIRC(handlers ConnectionHandlers) error {
+	defer c.resetJoined()
+
+	client := twitch.NewClient(c.username, c.Token())
+	client.SetJoinRateLimiter(c.queue.tier.JoinLimiter())
+
+	// Статус модератора определяет лимиты отправки в канале
+	client.OnUserStateMessage(func(message twitch.UserStateMessage) {
+		c.markAlive()
+		_, broadcaster := message.User.Badges["broadcaster"]
+		c.queue.SetModerator(normalizeChannel(message.Channel), message.User.IsMod || broadcaster)
+	})
+	client.OnPrivateMessage(func(message twitch.PrivateMessage) {
+		c.markAlive()
+		handlers.OnMessage(message)
+	})
+	client.OnConnect(func() {
+		c.markAlive()
+		handlers.OnConnect(c)
+	})
+
+	// Признаки живого соединения для watchdog
+	client.OnPingMessage(func(twitch.PingMessage) { c.markAlive() })
+	client.OnPongMessage(func(twitch.PongMessage) { c.markAlive() })
+	client.OnUserJoinMessage(func(twitch.UserJoinMessage) { c.markAlive() })
+	client.OnUserPartMessage(func(twitch.UserPartMessage) { c.markAlive() })
+	client.OnSelfJoinMessage(func(message twitch.UserJoinMessage) {
+		c.markAlive()
+		c.setJoined(normalizeChannel(message.Channel), true)
+	})
+	client.OnSelfPartMessage(func(message twitch.UserPartMessage) {
+		c.setJoined(normalizeChannel(message.Channel), false)
+	})
+
+	// Таймауты и баны бота: не пишем в канал, где нас не слышно
+	client.OnClearChatMessage(func(message twitch.ClearChatMessage) {
+		c.markAlive()
+		c.handleClearChat(message)
+	})
+	client.OnClearMessage(func(message twitch.ClearMessage) {
+		c.markAlive()
+		c.handleClearMessage(message)
+	})
+	client.OnNoticeMessage(func(message twitch.NoticeMessage) {
+		c.markAlive()
+		c.handleNotice(message)
+	})
+	if handlers.SetupIRC != nil {
+		handlers.SetupIRC(c, client)
+	}
+
+	client.Join(c.channels...)
+	c.setClient(client)
+
+	return client.Connect()
+}
+
+// runEventSub подключается через EventSub WebSocket и блокируется до обрыва
+func (c *Connection) runEventSub(handlers ConnectionHandlers) error {
+	defer c.resetJoined()
+
+	client := NewEventSubClient(c.helix, c.username, c.channels)
+	client.onActivity = c.markAlive
+	c.setClient(client)
+
+	return client.Run(handlers.OnMessage, func() {
+		// Подписки созданы на все каналы подключения
+		for _, channel := range c.channels {
+			c.setJoined(channel, true)
+		}
+		handlers.OnConnect(c)
+	})
+}
+
+// Пул подключений: каждый канал обслуживается ровно одной учетной записью
+type ConnectionPool struct {
+	connections []*Connection
+	byChannel   map[string]*Connection
+}
+
+// NewConnectionPool распределяет каналы по учетным записям. Каналы, явно
+// указанные у учетной записи, закрепляются за ней; остальные из extraChannels
+// раздаются по кругу.
+func NewConnectionPool(accounts []Account, extraChannels []string, options PoolOptions) (*ConnectionPool, error) {
+	pool := &ConnectionPool{
+		byChannel: make(map[string]*Connection),
+	}
+
+	for _, account := range accounts {
+		username := strings.ToLower(strings.TrimSpace(account.Username))
+		token := account.ResolveToken()
+		if username == "" || token == "" {
+			return nil, fmt.Errorf("у учетной записи %q не задано имя или токен", account.Username)
+		}
+
+		tier := options.DefaultTier
+		if account.RateLimitTier != "" {
+			var err error
+			if tier, err = parseRateTier(account.RateLimitTier); err != nil {
+				return nil, fmt.Errorf("учетная запись %s: %w", username, err)
+			}
+		}
+
+		transport := options.DefaultTransport
+		if account.Transport != "" {
+			transport = account.Transport
+		}
+		transport = strings.ToLower(transport)
+		if transport != TransportIRC && transport != TransportEventSub {
+			return nil, fmt.Errorf("учетная запись %s: неизвестный транспорт %q (irc, eventsub)", username, transport)
+		}
+		if transport == TransportEventSub && options.ClientID == "" {
+			return nil, fmt.Errorf("учетная запись %s: для транспорта eventsub нужен TWITCH_CLIENT_ID", username)
+		}
+
+		conn := &Connection{
+			username:  username,
+			token:     token,
+			tokenFile: account.TokenFile,
+			transport: transport,
+			clientID:  options.ClientID,
+		}
+		if options.ClientID != "" {
+			conn.helix = NewHelixClient(options.ClientID, token)
+		}
+		conn.queue = NewSendQueue(conn, tier)
+		for _, channel := range account.Channels {
+			pool.assign(conn, normalizeChannel(channel))
+		}
+		pool.connections = append(pool.connections, conn)
+	}
+
+	if len(pool.connections) == 0 {
+		return nil, fmt.Errorf("не задано ни одной учетной записи бота")
+	}
+
+	next := 0
+	for _, channel := range extraChannels {
+		channel = normalizeChannel(channel)
+		if _, assigned := pool.byChannel[channel]; assigned {
+			continue
+		}
+		pool.assign(pool.connections[next%len(pool.connections)], channel)
+		next++
+	}
+
+	if len(pool.byChannel) == 0 {
+		return nil, fmt.Errorf("не задано ни одного канала")
+	}
+
+	return pool, nil
+}
+
+func (p *ConnectionPool) assign(conn *Connection, channel string) {
+	if owner, exists := p.byChannel[channel]; exists {
+		slog.Warn("Канал уже закреплен за другой учетной записью",
+			"channel", channel,
+			"owner", owner.username,
+			"skipped", conn.username)
+		return
+	}
+	p.byChannel[channel] = conn
+	conn.channels = append(conn.channels, channel)
+}
+
+// For возвращает подключение, обслуживающее канал
+func (p *ConnectionPool) For(channel string) *Connection {
+	return p.byChannel[normalizeChannel(channel)]
+}
+
+// Usernames возвращает имена всех учетных записей бота
+func (p *ConnectionPool) Usernames() []string {
+	names := make([]string, 0, len(p.connections))
+	for _, conn := range p.connections {
+		names = append(names, conn.username)
+	}
+	return names
+}
+
+// IsOwnAccount сообщает, принадлежит ли имя одной из учетных записей бота
+func (p *ConnectionPool) IsOwnAccount(name string) bool {
+	for _, conn := range p.connections {
+		if strings.EqualFold(conn.username, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Channels возвращает все обслуживаемые каналы
+func (p *ConnectionPool) Channels() []string {
+	channels := make([]string, 0, len(p.byChannel))
+	for _, conn := range p.connections {
+		channels = append(channels, conn.channels...)
+	}
+	return channels
+}
+
+// Healthy сообщает, живы ли все подключения, у которых есть каналы
+func (p *ConnectionPool) Healthy(timeout time.Duration) bool {
+	for _, conn := range p.connections {
+		if len(conn.channels) > 0 && !conn.Alive(timeout) {
+			return false
+		}
+	}
+	return true
+}
+
+// JoinedChannels возвращает каналы, в которые бот сейчас вошел
+func (p *ConnectionPool) JoinedChannels() []string {
+	var channels []string
+	for _, conn := range p.connections {
+		conn.mu.RLock()
+		for channel := range conn.joined {
+			channels = append(channels, channel)
+		}
+		conn.mu.RUnlock()
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// Run запускает все подключения под наблюдением супервизора и блокируется
+func (p *ConnectionPool) Run(handlers ConnectionHandlers) {
+	var wg sync.WaitGroup
+	for _, conn := range p.connections {
+		if len(conn.channels) == 0 {
+			slog.Warn("Учетной записи не досталось каналов", "bot_username", conn.username)
+			continue
+		}
+
+		wg.Add(1)
+		go func(conn *Connection) {
+			defer wg.Done()
+			conn.supervise(handlers)
+		}(conn)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,101 @@
+// random.go
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Функции шаблонов со случайным результатом
+var randomFuncs = map[string]templateFunc{
+	"pick":    pickFunc,
+	"roll":    rollFunc,
+	"randint": randintFunc,
+	"shuffle": shuffleFunc,
+}
+
+// {pick "a" "b" "c"} - случайный вариант
+func pickFunc(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("pick без вариантов")
+	}
+	return args[rand.IntN(len(args))], nil
+}
+
+// {randint 1 100} - случайное число от min до max включительно
+func randintFunc(args []string) (string, error) {
+	if len(args) != 2 {
+		return "", fmt.Errorf("ожидается {randint min max}")
+	}
+	lo, err1 := strconv.Atoi(args[0])
+	hi, err2 := strconv.Atoi(args[1])
+	if err1 != nil || err2 != nil || lo > hi {
+		return "", fmt.Errorf("неверные границы randint")
+	}
+	// Ширина диапазона в uint64: hi-lo+1 в int переполняется на больших границах
+	span := uint64(hi) - uint64(lo) + 1
+	if span == 0 {
+		// Весь диапазон int64
+		return strconv.FormatInt(int64(rand.Uint64()), 10), nil
+	}
+	return strconv.Itoa(lo + int(rand.Uint64N(span))), nil
+}
+
+// {shuffle a b c} - аргументы в случайном порядке через пробел
+func shuffleFunc(args []string) (string, error) {
+	shuffled := append([]string(nil), args...)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return strings.Join(shuffled, " "), nil
+}
+
+var diceRe = regexp.MustCompile(`^(\d*)[dдDД](\d+)([+-]\d+)?$`)
+
+// Ограничения броска, чтобы паста не могла занять процессор
+const (
+	maxDice  = 100
+	maxSides = 1000000
+)
+
+// {roll 2d6+1} - сумма броска костей
+func rollFunc(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("ожидается {roll NdM}")
+	}
+	total, err := rollDice(args[0])
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(total), nil
+}
+
+// rollDice бросает кости в нотации NdM(+K), например 1d20 или 3d6-2
+func rollDice(notation string) (int, error) {
+	match := diceRe.FindStringSubmatch(strings.TrimSpace(notation))
+	if match == nil {
+		return 0, fmt.Errorf("неверная нотация броска %q", notation)
+	}
+
+	count := 1
+	if match[1] != "" {
+		count, _ = strconv.Atoi(match[1])
+	}
+	sides, _ := strconv.Atoi(match[2])
+	if count < 1 || count > maxDice || sides < 1 || sides > maxSides {
+		return 0, fmt.Errorf("бросок %q вне допустимых пределов", notation)
+	}
+
+	total := 0
+	for range count {
+		total += 1 + rand.IntN(sides)
+	}
+	if match[3] != "" {
+		modifier, _ := strconv.Atoi(match[3])
+		total += modifier
+	}
+	return total, nil
+}
@@ -0,0 +1,97 @@
+// random_test.go
+package main
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestRandintFunc(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		lo, hi  int
+		wantErr bool
+	}{
+		{"обычный диапазон", []string{"1", "6"}, 1, 6, false},
+		{"одно значение", []string{"5", "5"}, 5, 5, false},
+		{"отрицательные", []string{"-10", "-1"}, -10, -1, false},
+		{"до максимума int", []string{"0", strconv.Itoa(math.MaxInt)}, 0, math.MaxInt, false},
+		{"почти весь диапазон", []string{"-9000000000000000000", "9000000000000000000"}, -9000000000000000000, 9000000000000000000, false},
+		{"весь диапазон", []string{strconv.Itoa(math.MinInt), strconv.Itoa(math.MaxInt)}, math.MinInt, math.MaxInt, false},
+		{"перепутаны границы", []string{"6", "1"}, 0, 0, true},
+		{"не число", []string{"a", "6"}, 0, 0, true},
+		{"вне int", []string{"0", "99999999999999999999"}, 0, 0, true},
+		{"один аргумент", []string{"6"}, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				got, err := randintFunc(tt.args)
+				if tt.wantErr {
+					if err == nil {
+						t.Fatalf("ожидалась ошибка, получено %q", got)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				n, err := strconv.Atoi(got)
+				if err != nil || n < tt.lo || n > tt.hi {
+					t.Fatalf("%q вне [%d, %d]", got, tt.lo, tt.hi)
+				}
+			}
+		})
+	}
+}
+
+func TestRollDice(t *testing.T) {
+	tests := []struct {
+		notation string
+		lo, hi   int
+		wantErr  bool
+	}{
+		{"d6", 1, 6, false},
+		{"1d20", 1, 20, false},
+		{"3d6-2", 1, 16, false},
+		{"2д10+5", 7, 25, false},
+		{"0d6", 0, 0, true},
+		{"101d6", 0, 0, true},
+		{"1d0", 0, 0, true},
+		{"1d1000001", 0, 0, true},
+		{"99999999999999999999d6", 0, 0, true},
+		{"кубик", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.notation, func(t *testing.T) {
+			for range 100 {
+				got, err := rollDice(tt.notation)
+				if tt.wantErr {
+					if err == nil {
+						t.Fatalf("ожидалась ошибка, получено %d", got)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got < tt.lo || got > tt.hi {
+					t.Fatalf("%d вне [%d, %d]", got, tt.lo, tt.hi)
+				}
+			}
+		})
+	}
+}
+
+func TestPickFunc(t *testing.T) {
+	if _, err := pickFunc(nil); err == nil {
+		t.Error("pick без вариантов должен возвращать ошибку")
+	}
+	if got, err := pickFunc([]string{"один"}); err != nil || got != "один" {
+		t.Errorf("получено %q, %v", got, err)
+	}
+}
@@ -0,0 +1,97 @@
+// recent.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Сколько последних выполнений команд помнить в памяти
+const recentCommandsSize = 100
+
+// Последние выполненные команды, включая встроенные, для !последние
+type RecentCommands struct {
+	mu      sync.Mutex
+	records []UsageRecord
+	next    int
+}
+
+func NewRecentCommands() *RecentCommands {
+	return &RecentCommands{records: make([]UsageRecord, 0, recentCommandsSize)}
+}
+
+// Record запоминает выполнение команды, вытесняя самое старое
+func (r *RecentCommands) Record(rec UsageRecord) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.records) < recentCommandsSize {
+		r.records = append(r.records, rec)
+		return
+	}
+	r.records[r.next] = rec
+	r.next = (r.next + 1) % recentCommandsSize
+}
+
+// Last возвращает до limit последних выполнений в канале, новые первыми
+func (r *RecentCommands) Last(channel string, limit int) []UsageRecord {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	channel = normalizeChannel(channel)
+	var last []UsageRecord
+	for i := 0; i < len(r.records) && len(last) < limit; i++ {
+		// Обход от самой новой записи к самой старой
+		rec := r.records[(r.next-1-i+2*len(r.records))%len(r.records)]
+		if normalizeChannel(rec.Channel) == channel {
+			last = append(last, rec)
+		}
+	}
+	return last
+}
+
+// recentText формирует ответ на !последние [N]. После перезапуска, пока в
+// памяти пусто, берет пасты из журнала использования.
+func (b *Bot) recentText(message twitch.PrivateMessage, args []string) string {
+	limit := 5
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			return "Использование: !последние [количество]"
+		}
+		limit = min(n, 10)
+	}
+
+	records := b.recent.Last(message.Channel, limit)
+	if len(records) == 0 && b.usage != nil {
+		var err error
+		records, err = b.usage.Query(UsageFilter{Channel: normalizeChannel(message.Channel), Limit: limit})
+		if err != nil {
+			slog.Error("Ошибка выборки статистики", "error", err)
+			return "Не удалось получить историю команд"
+		}
+	}
+	if len(records) == 0 {
+		return "Команды еще не вызывались"
+	}
+
+	location := b.Config().LocationFor(message.Channel)
+	parts := make([]string, 0, len(records))
+	for _, rec := range records {
+		parts = append(parts, fmt.Sprintf("%s %s (%s)", rec.Time.In(location).Format("15:04:05"), rec.Command, rec.User))
+	}
+	return "Последние команды: " + strings.Join(parts, ", ")
+}
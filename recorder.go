@@ -0,0 +1,92 @@
+// recorder.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Запись одного входящего сообщения в NDJSON-файле
+type RecordedMessage struct {
+	ReceivedAt time.Time             `json:"received_at"`
+	Message    twitch.PrivateMessage `json:"message"`
+}
+
+// Записывает все входящие сообщения чата (с тегами и исходной строкой IRC)
+// в файлы chat-YYYY-MM-DD.ndjson, чтобы потом прогнать их через replay
+type ChatRecorder struct {
+	dir string
+
+	mu   sync.Mutex
+	day  string
+	file *os.File
+	enc  *json.Encoder
+}
+
+func NewChatRecorder(dir string) (*ChatRecorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("ошибка создания каталога записи чата %s: %w", dir, err)
+	}
+	return &ChatRecorder{dir: dir}, nil
+}
+
+// Record дописывает сообщение в файл текущего дня
+func (r *ChatRecorder) Record(receivedAt time.Time, message twitch.PrivateMessage) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.rotateLocked(receivedAt); err != nil {
+		slog.Error("Ошибка записи чата", "error", err)
+		return
+	}
+	if err := r.enc.Encode(RecordedMessage{ReceivedAt: receivedAt, Message: message}); err != nil {
+		slog.Error("Ошибка записи чата", "error", err)
+	}
+}
+
+// rotateLocked открывает файл нового дня при смене даты
+func (r *ChatRecorder) rotateLocked(now time.Time) error {
+	day := now.Format("2006-01-02")
+	if r.file != nil && r.day == day {
+		return nil
+	}
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+
+	path := filepath.Join(r.dir, "chat-"+day+".ndjson")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия файла %s: %w", path, err)
+	}
+	r.day, r.file, r.enc = day, file, json.NewEncoder(file)
+	return nil
+}
+
+func (r *ChatRecorder) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
@@ -0,0 +1,207 @@
+// redis.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Таймаут одного запроса к Redis
+const redisTimeout = 2 * time.Second
+
+// Общее состояние нескольких экземпляров бота в Redis: cooldown, переменные
+// и окна подавления повторов. Очков у бота нет, поэтому хранить их не нужно.
+type RedisState struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisState подключается к Redis по адресу вида redis://host:6379/0.
+// Все ключи начинаются с prefix, чтобы разные боты могли делить один Redis.
+func NewRedisState(url, prefix string) (*RedisState, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("неверный REDIS_URL: %w", err)
+	}
+
+	client := redis.NewClient(options)
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("ошибка подключения к Redis: %w", err)
+	}
+
+	return &RedisState{client: client, prefix: prefix}, nil
+}
+
+func (r *RedisState) Close() error {
+	return r.client.Close()
+}
+
+func (r *RedisState) key(parts ...string) string {
+	return r.prefix + strings.Join(parts, ":")
+}
+
+func redisContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), redisTimeout)
+}
+
+// LastUsed возвращает время последнего ответа любого экземпляра в канале
+func (r *RedisState) LastUsed(channel string) (time.Time, error) {
+	ctx, cancel := redisContext()
+	defer cancel()
+
+	ms, err := r.client.Get(ctx, r.key("cooldown", channel)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ошибка чтения cooldown из Redis: %w", err)
+	}
+	return time.UnixMilli(ms), nil
+}
+
+// MarkUsed запоминает время ответа в канале. Ключ живет не дольше ttl.
+func (r *RedisState) MarkUsed(channel string, at time.Time, ttl time.Duration) error {
+	ctx, cancel := redisContext()
+	defer cancel()
+
+	if err := r.client.Set(ctx, r.key("cooldown", channel), at.UnixMilli(), ttl).Err(); err != nil {
+		return fmt.Errorf("ошибка записи cooldown в Redis: %w", err)
+	}
+	return nil
+}
+
+// Hit учитывает вызов в окне и возвращает его номер. Первый вызов открывает
+// окно длиной ttl.
+func (r *RedisState) Hit(key string, ttl time.Duration) (int64, error) {
+	ctx, cancel := redisContext()
+	defer cancel()
+
+	redisKey := r.key("dedup", key)
+	count, err := r.client.Incr(ctx, redisKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка учета повтора в Redis: %w", err)
+	}
+	if count == 1 {
+		if err := r.client.PExpire(ctx, redisKey, ttl).Err(); err != nil {
+			return 0, fmt.Errorf("ошибка учета повтора в Redis: %w", err)
+		}
+	}
+	return count, nil
+}
+
+// Take возвращает число вызовов в окне и закрывает его
+func (r *RedisState) Take(key string) (int64, error) {
+	ctx, cancel := redisContext()
+	defer cancel()
+
+	count, err := r.client.GetDel(ctx, r.key("dedup", key)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения повторов из Redis: %w", err)
+	}
+	return count, nil
+}
+
+// Open сообщает, открыто ли окно повторов
+func (r *RedisState) Open(key string) (bool, error) {
+	ctx, cancel := redisContext()
+	defer cancel()
+
+	count, err := r.client.Exists(ctx, r.key("dedup", key)).Result()
+	if err != nil {
+		return false, fmt.Errorf("ошибка чтения повторов из Redis: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Variables возвращает переменные {var}, хранящиеся в Redis
+func (r *RedisState) Variables() *RedisVariables {
+	return &RedisVariables{state: r}
+}
+
+// Переменные в хэше Redis; общие для всех экземпляров
+type RedisVariables struct {
+	state *RedisState
+}
+
+func (v *RedisVariables) key() string {
+	return v.state.key("variables")
+}
+
+func (v *RedisVariables) Get(name string) (string, bool) {
+	ctx, cancel := redisContext()
+	defer cancel()
+
+	value, err := v.state.client.HGet(ctx, v.key(), strings.ToLower(name)).Result()
+	return value, err == nil
+}
+
+func (v *RedisVariables) Names() []string {
+	ctx, cancel := redisContext()
+	defer cancel()
+
+	names, err := v.state.client.HKeys(ctx, v.key()).Result()
+	if err != nil {
+		return nil
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (v *RedisVariables) Set(name, value string) error {
+	name = strings.ToLower(name)
+	if !validVariableName(name) {
+		return fmt.Errorf("неверное имя переменной %q", name)
+	}
+
+	ctx, cancel := redisContext()
+	defer cancel()
+
+	if err := v.state.client.HSet(ctx, v.key(), name, value).Err(); err != nil {
+		return fmt.Errorf("ошибка сохранения переменной %s: %w", name, err)
+	}
+	return nil
+}
+
+func (v *RedisVariables) Add(name string, delta int) (int, error) {
+	name = strings.ToLower(name)
+	if !validVariableName(name) {
+		return 0, fmt.Errorf("неверное имя переменной %q", name)
+	}
+
+	ctx, cancel := redisContext()
+	defer cancel()
+
+	// HINCRBY атомарен, поэтому счетчики не теряют вызовы с разных экземпляров
+	value, err := v.state.client.HIncrBy(ctx, v.key(), name, int64(delta)).Result()
+	if err != nil {
+		if strings.Contains(err.Error(), "not an integer") {
+			return 0, fmt.Errorf("переменная %s не число", name)
+		}
+		return 0, fmt.Errorf("ошибка сохранения переменной %s: %w", name, err)
+	}
+	return int(value), nil
+}
+
+func (v *RedisVariables) Delete(name string) error {
+	name = strings.ToLower(name)
+
+	ctx, cancel := redisContext()
+	defer cancel()
+
+	if err := v.state.client.HDel(ctx, v.key(), name).Err(); err != nil {
+		return fmt.Errorf("ошибка удаления переменной %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+// reload.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Переменные окружения логирования, которые перечитываются из .env при перезагрузке
+var reloadableEnv = []string{"LOG_LEVEL", "LOG_FILE"}
+
+// Изменения набора команд при перезагрузке
+type CommandsDiff struct {
+	Added    []string
+	Removed  []string
+	Modified []string
+}
+
+func (d CommandsDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+func (d CommandsDiff) String() string {
+	return fmt.Sprintf("добавлено: %d, удалено: %d, изменено: %d", len(d.Added), len(d.Removed), len(d.Modified))
+}
+
+// diffCommands сравнивает два набора команд
+func diffCommands(before, after map[string]*Command) CommandsDiff {
+	var diff CommandsDiff
+
+	for name, cmd := range after {
+		old, exists := before[name]
+		switch {
+		case !exists:
+			diff.Added = append(diff.Added, name)
+		case !sameCommand(old, cmd):
+			diff.Modified = append(diff.Modified, name)
+		}
+	}
+	for name := range before {
+		if _, exists := after[name]; !exists {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+	return diff
+}
+
+// sameCommand сравнивает команды по их представлению в YAML
+func sameCommand(a, b *Command) bool {
+	left, errA := yaml.Marshal(a)
+	right, errB := yaml.Marshal(b)
+	return errA == nil && errB == nil && string(left) == string(right)
+}
+
+// watchReloadSignal перезагружает конфигурацию по SIGHUP
+func (b *Bot) watchReloadSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		slog.Info("Получен SIGHUP, перезагрузка конфигурации")
+		if err := b.reload("sighup"); err != nil {
+			slog.Error("Ошибка перезагрузки, продолжаем со старой конфигурацией", "error", err)
+		}
+	}
+}
+
+// reload перечитывает commands.yaml, config.yaml и настройки логирования без
+// переподключения к чату. При ошибке текущая конфигурация сохраняется.
+func (b *Bot) reload(actor string) error {
+	commands, err := loadEffectiveCommands(b.commandsFile, b.store)
+	if err != nil {
+		return err
+	}
+
+	config, err := loadConfig(b.configFile)
+	if err != nil {
+		return err
+	}
+
+	reloadLogging()
+
+	b.mu.Lock()
+	oldCommands, oldConfig := b.commands, b.config
+	b.setCommandsLocked(commands)
+	b.config = config
+	b.mu.Unlock()
+
+	diff := diffCommands(oldCommands, commands)
+	for _, name := range diff.Added {
+		b.audit.Record(actor, AuditCommandAdd, name, "")
+	}
+	for _, name := range diff.Removed {
+		b.audit.Record(actor, AuditCommandDelete, name, "")
+	}
+	for _, name := range diff.Modified {
+		b.audit.Record(actor, AuditCommandEdit, name, "")
+	}
+	b.audit.Record(actor, AuditReload, b.commandsFile, diff.String())
+
+	slog.Info("Конфигурация перезагружена",
+		"commands", len(commands),
+		"added", strings.Join(diff.Added, ", "),
+		"removed", strings.Join(diff.Removed, ", "),
+		"modified", strings.Join(diff.Modified, ", "))
+
+	if b.checkEmoteRefs {
+		b.checkEmotes()
+	}
+
+	// Учетные записи применяются только при запуске
+	if !reflect.DeepEqual(oldConfig.Accounts, config.Accounts) {
+		slog.Warn("Изменения учетных записей в config.yaml вступят в силу после перезапуска")
+	}
+
+	return nil
+}
+
+// reloadLogging перечитывает настройки логирования из .env и перенастраивает логгер
+func reloadLogging() {
+	env, err := godotenv.Read()
+	if err == nil {
+		for _, key := range reloadableEnv {
+			if value, ok := env[key]; ok {
+				os.Setenv(key, value)
+			}
+		}
+	}
+
+	setupLogging()
+}
@@ -0,0 +1,197 @@
+// replay.go
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// cliReplay: replay [-realtime] [-no-cooldown] [-channel канал] файл.ndjson...
+// Прогоняет записанные сообщения через обработку без подключения к Twitch:
+// ответы печатаются в stdout, причины молчания видны в отладочном логе (stderr).
+func cliReplay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	realtime := flags.Bool("realtime", false, "выдерживать паузы между сообщениями, как при записи")
+	noCooldown := flags.Bool("no-cooldown", false, "отключить глобальный cooldown")
+	channel := flags.String("channel", "", "только сообщения этого канала")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("использование: replay [-realtime] [-no-cooldown] [-channel канал] файл.ndjson...")
+	}
+
+	var records []RecordedMessage
+	for _, path := range flags.Args() {
+		loaded, err := readRecording(path)
+		if err != nil {
+			return err
+		}
+		records = append(records, loaded...)
+	}
+	if *channel != "" {
+		filtered := records[:0]
+		for _, record := range records {
+			if normalizeChannel(record.Message.Channel) == normalizeChannel(*channel) {
+				filtered = append(filtered, record)
+			}
+		}
+		records = filtered
+	}
+
+	seen := make(map[string]bool)
+	var channels []string
+	for _, record := range records {
+		if name := normalizeChannel(record.Message.Channel); !seen[name] {
+			seen[name] = true
+			channels = append(channels, name)
+		}
+	}
+	if len(channels) == 0 {
+		return fmt.Errorf("в записи нет сообщений")
+	}
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	bot, wait, err := replayBot(os.Stdout, channels, *noCooldown)
+	if err != nil {
+		return err
+	}
+
+	var previous time.Time
+	for _, record := range records {
+		if *realtime && !previous.IsZero() {
+			time.Sleep(record.ReceivedAt.Sub(previous))
+		}
+		previous = record.ReceivedAt
+
+		message := record.Message
+		fmt.Printf("%s #%s %s: %s\n", record.ReceivedAt.Format("15:04:05"), message.Channel, message.User.Name, message.Message)
+		bot.processMessage(message, time.Now())
+	}
+
+	// Отложенные ответы (задержка команды, подавление повторов) приходят позже
+	time.Sleep(wait)
+	return nil
+}
+
+// readRecording читает файл, записанный ChatRecorder
+func readRecording(path string) ([]RecordedMessage, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия записи %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var records []RecordedMessage
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var record RecordedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("ошибка разбора %s:%d: %w", path, line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения записи %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// replayBot создает бота с настройками из окружения, который ничего не отправляет
+// и ничего не меняет: без базы, аудита и статистики, переменные - в памяти.
+// Возвращает также время, за которое должны прийти отложенные ответы.
+func replayBot(out io.Writer, channels []string, noCooldown bool) (*Bot, time.Duration, error) {
+	configFile := getEnv("CONFIG_FILE", "config.yaml")
+	config, err := loadConfig(configFile)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Команды из базы только читаются
+	var store CommandStorage
+	storage, err := storageFromEnv(true)
+	if err != nil {
+		return nil, 0, err
+	}
+	if storage != nil {
+		defer storage.Close()
+		if store, err = storage.Commands(); err != nil {
+			return nil, 0, err
+		}
+	}
+	commandsFile := getEnv("COMMANDS_FILE", "commands.yaml")
+	commands, err := loadEffectiveCommands(commandsFile, store)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	usernames := getEnvList("TWITCH_BOT_USERNAME")
+	for _, account := range config.Accounts {
+		usernames = append(usernames, account.Username)
+	}
+	if len(usernames) == 0 {
+		usernames = []string{"replay_bot"}
+	}
+
+	// Helix только для чтения фолловинга и смайлов: у подключений его нет,
+	// поэтому модерация и отправка через API в прогоне не выполняются
+	var helix *HelixClient
+	if clientID, token := getEnv("TWITCH_CLIENT_ID", ""), getSecret("TWITCH_OAUTH_TOKEN"); clientID != "" && token != "" {
+		helix = NewHelixClient(clientID, token)
+	}
+
+	variables, _ := NewVariableStore(nil)
+	bot, err := newBot(botBackends{
+		pool:         newDryRunPool(usernames, channels, out),
+		helix:        helix,
+		config:       config,
+		configFile:   configFile,
+		commands:     commands,
+		commandsFile: commandsFile,
+		variables:    variables,
+		cooldown:     time.Duration(getEnvInt("COOLDOWN_SECONDS", 15)) * time.Second,
+		noCooldown:   noCooldown,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Задержки ответов выдерживаются, поэтому ждем самую долгую
+	var wait, longest time.Duration
+	if window := getEnvInt("DUPLICATE_WINDOW_SECONDS", 0); window > 0 {
+		wait = time.Duration(window) * time.Second
+	}
+	for _, command := range commands {
+		delay := bot.responseDelay(command)
+		longest = max(longest, delay.Base+delay.Jitter)
+	}
+	return bot, wait + longest + time.Second, nil
+}
+
+// newDryRunPool создает подключения, которые печатают исходящие сообщения в out
+// вместо отправки в Twitch
+func newDryRunPool(usernames, channels []string, out io.Writer) *ConnectionPool {
+	pool := &ConnectionPool{byChannel: make(map[string]*Connection)}
+	for _, username := range usernames {
+		conn := &Connection{username: strings.ToLower(username), transport: TransportIRC}
+		conn.queue = NewSendQueue(conn, rateTiers["normal"])
+		conn.queue.dryRun = out
+		pool.connections = append(pool.connections, conn)
+	}
+	for _, channel := range channels {
+		pool.assign(pool.connections[0], channel)
+	}
+	return pool
+}
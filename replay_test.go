@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Прогон записи собирается так же, как бот в работе: с ограничителем ответов
+// и адаптивным cooldown из окружения
+func TestReplayBotSettings(t *testing.T) {
+	dir := t.TempDir()
+	commandsFile := filepath.Join(dir, "commands.yaml")
+	if err := os.WriteFile(commandsFile, []byte("messages:\n  - command: \"!паста\"\n    text: текст\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", filepath.Join(dir, "config.yaml"))
+	t.Setenv("COMMANDS_FILE", commandsFile)
+	t.Setenv("DATABASE_FILE", "")
+	t.Setenv("TWITCH_CLIENT_ID", "")
+	t.Setenv("COOLDOWN_MODE", "adaptive")
+	t.Setenv("MAX_RESPONSES_PER_MINUTE", "5")
+
+	bot, _, err := replayBot(io.Discard, []string{"channel"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bot.breaker == nil {
+		t.Error("нет ограничителя ответов MAX_RESPONSES_PER_MINUTE")
+	}
+	if bot.cooldown.adaptive == nil || bot.activity == nil {
+		t.Error("COOLDOWN_MODE=adaptive не применен")
+	}
+
+	// -no-cooldown выключает и адаптивный cooldown
+	bot, _, err = replayBot(io.Discard, []string{"channel"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bot.cooldown.adaptive != nil || bot.cooldown.Duration("channel") != 0 {
+		t.Errorf("cooldown при -no-cooldown: %v", bot.cooldown.Duration("channel"))
+	}
+
+	t.Setenv("COOLDOWN_MODE", "bursty")
+	if _, _, err := replayBot(io.Discard, []string{"channel"}, false); err == nil {
+		t.Error("принят неизвестный COOLDOWN_MODE")
+	}
+}
@@ -0,0 +1,115 @@
+// schedule.go
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Расписание доступности команды
+type Schedule struct {
+	start    int // минуты от начала суток
+	end      int
+	days     map[time.Weekday]bool
+	location *time.Location
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday, "thu": time.Thursday,
+	"fri": time.Friday, "sat": time.Saturday, "sun": time.Sunday,
+	"пн": time.Monday, "вт": time.Tuesday, "ср": time.Wednesday, "чт": time.Thursday,
+	"пт": time.Friday, "сб": time.Saturday, "вс": time.Sunday,
+}
+
+// parseSchedule собирает расписание из полей команды.
+// Возвращает nil, если команда доступна всегда.
+func parseSchedule(onlyBetween string, days []string, timezone string) (*Schedule, error) {
+	if onlyBetween == "" && len(days) == 0 {
+		return nil, nil
+	}
+
+	schedule := &Schedule{
+		start:    0,
+		end:      24 * 60,
+		location: time.Local,
+	}
+
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("неизвестный часовой пояс %q: %w", timezone, err)
+		}
+		schedule.location = loc
+	}
+
+	if onlyBetween != "" {
+		from, to, ok := strings.Cut(onlyBetween, "-")
+		if !ok {
+			return nil, fmt.Errorf("неверный формат only_between %q, ожидается ЧЧ:ММ-ЧЧ:ММ", onlyBetween)
+		}
+
+		var err error
+		if schedule.start, err = parseClock(from); err != nil {
+			return nil, err
+		}
+		if schedule.end, err = parseClock(to); err != nil {
+			return nil, err
+		}
+		// Такое окно пустое и команда никогда не была бы доступна
+		if schedule.start == schedule.end {
+			return nil, fmt.Errorf("в only_between %q начало совпадает с концом; чтобы команда работала весь день, уберите only_between", onlyBetween)
+		}
+	}
+
+	if len(days) > 0 {
+		schedule.days = make(map[time.Weekday]bool)
+		for _, day := range days {
+			weekday, ok := weekdayNames[strings.ToLower(strings.TrimSpace(day))]
+			if !ok {
+				return nil, fmt.Errorf("неизвестный день недели %q", day)
+			}
+			schedule.days[weekday] = true
+		}
+	}
+
+	return schedule, nil
+}
+
+// parseClock разбирает время ЧЧ:ММ в минуты от начала суток
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("неверное время %q, ожидается ЧЧ:ММ", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Active сообщает, доступна ли команда в момент now.
+// Окно через полночь ("22:00-02:00") относится к дню, в который оно началось.
+func (s *Schedule) Active(now time.Time) bool {
+	if s == nil {
+		return true
+	}
+
+	local := now.In(s.location)
+	minutes := local.Hour()*60 + local.Minute()
+	day := local.Weekday()
+
+	if s.start <= s.end {
+		return minutes >= s.start && minutes < s.end && s.dayAllowed(day)
+	}
+
+	// Окно через полночь
+	if minutes >= s.start {
+		return s.dayAllowed(day)
+	}
+	if minutes < s.end {
+		return s.dayAllowed((day + 6) % 7)
+	}
+	return false
+}
+
+func (s *Schedule) dayAllowed(day time.Weekday) bool {
+	return s.days == nil || s.days[day]
+}
@@ -0,0 +1,82 @@
+// schedule_test.go
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		name        string
+		onlyBetween string
+		days        []string
+		timezone    string
+		wantNil     bool
+		wantErr     bool
+	}{
+		{"без ограничений", "", nil, "", true, false},
+		{"окно", "18:00-23:00", nil, "", false, false},
+		{"через полночь", "22:00-02:00", nil, "", false, false},
+		{"только дни", "", []string{"Пн", " sat "}, "", false, false},
+		{"пустое окно", "12:00-12:00", nil, "", false, true},
+		{"полночь до полночи", "00:00-00:00", nil, "", false, true},
+		{"без дефиса", "18:00", nil, "", false, true},
+		{"неверное время", "25:00-26:00", nil, "", false, true},
+		{"неизвестный день", "", []string{"funday"}, "", false, true},
+		{"неизвестный пояс", "10:00-11:00", nil, "Mars/Olympus", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseSchedule(tt.onlyBetween, tt.days, tt.timezone)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ошибка = %v, ожидалась %v", err, tt.wantErr)
+			}
+			if err == nil && (schedule == nil) != tt.wantNil {
+				t.Errorf("schedule = %+v", schedule)
+			}
+		})
+	}
+}
+
+func TestScheduleActive(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		// 2026-01-05 - понедельник
+		return time.Date(2026, 1, 5+day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name        string
+		onlyBetween string
+		days        []string
+		now         time.Time
+		want        bool
+	}{
+		{"внутри окна", "18:00-23:00", nil, at(0, 20, 0), true},
+		{"начало окна", "18:00-23:00", nil, at(0, 18, 0), true},
+		{"конец окна не входит", "18:00-23:00", nil, at(0, 23, 0), false},
+		{"до окна", "18:00-23:00", nil, at(0, 9, 0), false},
+		{"через полночь вечером", "22:00-02:00", nil, at(0, 23, 30), true},
+		{"через полночь ночью", "22:00-02:00", nil, at(1, 1, 0), true},
+		{"через полночь днем", "22:00-02:00", nil, at(0, 12, 0), false},
+		{"день разрешен", "", []string{"mon"}, at(0, 12, 0), true},
+		{"день запрещен", "", []string{"mon"}, at(1, 12, 0), false},
+		{"ночь после разрешенного дня", "22:00-02:00", []string{"fri"}, at(5, 1, 0), true},
+		{"ночь после запрещенного дня", "22:00-02:00", []string{"fri"}, at(4, 1, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseSchedule(tt.onlyBetween, tt.days, "UTC")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Active(tt.now); got != tt.want {
+				t.Errorf("Active(%s) = %v, ожидалось %v", tt.now.Format("Mon 15:04"), got, tt.want)
+			}
+		})
+	}
+
+	var always *Schedule
+	if !always.Active(time.Now()) {
+		t.Error("nil-расписание должно быть активно всегда")
+	}
+}
@@ -0,0 +1,161 @@
+// scheduled.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Паста по расписанию из config.yaml
+type ScheduledPaste struct {
+	Channel string `yaml:"channel"`
+	Cron    string `yaml:"cron"`
+	// Часовой пояс cron (по умолчанию - часовой пояс канала)
+	Timezone string `yaml:"timezone,omitempty"`
+	// Команда, текст которой отправить; пусто - случайная доступная паста
+	Command string `yaml:"command,omitempty"`
+	// Не отправлять, пока стрим не идет (нужен TWITCH_CLIENT_ID)
+	OnlyWhenLive bool `yaml:"only_when_live,omitempty"`
+
+	cron *CronExpr
+}
+
+// prepare проверяет расписание
+func (s *ScheduledPaste) prepare() error {
+	s.Channel = normalizeChannel(s.Channel)
+	if s.Channel == "" {
+		return fmt.Errorf("у расписания не указан канал")
+	}
+
+	cron, err := parseCron(s.Cron)
+	if err != nil {
+		return err
+	}
+	s.cron = cron
+
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("неверный часовой пояс расписания: %w", err)
+	}
+	return nil
+}
+
+// runScheduledPastes раз в минуту отправляет пасты, время которых наступило.
+// Расписания берутся из текущей конфигурации, поэтому подхватываются при перезагрузке.
+func (b *Bot) runScheduledPastes(helix *HelixClient) {
+	for {
+		// Ждем начала следующей минуты
+		now := time.Now()
+		time.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+
+		minute := time.Now().Truncate(time.Minute)
+		config := b.Config()
+		for i := range config.Schedules {
+			schedule := &config.Schedules[i]
+
+			location := config.LocationFor(schedule.Channel)
+			if schedule.Timezone != "" {
+				location, _ = time.LoadLocation(schedule.Timezone)
+			}
+			if !schedule.cron.Matches(minute.In(location)) {
+				continue
+			}
+
+			go b.postScheduled(schedule, helix)
+		}
+	}
+}
+
+func (b *Bot) postScheduled(schedule *ScheduledPaste, helix *HelixClient) {
+	if schedule.OnlyWhenLive {
+		if helix == nil {
+			slog.Warn("only_when_live требует TWITCH_CLIENT_ID, паста пропущена", "channel", schedule.Channel)
+			return
+		}
+		live, err := helix.IsLive(schedule.Channel)
+		if err != nil {
+			slog.Warn("Ошибка проверки стрима", "error", err, "channel", schedule.Channel)
+			return
+		}
+		if !live {
+			slog.Debug("Стрим не идет, паста по расписанию пропущена", "channel", schedule.Channel)
+			return
+		}
+	}
+
+	if paused, _ := b.pause.Paused(); paused {
+		return
+	}
+	// Пока стример отсутствует или идет пробный запуск, бот сам в чат не пишет
+	if away, _ := b.away.Active(time.Now()); away {
+		slog.Debug("Режим отсутствия, паста по расписанию пропущена", "channel", schedule.Channel)
+		return
+	}
+	if b.softLaunch.Enabled() {
+		slog.Debug("Пробный запуск, паста по расписанию пропущена", "channel", schedule.Channel)
+		return
+	}
+
+	name, command := b.scheduledCommand(schedule.Channel, schedule.Command)
+	if command == nil {
+		slog.Warn("Нет пасты для расписания", "channel", schedule.Channel, "command", schedule.Command)
+		return
+	}
+
+	conn := b.pool.For(schedule.Channel)
+	if conn == nil {
+		slog.Warn("Нет подключения для канала", "channel", schedule.Channel)
+		return
+	}
+	if !b.breaker.Allow(schedule.Channel) {
+		return
+	}
+
+	// Паста выводится от имени бота, как будто он сам ее вызвал
+	message := twitch.PrivateMessage{Channel: schedule.Channel, User: twitch.User{Name: conn.username}}
+	var texts []string
+	for _, text := range command.Messages() {
+		texts = append(texts, b.renderPaste(message, nil, text))
+	}
+	conn.Send(withSendPriority(context.Background(), PriorityPaste), outgoing{mode: DeliverSay, channel: schedule.Channel}, texts, command.SequenceDelay())
+	b.streamUses.Use(schedule.Channel, command)
+
+	slog.Info("Паста по расписанию отправлена", "channel", schedule.Channel, "command", name)
+}
+
+// scheduledCommand возвращает команду по имени или случайную подходящую.
+// Случайно выбираются только обычные текстовые пасты без ограничения доступа,
+// которые можно вызвать сейчас.
+func (b *Bot) scheduledCommand(channel, name string) (string, *Command) {
+	commands := b.Commands()
+	now := time.Now()
+
+	postable := func(command *Command) bool {
+		return command.Available(now) && !command.IsModeration() && !b.streamUses.Exhausted(channel, command)
+	}
+
+	if name != "" {
+		if command, ok := commands[name]; ok && postable(command) {
+			return name, command
+		}
+		return name, nil
+	}
+
+	var names []string
+	for name, command := range commands {
+		if postable(command) && command.Requires == "" && len(command.Parts) == 0 && command.Text != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+	sort.Strings(names)
+	name = names[rand.IntN(len(names))]
+	return name, commands[name]
+}
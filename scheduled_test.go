@@ -0,0 +1,51 @@
+// scheduled_test.go
+package main
+
+import "testing"
+
+func TestScheduledCommandCandidates(t *testing.T) {
+	exhausted := &Command{Command: "!раз", Text: "один раз за стрим", MaxUsesPerStream: 1}
+	b := &Bot{
+		streamUses: NewStreamUses(),
+		commands: map[string]*Command{
+			"!привет":  {Command: "!привет", Text: "всем привет"},
+			"!таймаут": {Command: "!таймаут", Text: "{target} отдыхает", Type: CommandTypeModeration, Action: ModerationTimeout},
+			"!серия":   {Command: "!серия", Text: "раз два", Parts: []string{"раз", "два"}},
+			"!саб":     {Command: "!саб", Text: "для сабов", Requires: "subscriber"},
+			"!выкл":    {Command: "!выкл", Text: "выключена", Disabled: true},
+			"!пусто":   {Command: "!пусто"},
+			"!раз":     exhausted,
+		},
+	}
+	b.streamUses.Use("channel", exhausted)
+
+	for range 200 {
+		name, command := b.scheduledCommand("channel", "")
+		if name != "!привет" || command == nil {
+			t.Fatalf("случайно выбрана %q", name)
+		}
+	}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"!привет", true},
+		{"!серия", true},
+		{"!саб", true},
+		{"!таймаут", false},
+		{"!выкл", false},
+		{"!раз", false},
+		{"!нет", false},
+	}
+	for _, tt := range tests {
+		if _, command := b.scheduledCommand("channel", tt.name); (command != nil) != tt.want {
+			t.Errorf("%s: выбрана %v, ожидалось %v", tt.name, command != nil, tt.want)
+		}
+	}
+
+	// В другом канале лимит не исчерпан
+	if _, command := b.scheduledCommand("other", "!раз"); command == nil {
+		t.Error("лимит одного канала действует в другом")
+	}
+}
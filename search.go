@@ -0,0 +1,61 @@
+// search.go
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// normalizeSearch приводит текст к виду для поиска: нижний регистр, ё -> е,
+// пунктуация заменяется пробелами
+func normalizeSearch(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if r == 'ё' {
+			return 'е'
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return ' '
+		}
+		return r
+	}, text), unicode.IsSpace), " ")
+}
+
+// searchCommands возвращает отсортированные имена доступных команд,
+// в имени или тексте которых встречается term
+func searchCommands(commands map[string]*Command, term string, now time.Time) []string {
+	needle := normalizeSearch(term)
+	if needle == "" {
+		return nil
+	}
+
+	var found []string
+	for name, cmd := range commands {
+		if !cmd.Available(now) {
+			continue
+		}
+		if strings.Contains(normalizeSearch(name), needle) || strings.Contains(normalizeSearch(cmd.Text), needle) {
+			found = append(found, name)
+		}
+	}
+	sort.Strings(found)
+	return found
+}
+
+// searchText формирует ответ для !найти <слово>, укладываясь в лимит длины сообщения
+func (b *Bot) searchText(user string, args []string) string {
+	if len(args) == 0 {
+		return "Использование: !найти <слово>"
+	}
+
+	term := strings.Join(args, " ")
+	found := searchCommands(b.Commands(), term, time.Now())
+	if len(found) == 0 {
+		return fmt.Sprintf("@%s, по запросу «%s» ничего не найдено", user, term)
+	}
+
+	return joinLimited(fmt.Sprintf("@%s, найдено: ", user), found, maxMessageLength)
+}
@@ -0,0 +1,170 @@
+// secrets.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// getSecret возвращает значение переменной окружения key или, если она не
+// задана, содержимое файла из key_FILE (секреты Docker и Kubernetes)
+func getSecret(key string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	file := os.Getenv(key + "_FILE")
+	if file == "" {
+		return ""
+	}
+	value, err := readSecretFile(file)
+	if err != nil {
+		slog.Warn("Ошибка чтения секрета", "key", key, "error", err)
+		return ""
+	}
+	return value
+}
+
+// readSecretFile читает секрет из файла без пробельных символов по краям
+func readSecretFile(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения файла %s: %w", file, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// loadExternalSecrets подставляет в окружение секреты из файла, зашифрованного
+// SOPS, и из Vault. Уже заданные переменные окружения не перезаписываются.
+func loadExternalSecrets() error {
+	if file := os.Getenv("SOPS_SECRETS_FILE"); file != "" {
+		values, err := decryptSOPS(file)
+		if err != nil {
+			return err
+		}
+		applySecrets("sops", values)
+	}
+
+	if addr := os.Getenv("VAULT_ADDR"); addr != "" {
+		path := os.Getenv("VAULT_SECRET_PATH")
+		if path == "" {
+			return fmt.Errorf("VAULT_ADDR задан, но VAULT_SECRET_PATH пуст")
+		}
+		values, err := readVault(addr, getSecret("VAULT_TOKEN"), path)
+		if err != nil {
+			return err
+		}
+		applySecrets("vault", values)
+	}
+	return nil
+}
+
+func applySecrets(source string, values map[string]string) {
+	applied := 0
+	for key, value := range values {
+		if os.Getenv(key) != "" {
+			continue
+		}
+		os.Setenv(key, value)
+		applied++
+	}
+	slog.Info("Секреты загружены", "source", source, "count", applied)
+}
+
+// decryptSOPS расшифровывает файл утилитой sops. Файл - плоский YAML, JSON
+// или dotenv с парами ИМЯ: значение.
+func decryptSOPS(file string) (map[string]string, error) {
+	cmd := exec.Command("sops", "--decrypt", "--output-type", "json", file)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка расшифровки %s через sops: %w", file, err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("ошибка разбора расшифрованного %s: %w", file, err)
+	}
+	return flattenSecrets(raw), nil
+}
+
+// readVault читает секрет KV (версии 1 или 2) из Vault
+func readVault(addr, token, path string) (map[string]string, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания запроса Vault: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса Vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault вернул статус %d для %s", resp.StatusCode, path)
+	}
+
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("ошибка разбора ответа Vault: %w", err)
+	}
+
+	// В KV v2 значения лежат в data.data рядом с data.metadata
+	data := body.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, v2 := data["metadata"]; v2 {
+			data = nested
+		}
+	}
+	return flattenSecrets(data), nil
+}
+
+// flattenSecrets оставляет строковые и числовые значения верхнего уровня
+func flattenSecrets(raw map[string]any) map[string]string {
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		case float64, bool:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return values
+}
+
+// WatchTokenFiles перечитывает файлы токенов раз в interval и подменяет токен
+// подключения, если файл изменился. onChange вызывается после подмены.
+func (p *ConnectionPool) WatchTokenFiles(interval time.Duration, onChange func(conn *Connection)) {
+	for {
+		time.Sleep(interval)
+
+		for _, conn := range p.connections {
+			if conn.tokenFile == "" {
+				continue
+			}
+			token, err := readSecretFile(conn.tokenFile)
+			if err != nil {
+				slog.Warn("Ошибка чтения файла токена", "bot_username", conn.username, "error", err)
+				continue
+			}
+			if token == "" || token == conn.Token() {
+				continue
+			}
+
+			conn.SetToken(token)
+			slog.Info("Токен обновлен из файла", "bot_username", conn.username)
+			onChange(conn)
+		}
+	}
+}
@@ -0,0 +1,551 @@
+// sendqueue.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Максимальная длина сообщения в чате Twitch
+const maxMessageLength = 500
+
+// Окно, в котором Twitch считает лимит сообщений
+const messageRateWindow = 30 * time.Second
+
+// Уровень лимитов учетной записи
+type RateTier struct {
+	Name string
+	// Сообщений за 30 секунд в каналах, где бот не модератор
+	Messages int
+	// Сообщений за 30 секунд в каналах, где бот модератор или владелец
+	ModMessages int
+	// Минимальный интервал между сообщениями в одном канале, где бот не модератор
+	ChannelInterval time.Duration
+	// Лимитер JOIN для go-twitch-irc
+	JoinLimiter func() twitch.RateLimiter
+}
+
+var rateTiers = map[string]RateTier{
+	"normal": {
+		Name:            "normal",
+		Messages:        20,
+		ModMessages:     100,
+		ChannelInterval: time.Second,
+		JoinLimiter:     func() twitch.RateLimiter { return twitch.CreateDefaultRateLimiter() },
+	},
+	"known": {
+		Name:            "known",
+		Messages:        50,
+		ModMessages:     100,
+		ChannelInterval: time.Second,
+		JoinLimiter:     func() twitch.RateLimiter { return twitch.CreateDefaultRateLimiter() },
+	},
+	"verified": {
+		Name:            "verified",
+		Messages:        7500,
+		ModMessages:     7500,
+		ChannelInterval: time.Second,
+		JoinLimiter:     func() twitch.RateLimiter { return twitch.CreateVerifiedRateLimiter() },
+	},
+}
+
+func parseRateTier(name string) (RateTier, error) {
+	tier, ok := rateTiers[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return RateTier{}, fmt.Errorf("неизвестный уровень лимитов %q (normal, known, verified)", name)
+	}
+	return tier, nil
+}
+
+// Способы доставки ответа
+const (
+	DeliverSay      = "say"
+	DeliverMention  = "mention"
+	DeliverReply    = "reply"
+	DeliverWhisper  = "whisper"
+	DeliverAnnounce = "announce"
+)
+
+var deliveryModes = map[string]bool{
+	DeliverSay:      true,
+	DeliverMention:  true,
+	DeliverReply:    true,
+	DeliverWhisper:  true,
+	DeliverAnnounce: true,
+}
+
+// Классы сообщений: очередь отправляет сначала более важные
+const (
+	// Ответы на служебные команды модераторов
+	PrioritySystem = iota
+	// Реакции на события чата и системные уведомления
+	PriorityEvent
+	// Пасты
+	PriorityPaste
+
+	priorityClasses
+)
+
+// Максимум сообщений, ожидающих отправки
+const sendQueueSize = 100
+
+type priorityKey struct{}
+
+// withSendPriority помечает ответы, отправленные в рамках ctx, классом priority
+func withSendPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// sendPriority возвращает класс из ctx; по умолчанию PriorityEvent
+func sendPriority(ctx context.Context) int {
+	if priority, ok := ctx.Value(priorityKey{}).(int); ok {
+		return priority
+	}
+	return PriorityEvent
+}
+
+// Исходящее сообщение в очереди
+type outgoing struct {
+	priority int
+	// say, reply, whisper или announce (mention отправляется как say)
+	mode     string
+	channel  string
+	parentID string
+	// ID получателя личного сообщения
+	userID string
+	// ID канала для Helix (announce)
+	roomID string
+	text   string
+	// Спан обработки входящего сообщения, к которому относится отправка
+	span trace.SpanContext
+	// Для метрик задержки: команда, получение сообщения и постановка в очередь
+	origin     latencyOrigin
+	enqueuedAt time.Time
+	// Пауза перед отправкой: между сообщениями последовательности
+	pause time.Duration
+}
+
+// Сообщение, разбитое на части. Части отправляются подряд: между ними не
+// вклиниваются другие сообщения в тот же канал (кроме служебных ответов
+// модераторам), а оставшиеся можно отменить. Пока задание выдерживает паузу
+// последовательности, очередь отправляет сообщения в другие каналы.
+type sendJob struct {
+	channel   string
+	priority  int
+	parts     []outgoing
+	cancelled atomic.Bool
+	// Сколько частей уже отправлено и когда можно отправлять следующую
+	sent      int
+	notBefore time.Time
+}
+
+// Очередь исходящих сообщений одной учетной записи с соблюдением лимитов Twitch
+type SendQueue struct {
+	conn *Connection
+	tier RateTier
+
+	pendingMu sync.Mutex
+	pendingCh chan struct{}
+	pending   [priorityClasses][]*sendJob
+	// Начатые задания по каналам: до их окончания в канал идут только служебные ответы
+	started map[string]*sendJob
+
+	mu       sync.Mutex
+	window   []time.Time
+	lastSent map[string]time.Time
+	mods     map[string]bool
+	// Каналы, где бот в таймауте или бане, и время, до которого не пишем
+	suspended map[string]time.Time
+
+	// Если задан, сообщения не отправляются, а печатаются сюда (replay)
+	dryRun io.Writer
+}
+
+func NewSendQueue(conn *Connection, tier RateTier) *SendQueue {
+	q := &SendQueue{
+		conn:      conn,
+		tier:      tier,
+		pendingCh: make(chan struct{}, 1),
+		started:   make(map[string]*sendJob),
+		lastSent:  make(map[string]time.Time),
+		mods:      make(map[string]bool),
+		suspended: make(map[string]time.Time),
+	}
+	go q.run()
+	return q
+}
+
+// Enqueue ставит сообщение в очередь, разбивая его на части по лимиту длины.
+// Ответом (reply) отправляется только первая часть.
+func (q *SendQueue) Enqueue(ctx context.Context, message outgoing) {
+	q.EnqueueSequence(ctx, message, []string{message.text}, 0)
+}
+
+// EnqueueSequence ставит в очередь последовательность сообщений одним заданием:
+// части других ответов между ними не вклиниваются. Перед каждым сообщением,
+// кроме первого, выдерживается пауза gap.
+func (q *SendQueue) EnqueueSequence(ctx context.Context, message outgoing, texts []string, gap time.Duration) {
+	message.span = trace.SpanContextFromContext(ctx)
+	message.priority = sendPriority(ctx)
+	message.origin = latencyOriginFrom(ctx)
+	message.enqueuedAt = time.Now()
+
+	job := &sendJob{channel: message.channel, priority: message.priority}
+	for n, text := range texts {
+		for i, part := range splitMessage(text, maxMessageLength) {
+			item := message
+			item.text = part
+			if len(job.parts) > 0 {
+				// Ответом отправляется только первая часть, задержка ответа считается по ней же
+				if item.mode == DeliverReply {
+					item.mode = DeliverSay
+					item.parentID = ""
+				}
+				item.origin = latencyOrigin{}
+			}
+			if n > 0 && i == 0 {
+				item.pause = gap
+			}
+			job.parts = append(job.parts, item)
+		}
+	}
+
+	if q.dryRun != nil {
+		for _, item := range job.parts {
+			fmt.Fprintf(q.dryRun, "  -> [%s] %s: %s\n", item.channel, item.mode, item.text)
+			item.sent()
+		}
+		return
+	}
+
+	q.pendingMu.Lock()
+	defer q.pendingMu.Unlock()
+
+	if free := sendQueueSize - q.depthLocked(); len(job.parts) > free {
+		slog.Warn("Очередь отправки переполнена, сообщение отброшено",
+			"bot_username", q.conn.username,
+			"channel", message.channel)
+		if free <= 0 {
+			return
+		}
+		job.parts = job.parts[:free]
+	}
+	q.pending[message.priority] = append(q.pending[message.priority], job)
+
+	select {
+	case q.pendingCh <- struct{}{}:
+	default:
+	}
+}
+
+// next выбирает часть для отправки: из самого важного задания, которое можно
+// продолжить сейчас. Если все ждут паузы последовательности, возвращает
+// время до ближайшей из них.
+func (q *SendQueue) next() (*sendJob, outgoing, time.Duration) {
+	q.pendingMu.Lock()
+	defer q.pendingMu.Unlock()
+
+	now := time.Now()
+	var wait time.Duration
+	for _, jobs := range q.pending {
+		for _, job := range jobs {
+			if busy := q.started[job.channel]; busy != nil && busy != job && job.priority != PrioritySystem {
+				continue
+			}
+			if delay := job.notBefore.Sub(now); delay > 0 {
+				if wait == 0 || delay < wait {
+					wait = delay
+				}
+				continue
+			}
+			return job, job.parts[job.sent], 0
+		}
+	}
+	return nil, outgoing{}, wait
+}
+
+// done отмечает отправку очередной части задания и назначает время следующей
+func (q *SendQueue) done(job *sendJob) {
+	q.pendingMu.Lock()
+	defer q.pendingMu.Unlock()
+
+	job.sent++
+	if job.sent < len(job.parts) && !job.cancelled.Load() {
+		job.notBefore = time.Now().Add(job.parts[job.sent].pause)
+		q.started[job.channel] = job
+		return
+	}
+	q.removeLocked(job)
+}
+
+// removeLocked убирает задание из очереди
+func (q *SendQueue) removeLocked(job *sendJob) {
+	jobs := q.pending[job.priority]
+	for i, pending := range jobs {
+		if pending == job {
+			q.pending[job.priority] = append(jobs[:i:i], jobs[i+1:]...)
+			break
+		}
+	}
+	if q.started[job.channel] == job {
+		delete(q.started, job.channel)
+	}
+}
+
+// Depth возвращает число частей сообщений, ожидающих отправки
+func (q *SendQueue) Depth() int {
+	q.pendingMu.Lock()
+	defer q.pendingMu.Unlock()
+
+	return q.depthLocked()
+}
+
+func (q *SendQueue) depthLocked() int {
+	depth := 0
+	for _, jobs := range q.pending {
+		for _, job := range jobs {
+			depth += len(job.parts) - job.sent
+		}
+	}
+	return depth
+}
+
+// Cancel отменяет оставшиеся части многочастных сообщений в канале: и
+// начатых, и ожидающих. Возвращает число отмененных сообщений.
+func (q *SendQueue) Cancel(channel string) int {
+	q.pendingMu.Lock()
+	defer q.pendingMu.Unlock()
+
+	cancelled := 0
+	for priority, jobs := range q.pending {
+		kept := jobs[:0]
+		for _, job := range jobs {
+			if job.channel == channel && len(job.parts) > 1 {
+				if !job.cancelled.Swap(true) {
+					cancelled++
+				}
+				continue
+			}
+			kept = append(kept, job)
+		}
+		q.pending[priority] = kept
+	}
+	if job := q.started[channel]; job != nil && job.cancelled.Load() {
+		delete(q.started, channel)
+	}
+	return cancelled
+}
+
+// SetModerator запоминает, является ли бот модератором в канале
+func (q *SendQueue) SetModerator(channel string, mod bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.mods[channel] != mod {
+		slog.Info("Статус модератора бота изменился",
+			"bot_username", q.conn.username,
+			"channel", channel,
+			"moderator", mod)
+	}
+	q.mods[channel] = mod
+}
+
+// Suspend запрещает отправку в канал до until
+func (q *SendQueue) Suspend(channel string, until time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.suspended[channel] = until
+}
+
+// Suspended сообщает, приостановлена ли отправка в канал
+func (q *SendQueue) Suspended(channel string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	until, ok := q.suspended[channel]
+	if ok && time.Now().After(until) {
+		delete(q.suspended, channel)
+		slog.Info("Отправка в канал возобновлена", "bot_username", q.conn.username, "channel", channel)
+		return false
+	}
+	return ok
+}
+
+func (q *SendQueue) run() {
+	for {
+		job, part, wait := q.next()
+		if job == nil {
+			// Ждем новое сообщение или окончание ближайшей паузы последовательности
+			if wait <= 0 {
+				<-q.pendingCh
+				continue
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-q.pendingCh:
+			case <-timer.C:
+			}
+			timer.Stop()
+			continue
+		}
+
+		q.send(part)
+		q.done(job)
+		if job.cancelled.Load() && job.sent < len(job.parts) {
+			slog.Debug("Отправка оставшихся частей отменена", "channel", job.channel)
+		}
+	}
+}
+
+func (q *SendQueue) send(item outgoing) {
+	ctx := trace.ContextWithSpanContext(context.Background(), item.span)
+	_, span := tracer.Start(ctx, "send", trace.WithAttributes(
+		attribute.String("mode", item.mode),
+		attribute.Int("priority", item.priority),
+		attribute.String("channel", item.channel),
+		attribute.String("bot_username", q.conn.username),
+		attribute.Int("length", len([]rune(item.text))),
+	))
+	defer span.End()
+
+	// Личные сообщения не зависят от таймаута в канале
+	if item.mode != DeliverWhisper && q.Suspended(item.channel) {
+		slog.Debug("Бот в таймауте, сообщение отброшено", "channel", item.channel)
+		span.SetAttributes(attribute.Bool("suspended", true))
+		item.failed("бот в таймауте или бане")
+		return
+	}
+
+	waited := q.wait(item.channel)
+	span.SetAttributes(attribute.Int64("rate_limit_wait_ms", waited.Milliseconds()))
+
+	switch item.mode {
+	case DeliverWhisper, DeliverAnnounce:
+		err := q.conn.sendHelix(item)
+		if err == nil {
+			item.sent()
+			return
+		}
+		// Без Helix или при ошибке отвечаем в чат, чтобы ответ не потерялся
+		slog.Warn("Ошибка доставки через Helix, ответ отправлен в чат",
+			"mode", item.mode,
+			"channel", item.channel,
+			"error", err)
+		span.RecordError(err)
+	}
+
+	client := q.conn.Client()
+	if client == nil {
+		slog.Warn("Нет активного клиента, сообщение отброшено", "channel", item.channel)
+		item.failed("нет подключения")
+		return
+	}
+
+	if item.parentID != "" {
+		client.Reply(item.channel, item.parentID, item.text)
+	} else {
+		client.Say(item.channel, item.text)
+	}
+	item.sent()
+}
+
+// sent учитывает задержку отправленного ответа на команду
+func (item outgoing) sent() {
+	if item.origin.command == "" {
+		return
+	}
+	now := time.Now()
+	latencyMetrics.Observe(item.origin.command, LatencyTotal, now.Sub(item.origin.receivedAt))
+	latencyMetrics.Observe(item.origin.command, LatencyQueue, now.Sub(item.enqueuedAt))
+	if item.origin.onSent != nil {
+		item.origin.onSent(now.Sub(item.origin.receivedAt))
+	}
+}
+
+// failed учитывает сообщение, которое не удалось отправить
+func (item outgoing) failed(reason string) {
+	if item.origin.command != "" {
+		latencyMetrics.Error(item.origin.command)
+	}
+	alerts.Count(AlertSendFailure, 5, 5*time.Minute, fmt.Sprintf("Сообщения в #%s не отправляются: %s", item.channel, reason))
+}
+
+// wait блокируется, пока отправка в канал не уложится в лимиты, и резервирует слот.
+// Возвращает время ожидания.
+func (q *SendQueue) wait(channel string) time.Duration {
+	started := time.Now()
+	for {
+		q.mu.Lock()
+		now := time.Now()
+
+		// Убираем из окна устаревшие отправки
+		fresh := q.window[:0]
+		for _, t := range q.window {
+			if now.Sub(t) < messageRateWindow {
+				fresh = append(fresh, t)
+			}
+		}
+		q.window = fresh
+
+		mod := q.mods[channel]
+		limit := q.tier.Messages
+		if mod {
+			limit = q.tier.ModMessages
+		}
+
+		var delay time.Duration
+		if len(q.window) >= limit {
+			delay = messageRateWindow - now.Sub(q.window[0])
+		}
+		if !mod {
+			if since := now.Sub(q.lastSent[channel]); since < q.tier.ChannelInterval {
+				delay = max(delay, q.tier.ChannelInterval-since)
+			}
+		}
+
+		if delay <= 0 {
+			q.window = append(q.window, now)
+			q.lastSent[channel] = now
+			q.mu.Unlock()
+			return time.Since(started)
+		}
+		q.mu.Unlock()
+
+		time.Sleep(delay)
+	}
+}
+
+// splitMessage разбивает текст на части не длиннее limit символов по границам слов
+func splitMessage(text string, limit int) []string {
+	var parts []string
+	runes := []rune(strings.TrimSpace(text))
+
+	for len(runes) > limit {
+		cut := limit
+		for i := limit; i > limit/2; i-- {
+			if runes[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+
+		parts = append(parts, strings.TrimSpace(string(runes[:cut])))
+		runes = []rune(strings.TrimSpace(string(runes[cut:])))
+	}
+
+	if len(runes) > 0 {
+		parts = append(parts, string(runes))
+	}
+	return parts
+}
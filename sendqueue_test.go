@@ -0,0 +1,112 @@
+// sendqueue_test.go
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{"короткое", "привет чат", 20, []string{"привет чат"}},
+		{"ровно лимит", "абвгд", 5, []string{"абвгд"}},
+		{"пустое", "   ", 10, nil},
+		{"по пробелу", "раз два три четыре", 10, []string{"раз два", "три четыре"}},
+		{"пробел в первой половине не годится", "раз два три четыре", 9, []string{"раз два", "три четыр", "е"}},
+		{"длинное слово режется", "абвгдежзик", 4, []string{"абвг", "дежз", "ик"}},
+		{"пробел слишком рано", "а бвгдежзик", 6, []string{"а бвгд", "ежзик"}},
+		{"лишние пробелы", "  раз   два  ", 5, []string{"раз", "два"}},
+	}
+	for _, tt := range tests {
+		if got := splitMessage(tt.text, tt.limit); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: splitMessage(%q, %d) = %q, ожидалось %q", tt.name, tt.text, tt.limit, got, tt.want)
+		}
+	}
+}
+
+// Части не длиннее лимита в символах и вместе дают исходный текст
+func TestSplitMessageLimit(t *testing.T) {
+	text := strings.Repeat("слово ", 200) + strings.Repeat("ы", 700)
+	parts := splitMessage(text, 500)
+	for i, part := range parts {
+		if n := utf8.RuneCountInString(part); n > 500 || n == 0 {
+			t.Errorf("часть %d длиной %d", i, n)
+		}
+	}
+	if joined := strings.Join(parts, ""); strings.ReplaceAll(joined, " ", "") != strings.ReplaceAll(text, " ", "") {
+		t.Error("текст потерян при разбиении")
+	}
+}
+
+// Пауза последовательности не задерживает служебные ответы и другие каналы,
+// а другие ответы в тот же канал ждут ее окончания
+func TestSendQueueSequenceGap(t *testing.T) {
+	q := &SendQueue{conn: &Connection{}, pendingCh: make(chan struct{}, 1), started: make(map[string]*sendJob)}
+	paste := withSendPriority(context.Background(), PriorityPaste)
+
+	q.EnqueueSequence(paste, outgoing{mode: DeliverSay, channel: "один"}, []string{"раз", "два"}, time.Hour)
+	job, part, _ := q.next()
+	if part.text != "раз" {
+		t.Fatalf("первая часть %q", part.text)
+	}
+	q.done(job)
+
+	q.Enqueue(paste, outgoing{mode: DeliverSay, channel: "один", text: "другая паста"})
+	q.Enqueue(paste, outgoing{mode: DeliverSay, channel: "два", text: "паста в другом канале"})
+	q.Enqueue(withSendPriority(context.Background(), PrioritySystem), outgoing{mode: DeliverSay, channel: "один", text: "бот на паузе"})
+
+	var sent []string
+	for {
+		job, part, wait := q.next()
+		if job == nil {
+			if wait < 59*time.Minute {
+				t.Errorf("ожидание паузы %v, ожидался почти час", wait)
+			}
+			break
+		}
+		sent = append(sent, part.text)
+		q.done(job)
+	}
+	if want := []string{"бот на паузе", "паста в другом канале"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("во время паузы отправлено %q, ожидалось %q", sent, want)
+	}
+	if q.Depth() != 2 {
+		t.Errorf("в очереди %d частей, ожидалось 2", q.Depth())
+	}
+
+	// После паузы последовательность доигрывается, и только потом идет другая паста
+	job.notBefore = time.Now()
+	for _, want := range []string{"два", "другая паста"} {
+		next, part, _ := q.next()
+		if next == nil || part.text != want {
+			t.Fatalf("отправлено %q, ожидалось %q", part.text, want)
+		}
+		q.done(next)
+	}
+}
+
+func TestSendQueueCancelStarted(t *testing.T) {
+	q := &SendQueue{conn: &Connection{}, pendingCh: make(chan struct{}, 1), started: make(map[string]*sendJob)}
+	paste := withSendPriority(context.Background(), PriorityPaste)
+
+	q.EnqueueSequence(paste, outgoing{mode: DeliverSay, channel: "один"}, []string{"раз", "два", "три"}, time.Hour)
+	job, _, _ := q.next()
+	q.done(job)
+
+	if n := q.Cancel("один"); n != 1 {
+		t.Errorf("отменено %d, ожидалось 1", n)
+	}
+	q.Enqueue(paste, outgoing{mode: DeliverSay, channel: "один", text: "новая"})
+	if _, part, _ := q.next(); part.text != "новая" {
+		t.Errorf("после отмены отправлено %q", part.text)
+	}
+}
@@ -0,0 +1,82 @@
+// sequence.go
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Пауза между сообщениями последовательности, если не задан sequence_delay_ms
+const defaultSequenceDelay = 1500 * time.Millisecond
+
+// UnmarshalYAML разрешает задавать text списком: сообщения последовательности
+// отправляются по порядку с паузой sequence_delay_ms
+func (c *Command) UnmarshalYAML(node *yaml.Node) error {
+	type plain Command
+
+	var parts []string
+	stripped := *node
+	stripped.Content = nil
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value == "text" && value.Kind == yaml.SequenceNode {
+			if err := value.Decode(&parts); err != nil {
+				return fmt.Errorf("text должен быть строкой или списком строк: %w", err)
+			}
+			continue
+		}
+		stripped.Content = append(stripped.Content, key, value)
+	}
+
+	if err := stripped.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	if parts != nil {
+		c.Parts = parts
+		c.Text = strings.Join(parts, " ")
+	}
+	return nil
+}
+
+// MarshalYAML сохраняет последовательность обратно списком
+func (c Command) MarshalYAML() (any, error) {
+	type plain Command
+
+	var node yaml.Node
+	if err := node.Encode(plain(c)); err != nil {
+		return nil, err
+	}
+	if len(c.Parts) == 0 {
+		return &node, nil
+	}
+
+	var parts yaml.Node
+	if err := parts.Encode(c.Parts); err != nil {
+		return nil, err
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "text" {
+			node.Content[i+1] = &parts
+		}
+	}
+	return &node, nil
+}
+
+// Messages возвращает сообщения пасты: одно или последовательность
+func (c *Command) Messages() []string {
+	if len(c.Parts) > 0 {
+		return c.Parts
+	}
+	return []string{c.Text}
+}
+
+// SequenceDelay возвращает паузу между сообщениями последовательности
+func (c *Command) SequenceDelay() time.Duration {
+	if c.SequenceDelayMs != nil {
+		return time.Duration(*c.SequenceDelayMs) * time.Millisecond
+	}
+	return defaultSequenceDelay
+}
@@ -0,0 +1,244 @@
+// session.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Рейд, полученный во время стрима
+type Raid struct {
+	From    string `json:"from"`
+	Viewers int    `json:"viewers"`
+}
+
+// Статистика одного стрима
+type StreamSession struct {
+	Channel  string         `json:"channel"`
+	Started  time.Time      `json:"started"`
+	Commands map[string]int `json:"commands"`
+	Users    map[string]int `json:"users"`
+	Raids    []Raid         `json:"raids"`
+}
+
+// Отслеживание стримов: начало и конец определяются опросом Helix. Пока
+// стрим идет, копится статистика, по окончании выводится сводка.
+type SessionTracker struct {
+	helix    *HelixClient
+	channels []string
+
+	mu       sync.Mutex
+	sessions map[string]*StreamSession
+	onStart  []func(channel string)
+	onEnd    []func(session *StreamSession)
+}
+
+func NewSessionTracker(helix *HelixClient, channels []string) *SessionTracker {
+	normalized := make([]string, len(channels))
+	for i, channel := range channels {
+		normalized[i] = normalizeChannel(channel)
+	}
+	return &SessionTracker{
+		helix:    helix,
+		channels: normalized,
+		sessions: make(map[string]*StreamSession),
+	}
+}
+
+// OnStart добавляет обработчик начала стрима: сброс состояния на один стрим
+func (t *SessionTracker) OnStart(fn func(channel string)) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onStart = append(t.onStart, fn)
+}
+
+// OnEnd добавляет обработчик окончания стрима
+func (t *SessionTracker) OnEnd(fn func(session *StreamSession)) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onEnd = append(t.onEnd, fn)
+}
+
+// Run опрашивает статус стримов с интервалом interval
+func (t *SessionTracker) Run(interval time.Duration) {
+	for {
+		for _, channel := range t.channels {
+			live, err := t.helix.IsLive(channel)
+			if err != nil {
+				slog.Warn("Ошибка проверки статуса стрима", "error", err, "channel", channel)
+				continue
+			}
+			t.update(channel, live, time.Now())
+		}
+		time.Sleep(interval)
+	}
+}
+
+// update начинает или завершает сессию канала по статусу стрима
+func (t *SessionTracker) update(channel string, live bool, now time.Time) {
+	t.mu.Lock()
+	session, active := t.sessions[channel]
+	switch {
+	case live && !active:
+		t.sessions[channel] = &StreamSession{
+			Channel:  channel,
+			Started:  now,
+			Commands: make(map[string]int),
+			Users:    make(map[string]int),
+		}
+		handlers := t.onStart
+		t.mu.Unlock()
+
+		slog.Info("Стрим начался", "channel", channel)
+		for _, fn := range handlers {
+			fn(channel)
+		}
+
+	case !live && active:
+		delete(t.sessions, channel)
+		handlers := t.onEnd
+		t.mu.Unlock()
+
+		slog.Info("Стрим закончился",
+			"channel", channel,
+			"duration", now.Sub(session.Started).Round(time.Minute),
+			"summary", session.Summary(now))
+		for _, fn := range handlers {
+			fn(session)
+		}
+
+	default:
+		t.mu.Unlock()
+	}
+}
+
+// RecordCommand учитывает вызов команды во время стрима
+func (t *SessionTracker) RecordCommand(channel, user, command string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if session, ok := t.sessions[normalizeChannel(channel)]; ok {
+		session.Commands[command]++
+		session.Users[strings.ToLower(user)]++
+	}
+}
+
+// RecordRaid учитывает рейд во время стрима
+func (t *SessionTracker) RecordRaid(channel, from string, viewers int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if session, ok := t.sessions[normalizeChannel(channel)]; ok {
+		session.Raids = append(session.Raids, Raid{From: from, Viewers: viewers})
+	}
+}
+
+// Live сообщает, идет ли стрим в канале по последнему опросу
+func (t *SessionTracker) Live(channel string) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, ok := t.sessions[normalizeChannel(channel)]
+	return ok
+}
+
+// Summary формирует сводку стрима для чата
+func (s *StreamSession) Summary(now time.Time) string {
+	total := 0
+	for _, count := range s.Commands {
+		total += count
+	}
+
+	parts := []string{fmt.Sprintf("Стрим длился %s", formatSessionDuration(now.Sub(s.Started)))}
+	if total == 0 {
+		parts = append(parts, "команд не было")
+	} else {
+		parts = append(parts,
+			fmt.Sprintf("команд %d (чаще всего: %s)", total, topCounts(s.Commands, 3)),
+			fmt.Sprintf("пользовались ботом %d (активнее всех: %s)", len(s.Users), topCounts(s.Users, 3)))
+	}
+	if len(s.Raids) > 0 {
+		raids := make([]string, len(s.Raids))
+		for i, raid := range s.Raids {
+			raids[i] = fmt.Sprintf("%s (%d)", raid.From, raid.Viewers)
+		}
+		parts = append(parts, "рейды: "+strings.Join(raids, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func formatSessionDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%d мин", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%d ч %d мин", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// topCounts возвращает до limit самых частых ключей в виде "a 3, b 2"
+func topCounts(counts map[string]int, limit int) string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	items := make([]string, len(keys))
+	for i, key := range keys {
+		items[i] = fmt.Sprintf("%s %d", key, counts[key])
+	}
+	return strings.Join(items, ", ")
+}
+
+// handleRaid учитывает входящий рейд из IRC
+func (b *Bot) handleRaid(message twitch.UserNoticeMessage) {
+	if message.MsgID != "raid" {
+		return
+	}
+	viewers, _ := strconv.Atoi(message.MsgParams["msg-param-viewerCount"])
+	from := message.MsgParams["msg-param-displayName"]
+	if from == "" {
+		from = message.User.Name
+	}
+	b.sessions.RecordRaid(message.Channel, from, viewers)
+}
+
+// postSessionSummary отправляет сводку стрима в чат канала
+func (b *Bot) postSessionSummary(session *StreamSession) {
+	conn := b.pool.For(session.Channel)
+	if conn == nil {
+		return
+	}
+	conn.Say(withSendPriority(context.Background(), PriorityEvent), session.Channel, session.Summary(time.Now()))
+}
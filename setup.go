@@ -0,0 +1,288 @@
+// setup.go
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+const (
+	twitchDeviceURL   = "https://id.twitch.tv/oauth2/device"
+	twitchTokenURL    = "https://id.twitch.tv/oauth2/token"
+	twitchValidateURL = "https://id.twitch.tv/oauth2/validate"
+)
+
+// Права токена: без первых двух бот не может работать в чате, остальные
+// нужны для отдельных возможностей
+var (
+	requiredScopes = []string{"chat:read", "chat:edit"}
+	optionalScopes = []string{
+		"user:read:chat", "user:write:chat", "user:manage:whispers",
+		"moderator:manage:announcements", "moderator:read:followers",
+		"moderator:manage:banned_users", "moderator:manage:warnings", "moderator:manage:chat_messages",
+		"moderator:manage:shoutouts",
+	}
+)
+
+// Команды для нового commands.yaml
+const starterCommands = `messages:
+  - command: "!ping"
+    text: pong
+    description: Проверка, что бот жив
+  - command: "!привет"
+    text: "Привет, {user}!"
+    description: Поздороваться
+`
+
+// cliSetup: setup [-client-id ID] [-env .env] [-commands commands.yaml] [-force]
+func cliSetup(args []string) error {
+	flags := flag.NewFlagSet("setup", flag.ContinueOnError)
+	clientID := flags.String("client-id", getEnv("TWITCH_CLIENT_ID", ""), "Client ID приложения Twitch (тип Public)")
+	envFile := flags.String("env", ".env", "куда записать настройки")
+	commandsFile := flags.String("commands", getEnv("COMMANDS_FILE", "commands.yaml"), "куда записать стартовые команды")
+	force := flags.Bool("force", false, "перезаписать существующие файлы")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if !*force {
+		if _, err := os.Stat(*envFile); err == nil {
+			return fmt.Errorf("%s уже существует, используйте -force для перезаписи", *envFile)
+		}
+	}
+
+	input := bufio.NewReader(os.Stdin)
+	if *clientID == "" {
+		fmt.Println("Зарегистрируйте приложение на https://dev.twitch.tv/console/apps (тип клиента Public)")
+		*clientID = prompt(input, "Client ID: ")
+		if *clientID == "" {
+			return fmt.Errorf("client id не указан")
+		}
+	}
+
+	fmt.Println("Войдите в Twitch под учетной записью бота.")
+	token, err := deviceCodeLogin(*clientID, append(requiredScopes, optionalScopes...))
+	if err != nil {
+		return err
+	}
+
+	validation, err := validateToken(token.AccessToken)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Токен получен для %s\n", validation.Login)
+	granted := make(map[string]bool, len(validation.Scopes))
+	for _, scope := range validation.Scopes {
+		granted[scope] = true
+	}
+	for _, scope := range requiredScopes {
+		if !granted[scope] {
+			return fmt.Errorf("у токена нет обязательного права %s", scope)
+		}
+	}
+	for _, scope := range optionalScopes {
+		if !granted[scope] {
+			fmt.Printf("Предупреждение: нет права %s, часть возможностей будет недоступна\n", scope)
+		}
+	}
+
+	channel := normalizeChannel(prompt(input, "Канал, в котором будет работать бот: "))
+	if channel == "" {
+		return fmt.Errorf("канал не указан")
+	}
+
+	fmt.Printf("Проверяем подключение к #%s...\n", channel)
+	if err := testJoin(validation.Login, token.AccessToken, channel, 15*time.Second); err != nil {
+		return err
+	}
+	fmt.Println("Подключение работает")
+
+	env := fmt.Sprintf(`TWITCH_BOT_USERNAME=%q
+TWITCH_OAUTH_TOKEN=%q
+TWITCH_CLIENT_ID=%q
+TWITCH_CHANNEL=%q
+MENTION_ONLY=false
+COMMANDS_FILE=%q
+`, validation.Login, "oauth:"+token.AccessToken, *clientID, channel, *commandsFile)
+	if err := os.WriteFile(*envFile, []byte(env), 0o600); err != nil {
+		return fmt.Errorf("ошибка записи %s: %w", *envFile, err)
+	}
+	fmt.Println("Настройки записаны в", *envFile)
+
+	if _, err := os.Stat(*commandsFile); err == nil && !*force {
+		fmt.Println(*commandsFile, "уже существует, оставлен без изменений")
+	} else {
+		if err := os.WriteFile(*commandsFile, []byte(starterCommands), 0o644); err != nil {
+			return fmt.Errorf("ошибка записи %s: %w", *commandsFile, err)
+		}
+		fmt.Println("Стартовые команды записаны в", *commandsFile)
+	}
+
+	if token.ExpiresIn > 0 {
+		fmt.Printf("Токен действует %s; когда истечет, запустите setup снова\n",
+			(time.Duration(token.ExpiresIn) * time.Second).Round(time.Minute))
+	}
+	return nil
+}
+
+func prompt(input *bufio.Reader, question string) string {
+	fmt.Print(question)
+	line, _ := input.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// Токен, выданный Twitch
+type oauthToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// deviceCodeLogin проходит Device Code Flow: показывает код и ждет, пока
+// пользователь подтвердит вход в браузере
+func deviceCodeLogin(clientID string, scopes []string) (*oauthToken, error) {
+	var device struct {
+		DeviceCode      string `json:"device_code"`
+		UserCode        string `json:"user_code"`
+		VerificationURI string `json:"verification_uri"`
+		ExpiresIn       int    `json:"expires_in"`
+		Interval        int    `json:"interval"`
+	}
+	err := postForm(twitchDeviceURL, url.Values{
+		"client_id": {clientID},
+		"scopes":    {strings.Join(scopes, " ")},
+	}, &device)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Откройте %s и введите код %s\n", device.VerificationURI, device.UserCode)
+
+	interval := time.Duration(max(device.Interval, 1)) * time.Second
+	deadline := time.Now().Add(time.Duration(device.ExpiresIn) * time.Second)
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		var token oauthToken
+		err := postForm(twitchTokenURL, url.Values{
+			"client_id":   {clientID},
+			"scopes":      {strings.Join(scopes, " ")},
+			"device_code": {device.DeviceCode},
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		}, &token)
+		var oauthErr *oauthError
+		if errors.As(err, &oauthErr) && oauthErr.Message == "authorization_pending" {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &token, nil
+	}
+	return nil, fmt.Errorf("время на подтверждение входа истекло")
+}
+
+// Ошибка OAuth-сервера Twitch
+type oauthError struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+func (e *oauthError) Error() string {
+	return fmt.Sprintf("twitch oauth: %s (статус %d)", e.Message, e.Status)
+}
+
+func postForm(endpoint string, form url.Values, out any) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.PostForm(endpoint, form)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса %s: %w", endpoint, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		oauthErr := &oauthError{Status: resp.StatusCode}
+		if json.Unmarshal(body, oauthErr) != nil || oauthErr.Message == "" {
+			oauthErr.Message = strings.TrimSpace(string(body))
+		}
+		return oauthErr
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("ошибка разбора ответа %s: %w", endpoint, err)
+	}
+	return nil
+}
+
+// Результат проверки токена
+type tokenValidation struct {
+	Login  string   `json:"login"`
+	UserID string   `json:"user_id"`
+	Scopes []string `json:"scopes"`
+}
+
+// validateToken узнает владельца и права токена
+func validateToken(accessToken string) (*tokenValidation, error) {
+	req, err := http.NewRequest(http.MethodGet, twitchValidateURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки токена: %w", err)
+	}
+	req.Header.Set("Authorization", "OAuth "+strings.TrimPrefix(accessToken, "oauth:"))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки токена: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("токен не прошел проверку (статус %d)", resp.StatusCode)
+	}
+	var validation tokenValidation
+	if err := json.NewDecoder(resp.Body).Decode(&validation); err != nil {
+		return nil, fmt.Errorf("ошибка разбора проверки токена: %w", err)
+	}
+	return &validation, nil
+}
+
+// testJoin подключается к IRC и ждет входа в канал
+func testJoin(username, accessToken, channel string, timeout time.Duration) error {
+	client := twitch.NewClient(username, "oauth:"+strings.TrimPrefix(accessToken, "oauth:"))
+	joined := make(chan struct{}, 1)
+	client.OnSelfJoinMessage(func(message twitch.UserJoinMessage) {
+		if normalizeChannel(message.Channel) == channel {
+			select {
+			case joined <- struct{}{}:
+			default:
+			}
+		}
+	})
+	client.Join(channel)
+
+	failed := make(chan error, 1)
+	go func() { failed <- client.Connect() }()
+	defer client.Disconnect()
+
+	select {
+	case <-joined:
+		return nil
+	case err := <-failed:
+		return fmt.Errorf("ошибка подключения к чату: %w", err)
+	case <-time.After(timeout):
+		return fmt.Errorf("не удалось войти в #%s за %s", channel, timeout)
+	}
+}
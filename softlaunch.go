@@ -0,0 +1,72 @@
+// softlaunch.go
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Пробный запуск: бот подключен к живому каналу, но отвечает только
+// пользователям из списка (и, если разрешено, модераторам), чтобы новые
+// пасты и возможности можно было проверить в настоящем чате
+type SoftLaunch struct {
+	mu        sync.RWMutex
+	enabled   bool
+	users     map[string]bool
+	allowMods bool
+}
+
+func NewSoftLaunch(enabled bool, users []string, allowMods bool) *SoftLaunch {
+	s := &SoftLaunch{enabled: enabled, users: make(map[string]bool), allowMods: allowMods}
+	for _, user := range users {
+		s.users[strings.ToLower(strings.TrimPrefix(user, "@"))] = true
+	}
+	return s
+}
+
+// Allowed сообщает, отвечает ли бот пользователю
+func (s *SoftLaunch) Allowed(user twitch.User) bool {
+	if s == nil {
+		return true
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.enabled || s.users[strings.ToLower(user.Name)] {
+		return true
+	}
+	return s.allowMods && isPrivileged(user)
+}
+
+// Enabled сообщает, включен ли пробный запуск
+func (s *SoftLaunch) Enabled() bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.enabled
+}
+
+// SetEnabled включает или выключает пробный запуск
+func (s *SoftLaunch) SetEnabled(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.enabled = enabled
+}
+
+// softLaunchMiddleware молча пропускает сообщения пользователей не из списка
+func softLaunchMiddleware(b *Bot, mc *MessageContext, next func()) {
+	if !b.softLaunch.Allowed(mc.Message.User) {
+		slog.Debug("Пробный запуск: пользователь не в списке", "user", mc.Message.User.Name)
+		return
+	}
+	next()
+}
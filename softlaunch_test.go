@@ -0,0 +1,62 @@
+// softlaunch_test.go
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// При SOFT_LAUNCH_MODS=false модератор не из списка все равно может выключить пробный запуск
+func TestSoftLaunchOffFromChat(t *testing.T) {
+	var out bytes.Buffer
+	b := &Bot{
+		config:     &Config{},
+		mentions:   NewMentionMatcher([]string{"paste_bot"}, nil),
+		pool:       newDryRunPool([]string{"paste_bot"}, []string{"channel"}, &out),
+		softLaunch: NewSoftLaunch(true, []string{"tester"}, false),
+		pause:      &PauseState{},
+		away:       NewAwayState(""),
+		cooldown:   NewGlobalCooldownManager(time.Second),
+	}
+
+	b.senders = NewSenderFilter(b.pool, false, nil)
+
+	mod := twitch.User{Name: "moder", IsMod: true}
+	b.processMessage(twitch.PrivateMessage{Channel: "channel", User: mod, Message: "!bot softlaunch off"}, time.Now())
+
+	if b.softLaunch.Enabled() {
+		t.Fatal("пробный запуск не выключен")
+	}
+	if !strings.Contains(out.String(), "выключен") {
+		t.Errorf("нет подтверждения: %q", out.String())
+	}
+}
+
+func TestSoftLaunchAllowed(t *testing.T) {
+	viewer := twitch.User{Name: "viewer"}
+	tester := twitch.User{Name: "Tester"}
+	mod := twitch.User{Name: "moder", IsMod: true}
+
+	tests := []struct {
+		name  string
+		soft  *SoftLaunch
+		user  twitch.User
+		allow bool
+	}{
+		{"выключен", NewSoftLaunch(false, []string{"tester"}, false), viewer, true},
+		{"зритель не из списка", NewSoftLaunch(true, []string{"tester"}, true), viewer, false},
+		{"зритель из списка", NewSoftLaunch(true, []string{"tester"}, false), tester, true},
+		{"модератор разрешен", NewSoftLaunch(true, nil, true), mod, true},
+		{"модератор не разрешен", NewSoftLaunch(true, nil, false), mod, false},
+		{"без пробного запуска", nil, viewer, true},
+	}
+	for _, tt := range tests {
+		if got := tt.soft.Allowed(tt.user); got != tt.allow {
+			t.Errorf("%s: %v, ожидалось %v", tt.name, got, tt.allow)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// status.go
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Состояние бота для !bot status, /health и /api/status
+type BotStatus struct {
+	Healthy        bool     `json:"healthy"`
+	Version        string   `json:"version"`
+	UptimeSeconds  int64    `json:"uptime_seconds"`
+	Channels       []string `json:"channels"`
+	JoinedChannels []string `json:"joined_channels"`
+	Commands       int      `json:"commands"`
+	QueueDepth     int      `json:"queue_depth"`
+	// Наибольший оставшийся cooldown среди каналов и cooldown каждого канала
+	CooldownRemaining float64            `json:"cooldown_remaining_seconds"`
+	CooldownChannels  map[string]float64 `json:"cooldown_remaining_by_channel"`
+	MemoryBytes       uint64             `json:"memory_bytes"`
+	SoftLaunch        bool               `json:"soft_launch"`
+}
+
+// status собирает текущее состояние бота
+func (b *Bot) status() BotStatus {
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+
+	status := BotStatus{
+		Healthy:          b.pool.Healthy(livenessTimeout),
+		Version:          buildInfo().String(),
+		UptimeSeconds:    int64(time.Since(b.started).Seconds()),
+		Channels:         b.pool.Channels(),
+		JoinedChannels:   b.pool.JoinedChannels(),
+		Commands:         len(b.Commands()),
+		CooldownChannels: make(map[string]float64),
+		MemoryBytes:      memory.Sys,
+		SoftLaunch:       b.softLaunch.Enabled(),
+	}
+	for _, conn := range b.pool.connections {
+		status.QueueDepth += conn.queue.Depth()
+	}
+	for _, channel := range status.Channels {
+		remaining := b.cooldown.Remaining(channel).Seconds()
+		status.CooldownChannels[channel] = remaining
+		status.CooldownRemaining = max(status.CooldownRemaining, remaining)
+	}
+	return status
+}
+
+// statusText формирует ответ на !bot status
+func (b *Bot) statusText() string {
+	status := b.status()
+
+	health := "в порядке"
+	if !status.Healthy {
+		health = "есть проблемы с подключением"
+	}
+	parts := []string{
+		"Статус: " + health,
+		"версия " + status.Version,
+		"работает " + formatUptime(time.Duration(status.UptimeSeconds)*time.Second),
+		fmt.Sprintf("каналы %d/%d", len(status.JoinedChannels), len(status.Channels)),
+		fmt.Sprintf("команд %d", status.Commands),
+		fmt.Sprintf("в очереди %d", status.QueueDepth),
+		fmt.Sprintf("память %d МБ", status.MemoryBytes>>20),
+	}
+	if status.SoftLaunch {
+		parts = append(parts, "пробный запуск")
+	}
+	if status.CooldownRemaining > 0 {
+		parts = append(parts, fmt.Sprintf("cooldown еще %.0f с", status.CooldownRemaining))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatUptime(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	if days > 0 {
+		return fmt.Sprintf("%d д %d ч", days, int(d.Hours())%24)
+	}
+	return formatSessionDuration(d)
+}
+
+// handleHealth отдает 200, если подключения живы, иначе 503
+func (s *AdminServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	status := s.bot.status()
+	code := http.StatusOK
+	if !status.Healthy {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, map[string]any{"healthy": status.Healthy, "version": status.Version})
+}
+
+func (s *AdminServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.bot.status())
+}
@@ -0,0 +1,78 @@
+// storage.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Типы постоянного хранилища (STORAGE_BACKEND)
+const (
+	StorageSQLite = "sqlite"
+	StorageBolt   = "bolt"
+)
+
+// Постоянное хранилище бота: команды, добавленные во время работы,
+// переменные-счетчики, статистика использования и cooldown каналов.
+// Реализации: SQLite (SQLiteStorage) и встроенная bbolt (BoltStorage),
+// которой не нужны ни CGO, ни внешняя база.
+type Storage interface {
+	Commands() (CommandStorage, error)
+	Variables() (Variables, error)
+	Usage() (UsageStorage, error)
+	Cooldown() (SharedCooldown, error)
+	Close() error
+}
+
+// Хранилище команд, которые накладываются поверх commands.yaml
+type CommandStorage interface {
+	List() ([]Command, error)
+	Save(cmd Command) error
+	Delete(name string) error
+	// DisabledFlags возвращает переключатели !bot enable/disable: имя -> выключена.
+	// Они хранятся отдельно от определений и накладываются на commands.yaml.
+	DisabledFlags() (map[string]bool, error)
+	SetDisabled(name string, disabled bool) error
+}
+
+// Журнал использования команд
+type UsageStorage interface {
+	Record(rec UsageRecord)
+	Count(filter UsageFilter) (int, error)
+	// Query возвращает выполнения, подходящие под фильтр, новые первыми
+	Query(filter UsageFilter) ([]UsageRecord, error)
+	CountsByCommand() (map[string]int, error)
+}
+
+// openStorage открывает хранилище выбранного типа
+func openStorage(backend, path string) (Storage, error) {
+	switch strings.ToLower(backend) {
+	case StorageSQLite:
+		db, err := openDatabase(path)
+		if err != nil {
+			return nil, err
+		}
+		return &SQLiteStorage{db: db}, nil
+	case StorageBolt:
+		return openBoltStorage(path)
+	default:
+		return nil, fmt.Errorf("неизвестный STORAGE_BACKEND %q (sqlite, bolt)", backend)
+	}
+}
+
+// storageFromEnv открывает хранилище по STORAGE_BACKEND и DATABASE_FILE.
+// Пустой DATABASE_FILE отключает хранилище: возвращается nil без ошибки.
+// При mustExist отсутствующий файл тоже считается отключенным хранилищем.
+func storageFromEnv(mustExist bool) (Storage, error) {
+	path := getEnvOptional("DATABASE_FILE", "bot.db")
+	if path == "" {
+		return nil, nil
+	}
+	if mustExist {
+		if _, err := os.Stat(path); err != nil {
+			return nil, nil
+		}
+	}
+	return openStorage(getEnv("STORAGE_BACKEND", StorageSQLite), path)
+}
@@ -0,0 +1,302 @@
+// storage_test.go
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Оба хранилища должны вести себя одинаково
+func TestStorageBackends(t *testing.T) {
+	for _, backend := range []string{StorageSQLite, StorageBolt} {
+		t.Run(backend, func(t *testing.T) {
+			storage, err := openStorage(backend, filepath.Join(t.TempDir(), "bot.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer storage.Close()
+
+			t.Run("команды", func(t *testing.T) { testCommandStorage(t, storage) })
+			t.Run("переменные", func(t *testing.T) { testVariableStorage(t, storage) })
+			t.Run("статистика", func(t *testing.T) { testUsageStorage(t, storage) })
+			t.Run("cooldown", func(t *testing.T) { testCooldownStorage(t, storage) })
+		})
+	}
+}
+
+func testCommandStorage(t *testing.T, storage Storage) {
+	store, err := storage.Commands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range []Command{
+		{Command: "!б", Text: "удалится"},
+		{Command: "!а", Text: "раз два", Parts: []string{"раз", "два"}},
+		{Command: "!в", Text: "выключена", Disabled: true},
+	} {
+		if err := store.Save(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Delete("!б"); err != nil {
+		t.Fatal(err)
+	}
+
+	commands, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commands) != 2 || commands[0].Command != "!а" || commands[1].Command != "!в" {
+		t.Fatalf("неожиданные команды: %+v", commands)
+	}
+	if !reflect.DeepEqual(commands[0].Parts, []string{"раз", "два"}) || !commands[1].Disabled {
+		t.Errorf("поля команд не сохранились: %+v", commands)
+	}
+
+	for name, disabled := range map[string]bool{"!а": true, "!г": true} {
+		if err := store.SetDisabled(name, disabled); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.SetDisabled("!г", false); err != nil {
+		t.Fatal(err)
+	}
+	if flags, err := store.DisabledFlags(); err != nil || !reflect.DeepEqual(flags, map[string]bool{"!а": true, "!г": false}) {
+		t.Errorf("DisabledFlags = %v, %v", flags, err)
+	}
+}
+
+func testVariableStorage(t *testing.T, storage Storage) {
+	variables, err := storage.Variables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := variables.Set("Попытка", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := variables.Add("попытка", 2); err != nil || value != 3 {
+		t.Errorf("Add = %d, %v, ожидалось 3", value, err)
+	}
+	if value, ok := variables.Get("ПОПЫТКА"); !ok || value != "3" {
+		t.Errorf("Get = %q, %v", value, ok)
+	}
+	if err := variables.Set("текст", "не число"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := variables.Add("текст", 1); err == nil {
+		t.Error("Add к нечисловой переменной без ошибки")
+	}
+	if err := variables.Set("плохое имя", "1"); err == nil {
+		t.Error("принято имя с пробелом")
+	}
+	if err := variables.Delete("текст"); err != nil {
+		t.Fatal(err)
+	}
+	if names := variables.Names(); !reflect.DeepEqual(names, []string{"попытка"}) {
+		t.Errorf("Names = %v", names)
+	}
+}
+
+func testUsageStorage(t *testing.T, storage Storage) {
+	usage, err := storage.Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 6 {
+		usage.Record(UsageRecord{
+			Time:    start.Add(time.Duration(i) * time.Hour),
+			Channel: []string{"один", "два"}[i%2],
+			User:    "зритель",
+			Command: []string{"!а", "!б", "!а"}[i%3],
+			Latency: time.Duration(i) * time.Millisecond,
+		})
+	}
+
+	counts := []struct {
+		name   string
+		filter UsageFilter
+		want   int
+	}{
+		{"все", UsageFilter{}, 6},
+		{"по команде", UsageFilter{Command: "!а"}, 4},
+		{"неизвестная команда", UsageFilter{Command: "!нет"}, 0},
+		{"по каналу", UsageFilter{Channel: "два"}, 3},
+		{"с начала периода", UsageFilter{Command: "!а", From: start.Add(2 * time.Hour)}, 3},
+		{"период", UsageFilter{From: start.Add(time.Hour), To: start.Add(3 * time.Hour)}, 2},
+		{"до конца периода", UsageFilter{To: start.Add(time.Hour)}, 1},
+		{"пустой период", UsageFilter{From: start.Add(10 * time.Hour)}, 0},
+	}
+	for _, tt := range counts {
+		if got, err := usage.Count(tt.filter); err != nil || got != tt.want {
+			t.Errorf("Count(%s) = %d, %v, ожидалось %d", tt.name, got, err, tt.want)
+		}
+	}
+
+	records, err := usage.Query(UsageFilter{Command: "!а", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || !records[0].Time.Equal(start.Add(5*time.Hour)) || !records[1].Time.Equal(start.Add(3*time.Hour)) {
+		t.Fatalf("Query вернул %+v", records)
+	}
+	if records[0].Latency != 5*time.Millisecond || records[0].Channel != "два" {
+		t.Errorf("поля записи не сохранились: %+v", records[0])
+	}
+
+	byCommand, err := usage.CountsByCommand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(byCommand, map[string]int{"!а": 4, "!б": 2}) {
+		t.Errorf("CountsByCommand = %v", byCommand)
+	}
+}
+
+func testCooldownStorage(t *testing.T, storage Storage) {
+	cooldown, err := storage.Cooldown()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last, err := cooldown.LastUsed("один"); err != nil || !last.IsZero() {
+		t.Fatalf("LastUsed до записи = %v, %v", last, err)
+	}
+	at := time.Now()
+	if err := cooldown.MarkUsed("один", at, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if last, err := cooldown.LastUsed("один"); err != nil || last.UnixMilli() != at.UnixMilli() {
+		t.Errorf("LastUsed = %v, %v, ожидалось %v", last, err, at)
+	}
+	if last, err := cooldown.LastUsed("два"); err != nil || !last.IsZero() {
+		t.Errorf("cooldown одного канала попал в другой: %v, %v", last, err)
+	}
+}
+
+// Сохраненный cooldown читается один раз на канал, а не на каждом сообщении
+type countingCooldown struct {
+	reads  map[string]int
+	writes map[string]int
+	last   map[string]time.Time
+}
+
+func (c *countingCooldown) LastUsed(channel string) (time.Time, error) {
+	c.reads[channel]++
+	return c.last[channel], nil
+}
+
+func (c *countingCooldown) MarkUsed(channel string, at time.Time, ttl time.Duration) error {
+	c.writes[channel]++
+	c.last[channel] = at
+	return nil
+}
+
+func TestCooldownRestore(t *testing.T) {
+	persist := &countingCooldown{
+		reads:  make(map[string]int),
+		writes: make(map[string]int),
+		last:   map[string]time.Time{"один": time.Now()},
+	}
+	gcm := NewGlobalCooldownManager(time.Minute)
+	gcm.Restore(persist)
+	if gcm.CanUse("#Один") {
+		t.Error("cooldown до перезапуска не восстановлен")
+	}
+	if !gcm.CanUse("два") {
+		t.Error("cooldown одного канала действует в другом")
+	}
+	for range 10 {
+		gcm.CanUse("один")
+		gcm.Remaining("один")
+	}
+	gcm.Use("один")
+
+	if persist.reads["один"] != 1 {
+		t.Errorf("база прочитана %d раз, ожидался один", persist.reads["один"])
+	}
+	if persist.writes["один"] != 1 || persist.writes["два"] != 0 {
+		t.Errorf("записи в базу: %v", persist.writes)
+	}
+}
+
+// Паста в одном канале не включает cooldown и не тратит уведомление в другом
+func TestCooldownPerChannel(t *testing.T) {
+	gcm := NewGlobalCooldownManager(time.Minute)
+	gcm.Use("один")
+	if gcm.CanUse("один") {
+		t.Error("cooldown канала не включился")
+	}
+	if !gcm.CanUse("два") || gcm.Remaining("два") != 0 {
+		t.Error("cooldown одного канала действует в другом")
+	}
+	if _, ok := gcm.TakeNotice("один"); !ok {
+		t.Error("нет уведомления о cooldown")
+	}
+	if _, ok := gcm.TakeNotice("один"); ok {
+		t.Error("второе уведомление за период")
+	}
+}
+
+// Адаптивный cooldown зависит только от активности своего канала
+func TestAdaptiveCooldownPerChannel(t *testing.T) {
+	activity := &ChatActivity{}
+	now := time.Now()
+	for range 120 {
+		activity.Record("#Шумный", now)
+	}
+	gcm := NewGlobalCooldownManager(30 * time.Second)
+	gcm.adaptive = &AdaptiveCooldown{activity: activity, referenceRate: 30, min: 5 * time.Second, max: 2 * time.Minute}
+
+	if got := gcm.Duration("шумный"); got != 2*time.Minute {
+		t.Errorf("cooldown шумного канала %v", got)
+	}
+	if got := gcm.Duration("тихий"); got != 5*time.Second {
+		t.Errorf("активность одного канала растянула cooldown другого: %v", got)
+	}
+}
+
+func TestResponseBreakerPerChannel(t *testing.T) {
+	breaker := NewResponseBreaker(2)
+	for range 2 {
+		if !breaker.Allow("один") {
+			t.Fatal("ответ в пределах лимита отклонен")
+		}
+	}
+	if breaker.Allow("#Один") {
+		t.Error("предохранитель не сработал")
+	}
+	if !breaker.Allow("два") {
+		t.Error("предохранитель одного канала заглушил другой")
+	}
+}
+
+func TestBoltLockedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.db")
+	storage, err := openStorage(StorageBolt, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer storage.Close()
+
+	if _, err := openStorage(StorageBolt, path); err == nil {
+		t.Error("занятый файл открыт второй раз")
+	}
+}
+
+func TestOpenStorageUnknown(t *testing.T) {
+	if _, err := openStorage("postgres", filepath.Join(t.TempDir(), "bot.db")); err == nil {
+		t.Error("принят неизвестный STORAGE_BACKEND")
+	}
+}
+
+// Явно пустой DATABASE_FILE отключает базу, а не заменяется на bot.db
+func TestStorageFromEnvDisabled(t *testing.T) {
+	t.Setenv("DATABASE_FILE", "")
+	storage, err := storageFromEnv(false)
+	if err != nil || storage != nil {
+		t.Errorf("storageFromEnv = %v, %v, ожидалось отключенное хранилище", storage, err)
+	}
+}
@@ -0,0 +1,62 @@
+// streamuses.go
+package main
+
+import (
+	"sync"
+)
+
+// Счетчики вызовов команд с max_uses_per_stream за текущий стрим. Сбрасываются
+// при начале стрима (STREAM_SESSIONS) или командой !bot reset.
+type StreamUses struct {
+	mu     sync.Mutex
+	counts map[string]map[string]int // канал -> команда -> вызовов
+}
+
+func NewStreamUses() *StreamUses {
+	return &StreamUses{counts: make(map[string]map[string]int)}
+}
+
+// Exhausted сообщает, что команда уже вызвана limit раз за стрим
+func (u *StreamUses) Exhausted(channel string, command *Command) bool {
+	if u == nil || command.MaxUsesPerStream <= 0 {
+		return false
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.counts[normalizeChannel(channel)][command.Command] >= command.MaxUsesPerStream
+}
+
+// Use засчитывает вызов команды
+func (u *StreamUses) Use(channel string, command *Command) {
+	if u == nil || command.MaxUsesPerStream <= 0 {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	channel = normalizeChannel(channel)
+	if u.counts[channel] == nil {
+		u.counts[channel] = make(map[string]int)
+	}
+	u.counts[channel][command.Command]++
+}
+
+// Reset обнуляет счетчик команды в канале, а при пустом command - все счетчики канала
+func (u *StreamUses) Reset(channel, command string) {
+	if u == nil {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	channel = normalizeChannel(channel)
+	if command == "" {
+		delete(u.counts, channel)
+		return
+	}
+	delete(u.counts[channel], command)
+}
@@ -0,0 +1,104 @@
+// systemd.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Если от подключения так долго нет ни сообщений, ни PONG, считаем его зависшим.
+// go-twitch-irc шлет PING после 15 секунд тишины, так что живое соединение
+// отвечает гораздо чаще.
+const livenessTimeout = 60 * time.Second
+
+// sdNotify отправляет состояние в systemd (sd_notify). Без NOTIFY_SOCKET ничего не делает.
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+
+	// Абстрактный сокет Linux
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к NOTIFY_SOCKET: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("ошибка отправки в NOTIFY_SOCKET: %w", err)
+	}
+	return nil
+}
+
+// watchdogInterval возвращает интервал watchdog из WATCHDOG_USEC или 0, если он выключен
+func watchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	// WATCHDOG_PID, если задан, должен указывать на нас
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
+
+// runSystemdNotifier сообщает systemd о готовности после подключения всех учетных
+// записей, обновляет STATUS со списком каналов и шлет WATCHDOG, только пока все
+// подключения живы. Если соединение молча зависнет, systemd перезапустит бота.
+func runSystemdNotifier(pool *ConnectionPool) {
+	if os.Getenv("NOTIFY_SOCKET") == "" {
+		return
+	}
+
+	interval := watchdogInterval()
+	tick := 5 * time.Second
+	if interval > 0 {
+		tick = min(tick, interval/2)
+	}
+
+	ready := false
+	lastStatus := ""
+
+	for range time.Tick(tick) {
+		healthy := pool.Healthy(livenessTimeout)
+
+		if healthy && !ready {
+			if err := sdNotify("READY=1"); err != nil {
+				slog.Warn("Ошибка sd_notify", "error", err)
+			}
+			ready = true
+		}
+
+		status := "Каналы: " + strings.Join(pool.JoinedChannels(), ", ")
+		if !healthy {
+			status = "Нет связи с Twitch. " + status
+		}
+		if status != lastStatus {
+			if err := sdNotify("STATUS=" + status); err != nil {
+				slog.Warn("Ошибка sd_notify", "error", err)
+			}
+			lastStatus = status
+		}
+
+		if interval > 0 && healthy {
+			if err := sdNotify("WATCHDOG=1"); err != nil {
+				slog.Warn("Ошибка sd_notify", "error", err)
+			}
+		} else if interval > 0 {
+			slog.Warn("Подключение не отвечает, WATCHDOG не отправлен")
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// templates.go
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// renderTemplate подставляет переменные вида {name} в текст
+func renderTemplate(text string, vars map[string]string) string {
+	if !strings.Contains(text, "{") {
+		return text
+	}
+
+	pairs := make([]string, 0, len(vars)*2)
+	for name, value := range vars {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// Функция шаблона пасты: {имя арг1 "арг 2"}
+type templateFunc func(args []string) (string, error)
+
+// expandTemplate заменяет вызовы {имя аргументы...} результатами функций.
+// Неизвестные функции и вызовы с ошибкой остаются в тексте как есть.
+func expandTemplate(text string, funcs map[string]templateFunc) string {
+	if !strings.Contains(text, "{") {
+		return text
+	}
+
+	var out strings.Builder
+	for {
+		start := strings.IndexByte(text, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(text[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		out.WriteString(text[:start])
+		call := text[start : end+1]
+		text = text[end+1:]
+
+		args := splitTemplateArgs(call[1 : len(call)-1])
+		if len(args) == 0 {
+			out.WriteString(call)
+			continue
+		}
+		fn, ok := funcs[strings.ToLower(args[0])]
+		if !ok {
+			out.WriteString(call)
+			continue
+		}
+		result, err := fn(args[1:])
+		if err != nil {
+			out.WriteString(call)
+			continue
+		}
+		out.WriteString(result)
+	}
+	out.WriteString(text)
+	return out.String()
+}
+
+// splitTemplateArgs делит вызов на слова; текст в кавычках - одно слово
+func splitTemplateArgs(s string) []string {
+	var args []string
+	var current strings.Builder
+	inQuotes, hasArg := false, false
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case r == ' ' && !inQuotes:
+			if hasArg {
+				args = append(args, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		default:
+			current.WriteRune(r)
+			hasArg = true
+		}
+	}
+	if hasArg {
+		args = append(args, current.String())
+	}
+	return args
+}
+
+// renderPaste подставляет в текст пасты части шаблонов и функции. args - слова
+// сообщения после команды: {touser} и {query}.
+func (b *Bot) renderPaste(message twitch.PrivateMessage, args []string, text string) string {
+	channel := message.Channel
+	location := b.Config().LocationFor(channel)
+
+	touser := message.User.Name
+	if len(args) > 0 {
+		touser = strings.TrimPrefix(args[0], "@")
+	}
+
+	text = b.expandPartials(channel, text)
+
+	funcs := map[string]templateFunc{
+		"user":    constFunc(message.User.Name),
+		"touser":  constFunc(touser),
+		"channel": constFunc(channel),
+		"query":   constFunc(strings.Join(args, " ")),
+		"time":    timeFunc(location, defaultTimeLayout),
+		"date":    timeFunc(location, defaultDateLayout),
+		"var": func(args []string) (string, error) {
+			if len(args) != 1 {
+				return "", fmt.Errorf("ожидается {var имя}")
+			}
+			value, ok := b.variables.Get(args[0])
+			if !ok {
+				return "", fmt.Errorf("переменная %s не задана", args[0])
+			}
+			return value, nil
+		},
+		"random_emote": func(args []string) (string, error) {
+			return b.emotes.Random(channel), nil
+		},
+	}
+	for name, fn := range randomFuncs {
+		funcs[name] = fn
+	}
+	return expandTemplate(text, funcs)
+}
+
+// constFunc возвращает функцию шаблона без аргументов с постоянным значением
+func constFunc(value string) templateFunc {
+	return func(args []string) (string, error) {
+		if len(args) > 0 {
+			return "", fmt.Errorf("функция не принимает аргументов")
+		}
+		return value, nil
+	}
+}
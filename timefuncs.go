@@ -0,0 +1,57 @@
+// timefuncs.go
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Форматы по умолчанию для {time} и {date}
+const (
+	defaultTimeLayout = "15:04"
+	defaultDateLayout = "02.01.2006"
+)
+
+// timeFunc возвращает функцию шаблона {time "часовой пояс" "формат"}.
+// Без аргументов используется часовой пояс канала; формат - в нотации Go.
+func timeFunc(location *time.Location, defaultLayout string) templateFunc {
+	return func(args []string) (string, error) {
+		if len(args) > 2 {
+			return "", fmt.Errorf("ожидается {time \"часовой пояс\" \"формат\"}")
+		}
+
+		loc := location
+		if len(args) > 0 && args[0] != "" {
+			var err error
+			if loc, err = time.LoadLocation(args[0]); err != nil {
+				return "", fmt.Errorf("неверный часовой пояс %q: %w", args[0], err)
+			}
+		}
+
+		layout := defaultLayout
+		if len(args) > 1 {
+			layout = args[1]
+		}
+		return time.Now().In(loc).Format(layout), nil
+	}
+}
+
+// localTimeText формирует ответ для !время
+func (b *Bot) localTimeText(message twitch.PrivateMessage) string {
+	location := b.Config().LocationFor(message.Channel)
+	now := time.Now().In(location)
+
+	zone := location.String()
+	if zone == "Local" {
+		zone, _ = now.Zone()
+	}
+
+	return renderTemplate(b.templates(message).LocalTime, map[string]string{
+		"user":     message.User.Name,
+		"time":     now.Format(defaultTimeLayout),
+		"date":     now.Format(defaultDateLayout),
+		"timezone": zone,
+	})
+}
@@ -0,0 +1,81 @@
+// timeout.go
+package main
+
+import (
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Срок, на который бот замолкает в канале после бана (до снятия бана вручную
+// Twitch ничего не присылает, поэтому периодически пробуем снова)
+const banBackoff = 24 * time.Hour
+
+var timedOutRe = regexp.MustCompile(`(\d+) more seconds`)
+
+// handleClearChat реагирует на таймаут или бан учетной записи бота
+func (c *Connection) handleClearChat(message twitch.ClearChatMessage) {
+	if !strings.EqualFold(message.TargetUsername, c.username) {
+		return
+	}
+	channel := normalizeChannel(message.Channel)
+
+	if message.BanDuration > 0 {
+		duration := time.Duration(message.BanDuration) * time.Second
+		c.queue.Suspend(channel, time.Now().Add(duration))
+		slog.Error("Бот получил таймаут, отправка в канал приостановлена",
+			"bot_username", c.username,
+			"channel", channel,
+			"duration", duration)
+		return
+	}
+
+	c.queue.Suspend(channel, time.Now().Add(banBackoff))
+	slog.Error("Бот забанен в канале, отправка приостановлена",
+		"bot_username", c.username,
+		"channel", channel,
+		"retry_in", banBackoff)
+}
+
+// handleClearMessage сообщает об удалении сообщения бота модератором
+func (c *Connection) handleClearMessage(message twitch.ClearMessage) {
+	if !strings.EqualFold(message.Login, c.username) {
+		return
+	}
+	slog.Warn("Модератор удалил сообщение бота",
+		"bot_username", c.username,
+		"channel", normalizeChannel(message.Channel),
+		"text", message.Message)
+}
+
+// handleNotice ловит отказы Twitch в отправке, если CLEARCHAT был пропущен
+// (например, таймаут выдан до подключения)
+func (c *Connection) handleNotice(message twitch.NoticeMessage) {
+	channel := normalizeChannel(message.Channel)
+
+	switch message.MsgID {
+	case "msg_timedout":
+		duration := time.Minute
+		if match := timedOutRe.FindStringSubmatch(message.Message); match != nil {
+			if seconds, err := strconv.Atoi(match[1]); err == nil {
+				duration = time.Duration(seconds) * time.Second
+			}
+		}
+		c.queue.Suspend(channel, time.Now().Add(duration))
+		slog.Error("Бот в таймауте, отправка в канал приостановлена",
+			"bot_username", c.username,
+			"channel", channel,
+			"duration", duration)
+
+	case "msg_banned":
+		c.queue.Suspend(channel, time.Now().Add(banBackoff))
+		slog.Error("Бот забанен в канале, отправка приостановлена",
+			"bot_username", c.username,
+			"channel", channel,
+			"retry_in", banBackoff)
+	}
+}
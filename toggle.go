@@ -0,0 +1,56 @@
+// toggle.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// setCommandEnabled включает или выключает команду и пишет это в журнал аудита.
+// С базой сохраняется только переключатель: он переживает перезапуск, а правки
+// команды в commands.yaml продолжают действовать. Без базы переключатель
+// действует до перезагрузки команд, и persistent=false.
+func (b *Bot) setCommandEnabled(actor, name string, enabled bool) (persistent bool, err error) {
+	if !strings.HasPrefix(name, "!") {
+		name = "!" + name
+	}
+	if b.isBuiltin(name) {
+		return false, fmt.Errorf("встроенную команду %s нельзя выключить", name)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	current, ok := b.commands[name]
+	if !ok {
+		return false, fmt.Errorf("команда %s не найдена", name)
+	}
+	if current.Disabled == !enabled {
+		return b.store != nil, nil
+	}
+
+	// Набор команд неизменяемый, поэтому меняем копию
+	cmd := *current
+	cmd.Disabled = !enabled
+	if b.store != nil {
+		if err := b.store.SetDisabled(name, cmd.Disabled); err != nil {
+			return false, err
+		}
+	}
+
+	commands := make(map[string]*Command, len(b.commands))
+	for n, c := range b.commands {
+		commands[n] = c
+	}
+	commands[name] = &cmd
+	b.setCommandsLocked(commands)
+
+	action := AuditCommandDisable
+	if enabled {
+		action = AuditCommandEnable
+	}
+	b.audit.Record(actor, action, name, "")
+	slog.Info("Команда переключена", "actor", actor, "command", name, "enabled", enabled, "persistent", b.store != nil)
+	return b.store != nil, nil
+}
@@ -0,0 +1,153 @@
+// toggle_test.go
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+func TestSetCommandEnabledAudit(t *testing.T) {
+	dir := t.TempDir()
+	audit, err := NewAuditLog(filepath.Join(dir, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.Close()
+
+	storage, err := openStorage(StorageBolt, filepath.Join(dir, "bot.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer storage.Close()
+	store, err := storage.Commands()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &Bot{
+		audit:    audit,
+		store:    store,
+		commands: map[string]*Command{"!паста": {Command: "!паста", Text: "привет"}},
+	}
+	b.registerHandler(handlerFunc{[]string{"!пасты"}, func(context.Context, twitch.PrivateMessage, []string) string { return "" }})
+
+	if _, err := b.setCommandEnabled("moder", "паста", false); err != nil {
+		t.Fatal(err)
+	}
+	if b.Commands()["!паста"].Available(time.Now()) {
+		t.Error("выключенная команда доступна")
+	}
+	// Повторное выключение ничего не меняет и не пишется в журнал
+	if _, err := b.setCommandEnabled("moder", "!паста", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// В базе только переключатель, а не копия команды
+	if stored, err := store.List(); err != nil || len(stored) != 0 {
+		t.Fatalf("команда сохранена в базе целиком: %+v, %v", stored, err)
+	}
+	if flags, err := store.DisabledFlags(); err != nil || !flags["!паста"] {
+		t.Fatalf("переключатель не сохранен: %v, %v", flags, err)
+	}
+
+	if _, err := b.setCommandEnabled("admin-api", "!паста", true); err != nil {
+		t.Fatal(err)
+	}
+	if !b.Commands()["!паста"].Available(time.Now()) {
+		t.Error("включенная команда недоступна")
+	}
+
+	if _, err := b.setCommandEnabled("moder", "!нет", false); err == nil {
+		t.Error("выключена несуществующая команда")
+	}
+	if _, err := b.setCommandEnabled("moder", "!пасты", false); err == nil {
+		t.Error("выключена встроенная команда")
+	}
+
+	entries, err := audit.Query(AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AuditEntry{
+		{Actor: "moder", Action: AuditCommandDisable, Target: "!паста"},
+		{Actor: "admin-api", Action: AuditCommandEnable, Target: "!паста"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("в журнале %d записей, ожидалось %d: %+v", len(entries), len(want), entries)
+	}
+	for i, entry := range entries {
+		if entry.Actor != want[i].Actor || entry.Action != want[i].Action || entry.Target != want[i].Target {
+			t.Errorf("запись %d: %+v, ожидалось %+v", i, entry, want[i])
+		}
+	}
+}
+
+// Переключатель накладывается на commands.yaml, а правки файла продолжают действовать
+func TestDisabledFlagOverYAML(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := openStorage(StorageSQLite, filepath.Join(dir, "bot.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer storage.Close()
+	store, err := storage.Commands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetDisabled("!паста", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetDisabled("!старая", false); err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(dir, "commands.yaml")
+	yaml := `messages:
+  - command: "!паста"
+    text: новый текст
+  - command: "!старая"
+    text: была выключена в файле
+    disabled: true
+`
+	if err := os.WriteFile(file, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	commands, err := loadEffectiveCommands(file, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd := commands["!паста"]; cmd.Text != "новый текст" || !cmd.Disabled {
+		t.Errorf("!паста: %+v", cmd)
+	}
+	if cmd := commands["!старая"]; cmd.Disabled {
+		t.Errorf("!старая осталась выключенной: %+v", cmd)
+	}
+
+	// Удаление из файла удаляет команду, несмотря на переключатель
+	if err := os.WriteFile(file, []byte("messages:\n  - command: \"!старая\"\n    text: текст\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if commands, err = loadEffectiveCommands(file, store); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := commands["!паста"]; ok {
+		t.Error("удаленная из файла команда осталась")
+	}
+}
+
+func TestSetCommandEnabledWithoutStore(t *testing.T) {
+	b := &Bot{commands: map[string]*Command{"!паста": {Command: "!паста", Text: "привет"}}}
+	persistent, err := b.setCommandEnabled("moder", "!паста", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if persistent {
+		t.Error("без базы переключатель не может пережить перезагрузку")
+	}
+}
@@ -0,0 +1,42 @@
+// tracing.go
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Трейсер конвейера обработки сообщений. Пока трейсинг не включен,
+// глобальный провайдер OpenTelemetry ничего не делает.
+var tracer = otel.Tracer("twitch-paste-bot")
+
+// setupTracing включает экспорт спанов по OTLP/HTTP. Адрес коллектора и прочие
+// параметры берутся из стандартных переменных OTEL_EXPORTER_OTLP_*.
+func setupTracing(ctx context.Context) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания OTLP-экспортера: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(getEnv("OTEL_SERVICE_NAME", "twitch-paste-bot")),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания ресурса OpenTelemetry: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
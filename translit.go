@@ -0,0 +1,146 @@
+// translit.go
+package main
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Транслитерация кириллицы латиницей для синонимов команд
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "h", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// Обратная транслитерация: сначала сочетания, затем одиночные буквы
+var latinToCyrillic = []struct{ latin, cyrillic string }{
+	{"sch", "щ"}, {"zh", "ж"}, {"ts", "ц"}, {"ch", "ч"}, {"sh", "ш"},
+	{"yu", "ю"}, {"ya", "я"}, {"yo", "ё"},
+	{"a", "а"}, {"b", "б"}, {"v", "в"}, {"w", "в"}, {"g", "г"}, {"d", "д"},
+	{"e", "е"}, {"z", "з"}, {"i", "и"}, {"j", "дж"}, {"k", "к"}, {"c", "к"},
+	{"q", "к"}, {"l", "л"}, {"m", "м"}, {"n", "н"}, {"o", "о"}, {"p", "п"},
+	{"r", "р"}, {"s", "с"}, {"t", "т"}, {"u", "у"}, {"f", "ф"}, {"h", "х"},
+	{"x", "кс"}, {"y", "ы"},
+}
+
+// transliterate переводит имя команды из кириллицы в латиницу или обратно.
+// Имена со смешанными алфавитами не переводятся.
+func transliterate(name string) string {
+	prefix := ""
+	if strings.HasPrefix(name, "!") {
+		prefix, name = "!", name[1:]
+	}
+	name = strings.ToLower(name)
+
+	var cyrillic, latin bool
+	for _, r := range name {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic = true
+		case r >= 'a' && r <= 'z':
+			latin = true
+		}
+	}
+
+	var result strings.Builder
+	switch {
+	case cyrillic && !latin:
+		for _, r := range name {
+			if latin, ok := cyrillicToLatin[r]; ok {
+				result.WriteString(latin)
+			} else {
+				result.WriteRune(r)
+			}
+		}
+	case latin && !cyrillic:
+		for rest := name; rest != ""; {
+			matched := false
+			for _, pair := range latinToCyrillic {
+				if strings.HasPrefix(rest, pair.latin) {
+					letter := pair.cyrillic
+					// "y" после гласной - это "й": !moy -> !мой
+					if pair.latin == "y" && endsWithVowel(result.String()) {
+						letter = "й"
+					}
+					result.WriteString(letter)
+					rest = rest[len(pair.latin):]
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				result.WriteByte(rest[0])
+				rest = rest[1:]
+			}
+		}
+	default:
+		return ""
+	}
+	return prefix + result.String()
+}
+
+func endsWithVowel(text string) bool {
+	runes := []rune(text)
+	return len(runes) > 0 && strings.ContainsRune("аеёиоуыэюя", runes[len(runes)-1])
+}
+
+// buildAliases строит синонимы-транслитерации для команд и встроенных команд.
+// Синоним, совпадающий с существующей командой или другим синонимом, не
+// создается; такие случаи пишутся в лог.
+func (b *Bot) buildAliases(commands map[string]*Command) map[string]string {
+	names := make([]string, 0, len(commands)+len(b.handlers))
+	for name := range commands {
+		names = append(names, name)
+	}
+	for name := range b.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	aliases := make(map[string]string)
+	conflicts := make(map[string]bool)
+	for _, name := range names {
+		alias := transliterate(name)
+		if alias == "" || alias == name || conflicts[alias] {
+			continue
+		}
+		if _, exists := commands[alias]; exists || b.isBuiltin(alias) {
+			slog.Warn("Транслитерация совпадает с существующей командой, синоним не создан",
+				"command", name, "alias", alias)
+			continue
+		}
+		if other, exists := aliases[alias]; exists {
+			slog.Warn("Одинаковая транслитерация у разных команд, синоним не создан",
+				"command", name, "other", other, "alias", alias)
+			delete(aliases, alias)
+			conflicts[alias] = true
+			continue
+		}
+		aliases[alias] = name
+	}
+
+	slog.Debug("Синонимы-транслитерации построены", "count", len(aliases))
+	return aliases
+}
+
+// setCommandsLocked заменяет набор команд и перестраивает синонимы. Вызывается под b.mu.
+func (b *Bot) setCommandsLocked(commands map[string]*Command) {
+	b.commands = commands
+	if b.transliterate {
+		b.aliases = b.buildAliases(commands)
+	}
+}
+
+// resolveAlias возвращает имя команды по синониму-транслитерации
+func (b *Bot) resolveAlias(name string) (string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	command, ok := b.aliases[strings.ToLower(name)]
+	return command, ok
+}
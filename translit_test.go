@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"кириллица в латиницу", "!паста", "!pasta"},
+		{"цифры сохраняются", "!паста3", "!pasta3"},
+		{"регистр не важен", "!Паста", "!pasta"},
+		{"буквы из нескольких латинских", "!жужа", "!zhuzha"},
+		{"щ длиннее ш", "!щи", "!schi"},
+		{"твердый и мягкий знак пропадают", "!объявь", "!obyav"},
+		{"без восклицательного знака", "привет", "privet"},
+		{"латиница в кириллицу", "!hello", "!хелло"},
+		{"sch раньше sh и ch", "!schuka", "!щука"},
+		{"sh и ch", "!shchi", "!шчи"},
+		{"ts в ц", "!tsar", "!цар"},
+		{"yo раньше y", "!yolka", "!ёлка"},
+		{"ya и yu", "!yayu", "!яю"},
+		{"y после согласной", "!pasty", "!пасты"},
+		{"y после гласной", "!moy", "!мой"},
+		{"y в начале слова", "!y", "!ы"},
+		{"смешанные алфавиты", "!пастаx", ""},
+		{"без букв", "!123", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transliterate(tt.in); got != tt.want {
+				t.Errorf("transliterate(%q) = %q, ожидалось %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
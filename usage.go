@@ -0,0 +1,170 @@
+// usage.go
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Запись об одном выполнении команды
+type UsageRecord struct {
+	Time      time.Time     `json:"time"`
+	Channel   string        `json:"channel"`
+	User      string        `json:"user"`
+	Command   string        `json:"command"`
+	Latency   time.Duration `json:"-"`
+	LatencyMs int64         `json:"latency_ms"`
+}
+
+// Фильтр для выборки из журнала использования
+type UsageFilter struct {
+	Command string
+	Channel string
+	User    string
+	From    time.Time
+	To      time.Time
+	Limit   int
+}
+
+// Журнал использования команд в SQLite
+type UsageLog struct {
+	db *sql.DB
+}
+
+func NewUsageLog(db *sql.DB) (*UsageLog, error) {
+	schema := `
+CREATE TABLE IF NOT EXISTS usage (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	ts         INTEGER NOT NULL,
+	channel    TEXT NOT NULL,
+	user       TEXT NOT NULL,
+	command    TEXT NOT NULL,
+	latency_ms INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS usage_command_ts ON usage (command, ts);`
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("ошибка создания схемы статистики: %w", err)
+	}
+
+	return &UsageLog{db: db}, nil
+}
+
+// Record сохраняет выполнение команды. Для nil-журнала ничего не делает.
+func (u *UsageLog) Record(rec UsageRecord) {
+	if u == nil {
+		return
+	}
+
+	_, err := u.db.Exec(
+		`INSERT INTO usage (ts, channel, user, command, latency_ms) VALUES (?, ?, ?, ?, ?)`,
+		rec.Time.UnixMilli(), rec.Channel, rec.User, rec.Command, rec.Latency.Milliseconds(),
+	)
+	if err != nil {
+		slog.Error("Ошибка записи статистики", "error", err, "command", rec.Command)
+	}
+}
+
+// whereClause собирает условие WHERE по фильтру
+func (f UsageFilter) whereClause() (string, []any) {
+	var conds []string
+	var args []any
+
+	if f.Command != "" {
+		conds = append(conds, "command = ?")
+		args = append(args, f.Command)
+	}
+	if f.Channel != "" {
+		conds = append(conds, "channel = ?")
+		args = append(args, f.Channel)
+	}
+	if f.User != "" {
+		conds = append(conds, "user = ?")
+		args = append(args, f.User)
+	}
+	if !f.From.IsZero() {
+		conds = append(conds, "ts >= ?")
+		args = append(args, f.From.UnixMilli())
+	}
+	if !f.To.IsZero() {
+		conds = append(conds, "ts < ?")
+		args = append(args, f.To.UnixMilli())
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// match проверяет запись по фильтру так же, как whereClause
+func (f UsageFilter) match(rec UsageRecord) bool {
+	return (f.Command == "" || rec.Command == f.Command) &&
+		(f.Channel == "" || rec.Channel == f.Channel) &&
+		(f.User == "" || rec.User == f.User) &&
+		(f.From.IsZero() || !rec.Time.Before(f.From)) &&
+		(f.To.IsZero() || rec.Time.Before(f.To))
+}
+
+// Count возвращает количество выполнений, подходящих под фильтр
+func (u *UsageLog) Count(filter UsageFilter) (int, error) {
+	where, args := filter.whereClause()
+
+	var count int
+	if err := u.db.QueryRow("SELECT COUNT(*) FROM usage"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("ошибка подсчета статистики: %w", err)
+	}
+	return count, nil
+}
+
+// Query возвращает последние выполнения, подходящие под фильтр (новые первыми)
+func (u *UsageLog) Query(filter UsageFilter) ([]UsageRecord, error) {
+	where, args := filter.whereClause()
+
+	query := "SELECT ts, channel, user, command, latency_ms FROM usage" + where + " ORDER BY ts DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := u.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки статистики: %w", err)
+	}
+	defer rows.Close()
+
+	records := []UsageRecord{}
+	for rows.Next() {
+		var rec UsageRecord
+		var ts int64
+		if err := rows.Scan(&ts, &rec.Channel, &rec.User, &rec.Command, &rec.LatencyMs); err != nil {
+			return nil, fmt.Errorf("ошибка чтения статистики: %w", err)
+		}
+		rec.Time = time.UnixMilli(ts).UTC()
+		rec.Latency = time.Duration(rec.LatencyMs) * time.Millisecond
+		records = append(records, rec)
+	}
+
+	return records, rows.Err()
+}
+
+// CountsByCommand возвращает количество выполнений каждой команды
+func (u *UsageLog) CountsByCommand() (map[string]int, error) {
+	rows, err := u.db.Query("SELECT command, COUNT(*) FROM usage GROUP BY command")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подсчета статистики: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var command string
+		var count int
+		if err := rows.Scan(&command, &count); err != nil {
+			return nil, fmt.Errorf("ошибка чтения статистики: %w", err)
+		}
+		counts[command] = count
+	}
+	return counts, rows.Err()
+}
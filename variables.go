@@ -0,0 +1,250 @@
+// variables.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Хранилище переменных {var}: локальное (VariableStore, BoltVariables) или
+// общее для нескольких экземпляров (RedisVariables)
+type Variables interface {
+	Get(name string) (string, bool)
+	Names() []string
+	Set(name, value string) error
+	Add(name string, delta int) (int, error)
+	Delete(name string) error
+}
+
+// Общие переменные, которые подставляются в пасты как {var имя}.
+// Хранятся в памяти и, если есть база, сохраняются в ней.
+type VariableStore struct {
+	db *sql.DB
+
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+// NewVariableStore загружает переменные из базы. db может быть nil - тогда
+// переменные живут только до перезапуска.
+func NewVariableStore(db *sql.DB) (*VariableStore, error) {
+	s := &VariableStore{db: db, values: make(map[string]string)}
+	if db == nil {
+		return s, nil
+	}
+
+	schema := `
+CREATE TABLE IF NOT EXISTS variables (
+	name       TEXT PRIMARY KEY,
+	value      TEXT NOT NULL,
+	updated_at INTEGER NOT NULL
+);`
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("ошибка создания схемы переменных: %w", err)
+	}
+
+	rows, err := db.Query("SELECT name, value FROM variables")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения переменных: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("ошибка чтения переменных: %w", err)
+		}
+		s.values[name] = value
+	}
+	return s, rows.Err()
+}
+
+// validVariableName проверяет имя: буквы, цифры, _ и -
+func validVariableName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// Get возвращает значение переменной
+func (s *VariableStore) Get(name string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.values[strings.ToLower(name)]
+	return value, ok
+}
+
+// Names возвращает отсортированные имена переменных
+func (s *VariableStore) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.values))
+	for name := range s.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Set задает значение переменной
+func (s *VariableStore) Set(name, value string) error {
+	name = strings.ToLower(name)
+	if !validVariableName(name) {
+		return fmt.Errorf("неверное имя переменной %q", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.setLocked(name, value)
+}
+
+// Add прибавляет delta к числовой переменной (отсутствующая считается нулем)
+func (s *VariableStore) Add(name string, delta int) (int, error) {
+	name = strings.ToLower(name)
+	if !validVariableName(name) {
+		return 0, fmt.Errorf("неверное имя переменной %q", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := 0
+	if value, ok := s.values[name]; ok {
+		var err error
+		if current, err = strconv.Atoi(value); err != nil {
+			return 0, fmt.Errorf("переменная %s не число", name)
+		}
+	}
+
+	current += delta
+	return current, s.setLocked(name, strconv.Itoa(current))
+}
+
+func (s *VariableStore) setLocked(name, value string) error {
+	if s.db != nil {
+		_, err := s.db.Exec(
+			`INSERT INTO variables (name, value, updated_at) VALUES (?, ?, ?)
+			 ON CONFLICT (name) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+			name, value, time.Now().Unix(),
+		)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения переменной %s: %w", name, err)
+		}
+	}
+	s.values[name] = value
+	return nil
+}
+
+// Delete удаляет переменную
+func (s *VariableStore) Delete(name string) error {
+	name = strings.ToLower(name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		if _, err := s.db.Exec("DELETE FROM variables WHERE name = ?", name); err != nil {
+			return fmt.Errorf("ошибка удаления переменной %s: %w", name, err)
+		}
+	}
+	delete(s.values, name)
+	return nil
+}
+
+// handleVarCommand обрабатывает !var set/get/inc/del/list
+func (b *Bot) handleVarCommand(ctx context.Context, message twitch.PrivateMessage, args []string) {
+	usage := "Использование: !var set <имя> <значение> | get <имя> | inc <имя> [число] | del <имя> | list"
+	if len(args) == 0 {
+		b.respond(ctx, message, usage)
+		return
+	}
+
+	action := strings.ToLower(args[0])
+	if action == "list" {
+		names := b.variables.Names()
+		if len(names) == 0 {
+			b.respond(ctx, message, "Переменных нет")
+			return
+		}
+		b.respond(ctx, message, "Переменные: "+strings.Join(names, ", "))
+		return
+	}
+	if len(args) < 2 {
+		b.respond(ctx, message, usage)
+		return
+	}
+	name := strings.ToLower(args[1])
+
+	switch action {
+	case "set":
+		if len(args) < 3 {
+			b.respond(ctx, message, usage)
+			return
+		}
+		value := strings.Join(args[2:], " ")
+		if err := b.variables.Set(name, value); err != nil {
+			slog.Warn("Ошибка изменения переменной", "error", err, "name", name)
+			b.respond(ctx, message, "Не удалось сохранить переменную")
+			return
+		}
+		b.audit.Record(message.User.Name, AuditVariableSet, name, value)
+		b.respond(ctx, message, fmt.Sprintf("%s = %s", name, value))
+
+	case "get":
+		value, ok := b.variables.Get(name)
+		if !ok {
+			b.respond(ctx, message, fmt.Sprintf("Переменная %s не задана", name))
+			return
+		}
+		b.respond(ctx, message, fmt.Sprintf("%s = %s", name, value))
+
+	case "inc":
+		delta := 1
+		if len(args) > 2 {
+			var err error
+			if delta, err = strconv.Atoi(args[2]); err != nil {
+				b.respond(ctx, message, usage)
+				return
+			}
+		}
+		value, err := b.variables.Add(name, delta)
+		if err != nil {
+			slog.Warn("Ошибка изменения переменной", "error", err, "name", name)
+			b.respond(ctx, message, fmt.Sprintf("Не удалось изменить %s: %s", name, err))
+			return
+		}
+		b.audit.Record(message.User.Name, AuditVariableSet, name, strconv.Itoa(value))
+		b.respond(ctx, message, fmt.Sprintf("%s = %d", name, value))
+
+	case "del":
+		if err := b.variables.Delete(name); err != nil {
+			slog.Warn("Ошибка удаления переменной", "error", err, "name", name)
+			b.respond(ctx, message, "Не удалось удалить переменную")
+			return
+		}
+		b.audit.Record(message.User.Name, AuditVariableDelete, name, "")
+		b.respond(ctx, message, fmt.Sprintf("Переменная %s удалена", name))
+
+	default:
+		b.respond(ctx, message, usage)
+	}
+}
@@ -0,0 +1,90 @@
+// version.go
+package main
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// Версия сборки. Задается при сборке:
+//
+//	go build -ldflags "-X main.version=1.4.0 -X main.commit=$(git rev-parse HEAD) -X main.buildDate=$(date -u +%FT%TZ)"
+//
+// Незаданные значения берутся из сведений о сборке Go (vcs.revision, vcs.time).
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// Сведения о сборке
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// buildInfo возвращает версию сборки: ldflags > сведения о сборке Go
+func buildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if info.Version == "dev" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+		info.Version = build.Main.Version
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildDate == "" {
+				info.BuildDate = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+// String возвращает версию с коротким коммитом: "1.4.0 (a1b2c3d4e5f6)"
+func (info BuildInfo) String() string {
+	text := info.Version
+	if short := info.Commit; short != "" {
+		if len(short) > 12 {
+			short = short[:12]
+		}
+		if info.Modified {
+			short += "-dirty"
+		}
+		text += " (" + short + ")"
+	}
+	return text
+}
+
+// versionText формирует ответ на !version
+func versionText() string {
+	info := buildInfo()
+	text := "twitch-paste-bot " + info.String()
+	if info.BuildDate != "" {
+		text += ", собран " + info.BuildDate
+	}
+	return text
+}
+
+func (s *AdminServer) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, buildInfo())
+}